@@ -0,0 +1,71 @@
+package main
+
+//This is helper class for the asm command, see the asm package
+
+import (
+	asm "github.com/torch2424/chipGo/asm"
+	linemap "github.com/torch2424/chipGo/linemap"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+//Function to assemble a source file, and write the game
+func runAsm() {
+
+	//Assemble our source, errors already say which line they are on
+	program, err := asm.AssembleFile(*asmPath, asm.Options{})
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	//Write our game, next to the source if we weren't given a name
+	output := *asmOutput
+	if output == "" {
+		output = strings.TrimSuffix(*asmPath, filepath.Ext(*asmPath)) + ".ch8"
+	}
+	err = ioutil.WriteFile(output, program.Binary, 0644)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	//Write our listing and symbols, if asked for
+	if *asmListing != "" {
+		writeAsmFile(*asmListing, program.WriteListing)
+	}
+	if *asmSymbols != "" {
+		writeAsmFile(*asmSymbols, program.WriteSymbols)
+	}
+
+	//Write our line map, for debugging the game with --dap
+	if *asmMap {
+		err = program.LineMap().WriteFile(linemap.PathFor(output))
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+
+	print("Assembled ", *asmPath, " to ", output, ", ", len(program.Binary), " bytes\n")
+}
+
+//Function to create a file, and write to it
+func writeAsmFile(path string, write func(writer io.Writer) error) {
+	file, err := os.Create(path)
+	if err == nil {
+		err = write(file)
+		closeErr := file.Close()
+		if err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,278 @@
+package asm
+
+/*
+   Assembler for Chip-8, SCHIP, and XO-CHIP games
+
+   Uses the Cowgod mnemonics (http://devernay.free.fr/hacks/chip8/C8TECH10.HTM),
+   the same syntax chipgo disasm --syntax cowgod writes
+
+   Source is one statement per line, comments start with a ;
+
+	   :include "sprites.asm"      ; Paste in another source file
+	   SPEED = 4                   ; Constants, also SPEED EQU 4 or :const SPEED 4
+	   :macro move reg, amount     ; Macros, parameters are replaced by the arguments
+	       ADD reg, amount
+	   :endm
+	   main:                       ; Labels
+	       LD V0, SPEED * 2        ; Expressions, with | ^ & << >> + - * / % ~ and ( )
+	       move V0, 1
+	       LD I, ball
+	       DRW V0, V1, ball_end - ball
+	       JP $                    ; $ is the address of this instruction
+	   ball:
+	       DB 0b11000000, $C0      ; Data, also DW for 16 bit words, DS to reserve space
+	   ball_end:
+	       ORG 0x300               ; Move to an address, and ALIGN 2 to line up words
+*/
+
+//Imports
+import (
+	linemap "github.com/torch2424/chipGo/linemap"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+//Where games are loaded into memory
+const romStart = 0x200
+
+//Error in our source, with where it happened
+type Error struct {
+	File    string
+	Line    int
+	Column  int
+	Message string
+}
+
+func (err *Error) Error() string {
+	if err.Column > 0 {
+		return fmt.Sprintf("%s:%d:%d: %s", err.File, err.Line, err.Column, err.Message)
+	}
+	return fmt.Sprintf("%s:%d: %s", err.File, err.Line, err.Message)
+}
+
+//ErrorList is every error we found while assembling
+type ErrorList []*Error
+
+func (errs ErrorList) Error() string {
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+//Options for assembling
+type Options struct {
+
+	//Function to read included files. Uses ioutil.ReadFile if nil
+	ReadFile func(path string) ([]byte, error)
+}
+
+//ListingLine is a line of source, and the bytes it assembled to
+type ListingLine struct {
+	Address int
+	Bytes   []byte
+
+	File   string
+	Line   int
+	Source string
+
+	//True if this line came from a macro
+	Macro bool
+}
+
+//Symbol is a label or constant, and its value
+type Symbol struct {
+	Name  string
+	Value int
+	Label bool
+}
+
+//Program is an assembled game
+type Program struct {
+	Binary  []byte
+	Listing []ListingLine
+	Symbols []Symbol
+}
+
+//Function to assemble a source file
+func AssembleFile(path string, options Options) (*Program, error) {
+	if options.ReadFile == nil {
+		options.ReadFile = ioutil.ReadFile
+	}
+
+	source, err := options.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return Assemble(path, source, options)
+}
+
+//Function to assemble source, fileName is used for errors and to find included files
+func Assemble(fileName string, source []byte, options Options) (*Program, error) {
+	if options.ReadFile == nil {
+		options.ReadFile = ioutil.ReadFile
+	}
+
+	assembler := newAssembler(options)
+
+	//Read our statements, then find where everything goes, then write out our bytes
+	//Lines with errors are skipped, so we can keep going and report every error at once
+	assembler.readSource(fileName, string(source), 0)
+	assembler.placeStatements()
+	assembler.encodeStatements()
+	if len(assembler.errors) > 0 {
+		return nil, assembler.errors
+	}
+
+	return assembler.program(), nil
+}
+
+//Function to write a listing, each line's address, bytes, and source
+func (program *Program) WriteListing(writer io.Writer) error {
+
+	file := ""
+	for _, line := range program.Listing {
+
+		//Note where each file starts, so included lines can be found
+		if line.File != file {
+			file = line.File
+			_, err := fmt.Fprintf(writer, "%24s; %s\n", "", file)
+			if err != nil {
+				return err
+			}
+		}
+
+		//Four bytes per line, any more go on the lines after
+		address := ""
+		if len(line.Bytes) > 0 {
+			address = fmt.Sprintf("%04X", line.Address)
+		}
+
+		marker := " "
+		if line.Macro {
+			marker = "+"
+		}
+
+		for i := 0; i == 0 || i < len(line.Bytes); i += 4 {
+
+			//Padding from ORG, ALIGN, and DS would be pages of the same byte, so write it once
+			if i > 0 && repeats(line.Bytes[i-1:]) && len(line.Bytes)-i > 4 {
+				_, err := fmt.Fprintf(writer, "%04X  %02X x %d\n", line.Address+i, line.Bytes[i], len(line.Bytes)-i)
+				if err != nil {
+					return err
+				}
+				break
+			}
+
+			hexBytes := ""
+			for j := i; j < len(line.Bytes) && j < i+4; j++ {
+				hexBytes += fmt.Sprintf("%02X ", line.Bytes[j])
+			}
+
+			var err error
+			if i == 0 {
+				_, err = fmt.Fprintf(writer, "%-4s  %-12s %s%5d  %s\n", address, hexBytes, marker, line.Line, line.Source)
+			} else {
+				_, err = fmt.Fprintf(writer, "%04X  %-12s\n", line.Address+i, hexBytes)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+//Function to check if every byte is the same
+func repeats(values []byte) bool {
+	for _, value := range values {
+		if value != values[0] {
+			return false
+		}
+	}
+	return true
+}
+
+//Function to write the symbol map, each label and constant and its value, sorted by value
+func (program *Program) WriteSymbols(writer io.Writer) error {
+
+	for _, symbol := range program.Symbols {
+		kind := "const"
+		if symbol.Label {
+			kind = "label"
+		}
+
+		_, err := fmt.Fprintf(writer, "0x%04X %s %s\n", symbol.Value&0xFFFF, kind, symbol.Name)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//Function to build a line map, the line each instruction and byte came from, and every label
+//Debuggers read this to show source instead of addresses
+func (program *Program) LineMap() *linemap.Map {
+	lines := make([]linemap.Line, 0, len(program.Listing))
+	for _, line := range program.Listing {
+		if len(line.Bytes) > 0 {
+			lines = append(lines, linemap.Line{Address: line.Address, File: line.File, Line: line.Line})
+		}
+	}
+
+	labels := make([]linemap.Label, 0)
+	for _, symbol := range program.Symbols {
+		if symbol.Label {
+			labels = append(labels, linemap.Label{Name: symbol.Name, Address: symbol.Value})
+		}
+	}
+
+	return linemap.New(lines, labels)
+}
+
+//Function to build our program, once everything is assembled
+func (assembler *assembler) program() *Program {
+
+	program := &Program{Binary: make([]byte, 0)}
+
+	for _, statement := range assembler.statements {
+		program.Binary = append(program.Binary, statement.bytes...)
+		program.Listing = append(program.Listing, ListingLine{
+			Address: statement.address,
+			Bytes:   statement.bytes,
+			File:    statement.line.file,
+			Line:    statement.line.number,
+			Source:  statement.line.text,
+			Macro:   statement.line.macro != "",
+		})
+	}
+
+	for name, symbol := range assembler.scope.symbols {
+		value, _ := assembler.scope.lookup(name)
+		program.Symbols = append(program.Symbols, Symbol{Name: name, Value: value, Label: symbol.label})
+	}
+	sort.Slice(program.Symbols, func(i, j int) bool {
+		if program.Symbols[i].Value != program.Symbols[j].Value {
+			return program.Symbols[i].Value < program.Symbols[j].Value
+		}
+		return program.Symbols[i].Name < program.Symbols[j].Name
+	})
+
+	return program
+}
+
+//Function to find the path of an included file, relative to the file including it
+func includePath(fromFile string, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(filepath.Dir(fromFile), path)
+}
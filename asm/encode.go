@@ -0,0 +1,383 @@
+package asm
+
+/*
+   Assembler for Chip-8, SCHIP, and XO-CHIP games
+
+   Uses the Cowgod mnemonics (http://devernay.free.fr/hacks/chip8/C8TECH10.HTM),
+   the same syntax chipgo disasm --syntax cowgod writes
+*/
+
+//This is helper class for placing statements in memory, and turning them into bytes
+
+//Imports
+import (
+	"fmt"
+	"strings"
+)
+
+//The last address an XO-CHIP game can use
+const maxAddress = 0xFFFF
+
+//How an instruction is written, its opCode and what each operand fills in
+//Each operand has a letter: x and y are registers, a is a 12 bit address, b a byte,
+//n a nibble, p a nibble in place of the X register, l a 16 bit long address after the opCode,
+//0 must be V0, and - is a keyword
+type encoding struct {
+	opCode uint16
+	fields string
+}
+
+//Every instruction we know, by mnemonic and operand kinds
+//Operand kinds are V for registers, n for values, or the keyword itself
+var instructions = map[string]encoding{
+	"SYS n":      {0x0000, "a"},
+	"CLS":        {0x00E0, ""},
+	"RET":        {0x00EE, ""},
+	"SCD n":      {0x00C0, "n"},
+	"SCU n":      {0x00D0, "n"},
+	"SCR":        {0x00FB, ""},
+	"SCL":        {0x00FC, ""},
+	"EXIT":       {0x00FD, ""},
+	"LOW":        {0x00FE, ""},
+	"HIGH":       {0x00FF, ""},
+	"JP n":       {0x1000, "a"},
+	"JP V,n":     {0xB000, "0a"},
+	"CALL n":     {0x2000, "a"},
+	"SE V,n":     {0x3000, "xb"},
+	"SNE V,n":    {0x4000, "xb"},
+	"SE V,V":     {0x5000, "xy"},
+	"SAVE V,V":   {0x5002, "xy"},
+	"LOAD V,V":   {0x5003, "xy"},
+	"LD V,n":     {0x6000, "xb"},
+	"ADD V,n":    {0x7000, "xb"},
+	"LD V,V":     {0x8000, "xy"},
+	"OR V,V":     {0x8001, "xy"},
+	"AND V,V":    {0x8002, "xy"},
+	"XOR V,V":    {0x8003, "xy"},
+	"ADD V,V":    {0x8004, "xy"},
+	"SUB V,V":    {0x8005, "xy"},
+	"SHR V":      {0x8006, "x"},
+	"SHR V,V":    {0x8006, "xy"},
+	"SUBN V,V":   {0x8007, "xy"},
+	"SHL V":      {0x800E, "x"},
+	"SHL V,V":    {0x800E, "xy"},
+	"SNE V,V":    {0x9000, "xy"},
+	"LD I,n":     {0xA000, "-a"},
+	"RND V,n":    {0xC000, "xb"},
+	"DRW V,V,n":  {0xD000, "xyn"},
+	"SKP V":      {0xE09E, "x"},
+	"SKNP V":     {0xE0A1, "x"},
+	"LD I,LONG":  {0xF000, "-l"},
+	"PLANE n":    {0xF001, "p"},
+	"AUDIO":      {0xF002, ""},
+	"LD V,DT":    {0xF007, "x-"},
+	"LD V,K":     {0xF00A, "x-"},
+	"LD DT,V":    {0xF015, "-x"},
+	"LD ST,V":    {0xF018, "-x"},
+	"ADD I,V":    {0xF01E, "-x"},
+	"LD F,V":     {0xF029, "-x"},
+	"LD HF,V":    {0xF030, "-x"},
+	"LD B,V":     {0xF033, "-x"},
+	"LD PITCH,V": {0xF03A, "-x"},
+	"LD [I],V":   {0xF055, "-x"},
+	"LD V,[I]":   {0xF065, "x-"},
+	"LD R,V":     {0xF075, "-x"},
+	"LD V,R":     {0xF085, "x-"},
+}
+
+//Keywords that can be operands, anything else is a value
+var keywords = map[string]bool{"I": true, "DT": true, "ST": true, "K": true, "F": true, "HF": true, "B": true, "R": true, "PITCH": true}
+
+//An operand, once we know what kind it is
+type operandValue struct {
+	kind     string
+	register int
+	value    expression
+	column   int
+}
+
+//Function to find where each statement goes in memory, and define our labels
+func (assembler *assembler) placeStatements() {
+
+	address := romStart
+	for _, current := range assembler.statements {
+		current.address = address
+		assembler.scope.address = address
+
+		if current.label != "" {
+			assembler.define(current.line, current.label, current.labelColumn, &symbol{value: numberExpression{value: address}, address: address, label: true, line: current.line})
+		}
+
+		switch current.kind {
+		case statementConstant:
+			//$ in a constant is the address it was defined at
+			assembler.scope.symbols[current.name].address = address
+		case statementInstruction:
+			current.size = 2
+			if isLongLoad(current) {
+				current.size = 4
+			}
+		case statementBytes:
+			for _, data := range current.operands {
+				if len(data.tokens) == 1 && data.tokens[0].kind == tokenString {
+					current.size += len(data.tokens[0].text)
+				} else {
+					current.size++
+				}
+			}
+		case statementWords:
+			current.size = 2 * len(current.operands)
+		case statementSpace:
+			if len(current.operands) < 1 || len(current.operands) > 2 {
+				assembler.addError(current.line, current.nameColumn, "DS needs a size, and can have a fill byte")
+				break
+			}
+			size, ok := assembler.evaluateOperand(current, current.operands[0], 0, maxAddress)
+			if ok {
+				current.size = size
+			}
+		case statementOrg:
+			if len(current.operands) != 1 {
+				assembler.addError(current.line, current.nameColumn, "ORG needs an address")
+				break
+			}
+			target, ok := assembler.evaluateOperand(current, current.operands[0], romStart, maxAddress+1)
+			if !ok {
+				break
+			}
+			if target < address {
+				assembler.addError(current.line, current.operands[0].column, fmt.Sprintf("ORG 0x%X is before the current address 0x%X", target, address))
+				break
+			}
+			current.size = target - address
+		case statementAlign:
+			if len(current.operands) != 1 {
+				assembler.addError(current.line, current.nameColumn, "ALIGN needs a size")
+				break
+			}
+			alignment, ok := assembler.evaluateOperand(current, current.operands[0], 1, maxAddress)
+			if ok && address%alignment != 0 {
+				current.size = alignment - address%alignment
+			}
+		}
+
+		address += current.size
+		if address > maxAddress+1 {
+			assembler.addError(current.line, 1, fmt.Sprintf("program is too big, it ends at 0x%X", address))
+			return
+		}
+	}
+}
+
+//Function to check if an instruction is the four byte LD I, LONG address
+func isLongLoad(current *statement) bool {
+	return current.name == "LD" && len(current.operands) == 2 &&
+		len(current.operands[1].tokens) > 0 && strings.ToUpper(current.operands[1].tokens[0].text) == "LONG"
+}
+
+//Function to write out the bytes of each statement, now that we know every label
+func (assembler *assembler) encodeStatements() {
+
+	for _, current := range assembler.statements {
+		assembler.scope.address = current.address
+
+		switch current.kind {
+		case statementInstruction:
+			assembler.encodeInstruction(current)
+		case statementBytes:
+			for _, data := range current.operands {
+				if len(data.tokens) == 1 && data.tokens[0].kind == tokenString {
+					current.bytes = append(current.bytes, []byte(data.tokens[0].text)...)
+					continue
+				}
+				value, _ := assembler.evaluateOperand(current, data, -128, 255)
+				current.bytes = append(current.bytes, byte(value))
+			}
+		case statementWords:
+			for _, data := range current.operands {
+				value, _ := assembler.evaluateOperand(current, data, -32768, 0xFFFF)
+				current.bytes = append(current.bytes, byte(value>>8), byte(value))
+			}
+		case statementSpace:
+			fill := 0
+			if len(current.operands) == 2 {
+				fill, _ = assembler.evaluateOperand(current, current.operands[1], -128, 255)
+			}
+			current.bytes = make([]byte, current.size)
+			for i := range current.bytes {
+				current.bytes[i] = byte(fill)
+			}
+		case statementOrg, statementAlign:
+			current.bytes = make([]byte, current.size)
+		}
+	}
+}
+
+//Function to turn an instruction into its opCode
+func (assembler *assembler) encodeInstruction(current *statement) {
+
+	//Work out what kind each operand is, so we can find the instruction
+	values := make([]operandValue, len(current.operands))
+	kinds := make([]string, len(current.operands))
+	for i, next := range current.operands {
+		value, ok := assembler.readOperand(current, next)
+		if !ok {
+			return
+		}
+		values[i] = value
+		kinds[i] = value.kind
+	}
+
+	signature := current.name
+	if len(kinds) > 0 {
+		signature = signature + " " + strings.Join(kinds, ",")
+	}
+
+	found, known := instructions[signature]
+	if !known {
+		if !isMnemonic(current.name) {
+			assembler.addError(current.line, current.nameColumn, fmt.Sprintf("unknown instruction %s", current.name))
+		} else {
+			assembler.addError(current.line, current.nameColumn, fmt.Sprintf("%s can't take the operands %s", current.name, strings.Join(kinds, ", ")))
+		}
+		return
+	}
+
+	opCode := found.opCode
+	var long uint16
+	for i, field := range found.fields {
+		value := values[i]
+		switch field {
+		case 'x':
+			opCode = opCode | uint16(value.register)<<8
+		case 'y':
+			opCode = opCode | uint16(value.register)<<4
+		case '0':
+			if value.register != 0 {
+				assembler.addError(current.line, value.column, "JP with an offset only works with V0")
+				return
+			}
+		case 'a':
+			number, ok := assembler.evaluate(current, value.value, 0, 0xFFF)
+			if !ok {
+				return
+			}
+			opCode = opCode | uint16(number)
+		case 'b':
+			number, ok := assembler.evaluate(current, value.value, -128, 255)
+			if !ok {
+				return
+			}
+			opCode = opCode | uint16(number&0xFF)
+		case 'n':
+			number, ok := assembler.evaluate(current, value.value, 0, 15)
+			if !ok {
+				return
+			}
+			opCode = opCode | uint16(number)
+		case 'p':
+			number, ok := assembler.evaluate(current, value.value, 0, 15)
+			if !ok {
+				return
+			}
+			opCode = opCode | uint16(number)<<8
+		case 'l':
+			number, ok := assembler.evaluate(current, value.value, 0, maxAddress)
+			if !ok {
+				return
+			}
+			long = uint16(number)
+		}
+	}
+
+	current.bytes = []byte{byte(opCode >> 8), byte(opCode)}
+	if current.size == 4 {
+		current.bytes = append(current.bytes, byte(long>>8), byte(long))
+	}
+}
+
+//Function to check if a name is the mnemonic of any instruction
+func isMnemonic(name string) bool {
+	for signature := range instructions {
+		if strings.SplitN(signature, " ", 2)[0] == name {
+			return true
+		}
+	}
+	return false
+}
+
+//Function to find what kind an operand is, a register, a keyword, LONG, or a value
+func (assembler *assembler) readOperand(current *statement, next operand) (operandValue, bool) {
+
+	tokens := next.tokens
+	if len(tokens) == 0 {
+		assembler.addError(current.line, next.column, "missing operand")
+		return operandValue{}, false
+	}
+
+	if len(tokens) == 1 && tokens[0].kind == tokenIdentifier {
+		name := strings.ToUpper(tokens[0].text)
+		if len(name) == 2 && name[0] == 'V' && isHexDigit(name[1]) {
+			register, _ := parseNumber("0x" + name[1:])
+			return operandValue{kind: "V", register: register, column: next.column}, true
+		}
+		if keywords[name] {
+			return operandValue{kind: name, column: next.column}, true
+		}
+	}
+
+	if len(tokens) == 3 && tokens[0].text == "[" && strings.ToUpper(tokens[1].text) == "I" && tokens[2].text == "]" {
+		return operandValue{kind: "[I]", column: next.column}, true
+	}
+
+	kind := "n"
+	if tokens[0].kind == tokenIdentifier && strings.ToUpper(tokens[0].text) == "LONG" {
+		kind = "LONG"
+		tokens = tokens[1:]
+	}
+
+	value, err := parseExpression(tokens)
+	if err != nil {
+		column := err.Column
+		if column == 0 {
+			column = next.column
+		}
+		assembler.addError(current.line, column, err.Message)
+		return operandValue{}, false
+	}
+
+	return operandValue{kind: kind, value: value, column: next.column}, true
+}
+
+//Function to read an operand as a value, and check it is in range
+func (assembler *assembler) evaluateOperand(current *statement, next operand, lowest int, highest int) (int, bool) {
+
+	value, err := parseExpression(next.tokens)
+	if err != nil {
+		column := err.Column
+		if column == 0 {
+			column = next.column
+		}
+		assembler.addError(current.line, column, err.Message)
+		return 0, false
+	}
+
+	return assembler.evaluate(current, value, lowest, highest)
+}
+
+//Function to evaluate an expression, and check it is in range
+func (assembler *assembler) evaluate(current *statement, value expression, lowest int, highest int) (int, bool) {
+
+	number, err := value.evaluate(&assembler.scope)
+	if err != nil {
+		assembler.addError(current.line, value.position(), err.Error())
+		return 0, false
+	}
+
+	if number < lowest || number > highest {
+		assembler.addError(current.line, value.position(), fmt.Sprintf("value %d is out of range, must be from %d to %d", number, lowest, highest))
+		return 0, false
+	}
+
+	return number, true
+}
@@ -0,0 +1,244 @@
+package asm
+
+/*
+   Assembler for Chip-8, SCHIP, and XO-CHIP games
+
+   Uses the Cowgod mnemonics (http://devernay.free.fr/hacks/chip8/C8TECH10.HTM),
+   the same syntax chipgo disasm --syntax cowgod writes
+*/
+
+//This is helper class for expressions, e.g. sprites + 5 * 2
+//Operators, from loosest to tightest: |, ^, &, << >>, + -, * / %, and unary - ~
+
+//Imports
+import (
+	"fmt"
+)
+
+//An expression, evaluated once we know where all of our labels are
+type expression interface {
+	evaluate(scope *scope) (int, error)
+	//Column the expression starts at, for errors
+	position() int
+}
+
+//A number
+type numberExpression struct {
+	value  int
+	column int
+}
+
+//A label or constant
+type nameExpression struct {
+	name   string
+	column int
+}
+
+//The current address, written as $
+type addressExpression struct {
+	column int
+}
+
+//An operator with two sides, e.g. a + b
+type binaryExpression struct {
+	operator string
+	left     expression
+	right    expression
+	column   int
+}
+
+//An operator with one side, e.g. -a
+type unaryExpression struct {
+	operator string
+	operand  expression
+	column   int
+}
+
+func (number numberExpression) evaluate(scope *scope) (int, error) {
+	return number.value, nil
+}
+
+func (name nameExpression) evaluate(scope *scope) (int, error) {
+	return scope.lookup(name.name)
+}
+
+func (address addressExpression) evaluate(scope *scope) (int, error) {
+	return scope.address, nil
+}
+
+func (binary binaryExpression) evaluate(scope *scope) (int, error) {
+	left, err := binary.left.evaluate(scope)
+	if err != nil {
+		return 0, err
+	}
+	right, err := binary.right.evaluate(scope)
+	if err != nil {
+		return 0, err
+	}
+
+	switch binary.operator {
+	case "|":
+		return left | right, nil
+	case "^":
+		return left ^ right, nil
+	case "&":
+		return left & right, nil
+	case "<<":
+		return left << uint(right&31), nil
+	case ">>":
+		return left >> uint(right&31), nil
+	case "+":
+		return left + right, nil
+	case "-":
+		return left - right, nil
+	case "*":
+		return left * right, nil
+	case "/", "%":
+		if right == 0 {
+			return 0, fmt.Errorf("division by zero")
+		}
+		if binary.operator == "/" {
+			return left / right, nil
+		}
+		return left % right, nil
+	}
+
+	return 0, fmt.Errorf("unknown operator %s", binary.operator)
+}
+
+func (unary unaryExpression) evaluate(scope *scope) (int, error) {
+	value, err := unary.operand.evaluate(scope)
+	if err != nil {
+		return 0, err
+	}
+
+	if unary.operator == "-" {
+		return -value, nil
+	}
+	return ^value, nil
+}
+
+func (number numberExpression) position() int   { return number.column }
+func (name nameExpression) position() int       { return name.column }
+func (address addressExpression) position() int { return address.column }
+func (binary binaryExpression) position() int   { return binary.column }
+func (unary unaryExpression) position() int     { return unary.column }
+
+//Our binary operators, loosest first
+var precedence = [][]string{
+	{"|"},
+	{"^"},
+	{"&"},
+	{"<<", ">>"},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+//Parser for expressions, reads from a list of tokens
+type expressionParser struct {
+	tokens []token
+	index  int
+}
+
+//Function to read a whole list of tokens as one expression
+func parseExpression(tokens []token) (expression, *Error) {
+	if len(tokens) == 0 {
+		return nil, &Error{Message: "missing value"}
+	}
+
+	parser := expressionParser{tokens: tokens}
+	value, err := parser.parseLevel(0)
+	if err != nil {
+		return nil, err
+	}
+
+	if parser.index < len(tokens) {
+		return nil, &Error{Column: tokens[parser.index].column, Message: fmt.Sprintf("unexpected %q", tokens[parser.index].text)}
+	}
+
+	return value, nil
+}
+
+//Function to read the binary operators at a precedence level
+func (parser *expressionParser) parseLevel(level int) (expression, *Error) {
+	if level >= len(precedence) {
+		return parser.parseUnary()
+	}
+
+	left, err := parser.parseLevel(level + 1)
+	if err != nil {
+		return nil, err
+	}
+
+	for parser.index < len(parser.tokens) {
+		next := parser.tokens[parser.index]
+		if next.kind != tokenPunctuation || !contains(precedence[level], next.text) {
+			break
+		}
+		parser.index++
+
+		right, err := parser.parseLevel(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = binaryExpression{operator: next.text, left: left, right: right, column: left.position()}
+	}
+
+	return left, nil
+}
+
+//Function to read a value, with any unary operators in front of it
+func (parser *expressionParser) parseUnary() (expression, *Error) {
+	if parser.index >= len(parser.tokens) {
+		column := 0
+		if len(parser.tokens) > 0 {
+			last := parser.tokens[len(parser.tokens)-1]
+			column = last.column + len(last.text)
+		}
+		return nil, &Error{Column: column, Message: "missing value"}
+	}
+
+	next := parser.tokens[parser.index]
+	parser.index++
+
+	switch next.kind {
+	case tokenNumber:
+		return numberExpression{value: next.value, column: next.column}, nil
+	case tokenIdentifier:
+		return nameExpression{name: next.text, column: next.column}, nil
+	case tokenPunctuation:
+		switch next.text {
+		case "-", "~":
+			operand, err := parser.parseUnary()
+			if err != nil {
+				return nil, err
+			}
+			return unaryExpression{operator: next.text, operand: operand, column: next.column}, nil
+		case "+":
+			return parser.parseUnary()
+		case "$":
+			return addressExpression{column: next.column}, nil
+		case "(":
+			value, err := parser.parseLevel(0)
+			if err != nil {
+				return nil, err
+			}
+			if parser.index >= len(parser.tokens) || parser.tokens[parser.index].text != ")" {
+				return nil, &Error{Column: next.column, Message: "missing )"}
+			}
+			parser.index++
+			return value, nil
+		}
+	}
+
+	return nil, &Error{Column: next.column, Message: fmt.Sprintf("unexpected %q", next.text)}
+}
+
+func contains(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,169 @@
+package asm
+
+/*
+   Assembler for Chip-8, SCHIP, and XO-CHIP games
+
+   Uses the Cowgod mnemonics (http://devernay.free.fr/hacks/chip8/C8TECH10.HTM),
+   the same syntax chipgo disasm --syntax cowgod writes
+*/
+
+//This is helper class for splitting a line of source into tokens
+
+//Imports
+import (
+	"fmt"
+	"strings"
+)
+
+//Kinds of tokens in our source
+type tokenKind int
+
+const (
+	tokenIdentifier tokenKind = iota
+	tokenNumber
+	tokenString
+	tokenPunctuation
+)
+
+//A single token, and the column it starts at (counting from 1)
+type token struct {
+	kind   tokenKind
+	text   string
+	value  int
+	column int
+}
+
+//Punctuation we understand, longest first so << is not read as two <
+var punctuation = []string{"<<", ">>", ",", ":", "[", "]", "(", ")", "+", "-", "*", "/", "%", "&", "|", "^", "~", "=", "$"}
+
+//Function to check if a character can start an identifier
+func isIdentifierStart(character byte) bool {
+	return character == '_' || character == '.' || character == '@' ||
+		(character >= 'a' && character <= 'z') || (character >= 'A' && character <= 'Z')
+}
+
+//Function to check if a character can be inside an identifier
+func isIdentifierPart(character byte) bool {
+	return isIdentifierStart(character) || (character >= '0' && character <= '9')
+}
+
+//Function to check if a character is a hex digit
+func isHexDigit(character byte) bool {
+	return (character >= '0' && character <= '9') || (character >= 'a' && character <= 'f') || (character >= 'A' && character <= 'F')
+}
+
+//Function to split a line into tokens, comments start with a ; and run to the end of the line
+//Returns the column of the bad character if the line can't be read
+func tokenize(line string) ([]token, int, error) {
+
+	tokens := make([]token, 0)
+
+	for i := 0; i < len(line); {
+		character := line[i]
+		column := i + 1
+
+		switch {
+		case character == ' ' || character == '\t' || character == '\r':
+			i++
+		case character == ';':
+			//Comment, ignore the rest of the line
+			return tokens, 0, nil
+		case character >= '0' && character <= '9':
+			//Numbers, decimal, 0x hex, or 0b binary
+			end := i
+			for end < len(line) && isIdentifierPart(line[end]) {
+				end++
+			}
+			value, err := parseNumber(line[i:end])
+			if err != nil {
+				return nil, column, err
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: line[i:end], value: value, column: column})
+			i = end
+		case character == '$' && i+1 < len(line) && isHexDigit(line[i+1]):
+			//$ hex numbers, a lone $ is the current address
+			end := i + 1
+			for end < len(line) && isHexDigit(line[end]) {
+				end++
+			}
+			value, err := parseNumber("0x" + line[i+1:end])
+			if err != nil {
+				return nil, column, err
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: line[i:end], value: value, column: column})
+			i = end
+		case character == '\'':
+			//Character, e.g. 'A'
+			if i+2 >= len(line) || line[i+2] != '\'' {
+				return nil, column, fmt.Errorf("unterminated character")
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: line[i : i+3], value: int(line[i+1]), column: column})
+			i += 3
+		case character == '"':
+			//String, used by DB and :include
+			end := strings.IndexByte(line[i+1:], '"')
+			if end < 0 {
+				return nil, column, fmt.Errorf("unterminated string")
+			}
+			tokens = append(tokens, token{kind: tokenString, text: line[i+1 : i+1+end], column: column})
+			i += end + 2
+		case isIdentifierStart(character):
+			end := i
+			for end < len(line) && isIdentifierPart(line[end]) {
+				end++
+			}
+			tokens = append(tokens, token{kind: tokenIdentifier, text: line[i:end], column: column})
+			i = end
+		default:
+			found := false
+			for _, symbol := range punctuation {
+				if strings.HasPrefix(line[i:], symbol) {
+					tokens = append(tokens, token{kind: tokenPunctuation, text: symbol, column: column})
+					i += len(symbol)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, column, fmt.Errorf("unexpected character %q", character)
+			}
+		}
+	}
+
+	return tokens, 0, nil
+}
+
+//Function to read a number, decimal, 0x hex, or 0b binary
+func parseNumber(text string) (int, error) {
+
+	base := 10
+	digits := text
+	lower := strings.ToLower(text)
+	if strings.HasPrefix(lower, "0x") {
+		base = 16
+		digits = text[2:]
+	} else if strings.HasPrefix(lower, "0b") {
+		base = 2
+		digits = text[2:]
+	}
+
+	if digits == "" {
+		return 0, fmt.Errorf("bad number %q", text)
+	}
+
+	value := 0
+	for i := 0; i < len(digits); i++ {
+		digit := strings.IndexByte("0123456789abcdef", lower[len(text)-len(digits)+i])
+		if digit < 0 || digit >= base {
+			return 0, fmt.Errorf("bad number %q", text)
+		}
+		value = value*base + digit
+
+		//Nothing in a Chip-8 game is bigger than 16 bits, stop before we overflow
+		if value > 0xFFFFFF {
+			return 0, fmt.Errorf("number %q is too big", text)
+		}
+	}
+
+	return value, nil
+}
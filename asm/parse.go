@@ -0,0 +1,508 @@
+package asm
+
+/*
+   Assembler for Chip-8, SCHIP, and XO-CHIP games
+
+   Uses the Cowgod mnemonics (http://devernay.free.fr/hacks/chip8/C8TECH10.HTM),
+   the same syntax chipgo disasm --syntax cowgod writes
+*/
+
+//This is helper class for reading source into statements, following includes and expanding macros
+
+//Imports
+import (
+	"fmt"
+	"strings"
+)
+
+//How deep includes and macros can go, so a file including itself doesn't run forever
+const maxDepth = 32
+
+//Stop after this many errors, anything after is usually caused by the first ones
+const maxErrors = 50
+
+//A line of source, and where it came from
+type sourceLine struct {
+	file   string
+	number int
+	text   string
+
+	//If this line came from a macro, where the macro was used
+	macro string
+}
+
+//Kinds of statements
+type statementKind int
+
+const (
+	//Blank lines, comments, and labels on their own
+	statementEmpty statementKind = iota
+	statementConstant
+	statementInstruction
+	statementBytes
+	statementWords
+	statementSpace
+	statementOrg
+	statementAlign
+)
+
+//A single statement, one per line of source
+type statement struct {
+	line *sourceLine
+	kind statementKind
+
+	//Label defined on this line
+	label       string
+	labelColumn int
+
+	//Constant name, or instruction mnemonic
+	name       string
+	nameColumn int
+
+	//Comma separated operands
+	operands []operand
+
+	//Where the statement goes in memory, and what it assembled to
+	address int
+	size    int
+	bytes   []byte
+}
+
+//An operand, the tokens between commas
+type operand struct {
+	tokens []token
+	column int
+}
+
+//A macro, and the lines it expands to
+type macro struct {
+	name       string
+	parameters []string
+	body       []sourceLine
+}
+
+//A label or constant
+type symbol struct {
+	value      expression
+	address    int
+	label      bool
+	line       *sourceLine
+	resolving  bool
+	resolved   bool
+	finalValue int
+}
+
+//Our symbol table, and the address of the statement we are on (for $)
+type scope struct {
+	symbols map[string]*symbol
+	address int
+}
+
+//Our assembler state
+type assembler struct {
+	options    Options
+	statements []*statement
+	macros     map[string]*macro
+	scope      scope
+	errors     ErrorList
+
+	//Counts our macro expansions, for \@ unique labels
+	expansions int
+}
+
+func newAssembler(options Options) *assembler {
+	return &assembler{
+		options: options,
+		macros:  make(map[string]*macro),
+		scope:   scope{symbols: make(map[string]*symbol)},
+	}
+}
+
+//Function to find the value of a label or constant
+func (scope *scope) lookup(name string) (int, error) {
+	found, defined := scope.symbols[name]
+	if !defined {
+		return 0, fmt.Errorf("undefined symbol %s", name)
+	}
+
+	if found.resolved {
+		return found.finalValue, nil
+	}
+	if found.resolving {
+		return 0, fmt.Errorf("constant %s is defined using itself", name)
+	}
+
+	//Constants are worked out when they are first used, so they can use labels defined after them
+	found.resolving = true
+	address := scope.address
+	scope.address = found.address
+	value, err := found.value.evaluate(scope)
+	scope.address = address
+	found.resolving = false
+	if err != nil {
+		return 0, err
+	}
+
+	found.resolved = true
+	found.finalValue = value
+	return value, nil
+}
+
+//Function to record an error
+func (assembler *assembler) addError(line *sourceLine, column int, message string) {
+	if len(assembler.errors) >= maxErrors {
+		return
+	}
+
+	if line.macro != "" {
+		message = message + " (" + line.macro + ")"
+	}
+	assembler.errors = append(assembler.errors, &Error{File: line.file, Line: line.number, Column: column, Message: message})
+}
+
+//Function to read a file of source into statements
+func (assembler *assembler) readSource(fileName string, source string, depth int) {
+
+	text := strings.Split(strings.Replace(source, "\r\n", "\n", -1), "\n")
+	lines := make([]sourceLine, len(text))
+	for i := range text {
+		lines[i] = sourceLine{file: fileName, number: i + 1, text: text[i]}
+	}
+
+	assembler.readLines(lines, depth)
+}
+
+//Function to read lines of source into statements
+func (assembler *assembler) readLines(lines []sourceLine, depth int) {
+
+	for i := 0; i < len(lines); i++ {
+		line := &lines[i]
+
+		tokens, column, err := tokenize(line.text)
+		if err != nil {
+			assembler.addError(line, column, err.Error())
+			continue
+		}
+
+		//Directives start with a colon, e.g. :include
+		if len(tokens) >= 2 && tokens[0].text == ":" && tokens[1].kind == tokenIdentifier {
+			directive := strings.ToLower(tokens[1].text)
+			switch directive {
+			case "include":
+				//Keep the :include line in our listing, before the lines it includes
+				assembler.statements = append(assembler.statements, &statement{line: line})
+				assembler.include(line, tokens[2:], depth)
+				continue
+			case "macro":
+				//Collect the lines up to :endm
+				end := i + 1
+				for end < len(lines) && !isEndMacro(lines[end].text) {
+					end++
+				}
+				if end >= len(lines) {
+					assembler.addError(line, tokens[0].column, "missing :endm")
+				}
+				assembler.defineMacro(line, tokens[2:], lines[i+1:minimum(end, len(lines))])
+				assembler.statements = append(assembler.statements, &statement{line: line})
+				i = end
+				continue
+			case "endm":
+				assembler.addError(line, tokens[0].column, ":endm without :macro")
+			case "const":
+				if len(tokens) < 4 || tokens[2].kind != tokenIdentifier {
+					assembler.addError(line, tokens[0].column, ":const needs a name and a value")
+					break
+				}
+				assembler.constant(line, tokens[2], tokens[3:])
+				continue
+			default:
+				assembler.addError(line, tokens[0].column, fmt.Sprintf("unknown directive :%s", tokens[1].text))
+			}
+
+			assembler.statements = append(assembler.statements, &statement{line: line})
+			continue
+		}
+
+		assembler.readStatement(line, tokens, depth)
+	}
+}
+
+//Function to check if a line ends a macro
+func isEndMacro(text string) bool {
+	tokens, _, err := tokenize(text)
+	return err == nil && len(tokens) >= 2 && tokens[0].text == ":" && strings.ToLower(tokens[1].text) == "endm"
+}
+
+//Function to read a single statement
+func (assembler *assembler) readStatement(line *sourceLine, tokens []token, depth int) {
+
+	current := &statement{line: line}
+
+	//Labels end with a colon, and can have a statement after them
+	if len(tokens) >= 2 && tokens[0].kind == tokenIdentifier && tokens[1].text == ":" {
+		current.label = tokens[0].text
+		current.labelColumn = tokens[0].column
+		tokens = tokens[2:]
+	}
+
+	if len(tokens) == 0 {
+		assembler.statements = append(assembler.statements, current)
+		return
+	}
+
+	if tokens[0].kind != tokenIdentifier {
+		assembler.addError(line, tokens[0].column, fmt.Sprintf("expected an instruction, found %q", tokens[0].text))
+		return
+	}
+
+	//Constants, NAME = value or NAME EQU value
+	if len(tokens) >= 2 && (tokens[1].text == "=" || strings.ToUpper(tokens[1].text) == "EQU") {
+		if current.label != "" {
+			assembler.addError(line, current.labelColumn, "a constant can't have a label")
+			return
+		}
+		assembler.constant(line, tokens[0], tokens[2:])
+		return
+	}
+
+	current.name = strings.ToUpper(tokens[0].text)
+	current.nameColumn = tokens[0].column
+	current.operands = splitOperands(tokens[1:])
+
+	//Macros are replaced by their lines
+	found, isMacro := assembler.macros[strings.ToLower(tokens[0].text)]
+	if isMacro {
+		current.name = ""
+		current.operands = nil
+		assembler.statements = append(assembler.statements, current)
+		assembler.expandMacro(line, found, splitOperands(tokens[1:]), depth)
+		return
+	}
+
+	switch current.name {
+	case "DB":
+		current.kind = statementBytes
+	case "DW":
+		current.kind = statementWords
+	case "DS":
+		current.kind = statementSpace
+	case "ORG":
+		current.kind = statementOrg
+	case "ALIGN":
+		current.kind = statementAlign
+	default:
+		current.kind = statementInstruction
+	}
+
+	assembler.statements = append(assembler.statements, current)
+}
+
+//Function to split tokens into operands on commas, ignoring commas in brackets
+func splitOperands(tokens []token) []operand {
+
+	operands := make([]operand, 0)
+	if len(tokens) == 0 {
+		return operands
+	}
+
+	depth := 0
+	start := 0
+	for i, next := range tokens {
+		switch next.text {
+		case "(", "[":
+			depth++
+		case ")", "]":
+			depth--
+		case ",":
+			if depth == 0 && next.kind == tokenPunctuation {
+				operands = append(operands, operand{tokens: tokens[start:i], column: next.column})
+				start = i + 1
+			}
+		}
+	}
+	operands = append(operands, operand{tokens: tokens[start:], column: tokens[len(tokens)-1].column})
+
+	//Use the column of the first token, if the operand has one
+	for i := range operands {
+		if len(operands[i].tokens) > 0 {
+			operands[i].column = operands[i].tokens[0].column
+		}
+	}
+
+	return operands
+}
+
+//Function to define a constant
+func (assembler *assembler) constant(line *sourceLine, name token, valueTokens []token) {
+
+	value, err := parseExpression(valueTokens)
+	if err != nil {
+		column := err.Column
+		if column == 0 {
+			column = name.column
+		}
+		assembler.addError(line, column, err.Message)
+		return
+	}
+
+	current := &statement{line: line, kind: statementConstant, name: name.text, nameColumn: name.column}
+	assembler.statements = append(assembler.statements, current)
+	assembler.define(line, name.text, name.column, &symbol{value: value, line: line})
+}
+
+//Function to add a label or constant to our symbol table
+func (assembler *assembler) define(line *sourceLine, name string, column int, defined *symbol) {
+	existing, isDefined := assembler.scope.symbols[name]
+	if isDefined {
+		assembler.addError(line, column, fmt.Sprintf("%s is already defined at %s:%d", name, existing.line.file, existing.line.number))
+		return
+	}
+
+	assembler.scope.symbols[name] = defined
+}
+
+//Function to read an included file
+func (assembler *assembler) include(line *sourceLine, tokens []token, depth int) {
+	if len(tokens) != 1 || tokens[0].kind != tokenString {
+		assembler.addError(line, 1, ":include needs a file name in quotes")
+		return
+	}
+	if depth >= maxDepth {
+		assembler.addError(line, tokens[0].column, "includes are nested too deep")
+		return
+	}
+
+	path := includePath(line.file, tokens[0].text)
+	source, err := assembler.options.ReadFile(path)
+	if err != nil {
+		assembler.addError(line, tokens[0].column, fmt.Sprintf("can't include %s: %s", tokens[0].text, err))
+		return
+	}
+
+	assembler.readSource(path, string(source), depth+1)
+}
+
+//Function to define a macro
+func (assembler *assembler) defineMacro(line *sourceLine, tokens []token, body []sourceLine) {
+	if len(tokens) == 0 || tokens[0].kind != tokenIdentifier {
+		assembler.addError(line, 1, ":macro needs a name")
+		return
+	}
+
+	defined := &macro{name: tokens[0].text, body: body}
+	for _, parameter := range splitOperands(tokens[1:]) {
+		if len(parameter.tokens) != 1 || parameter.tokens[0].kind != tokenIdentifier {
+			assembler.addError(line, parameter.column, "macro parameters must be names")
+			return
+		}
+		defined.parameters = append(defined.parameters, parameter.tokens[0].text)
+	}
+
+	name := strings.ToLower(defined.name)
+	_, isDefined := assembler.macros[name]
+	if isDefined {
+		assembler.addError(line, tokens[0].column, fmt.Sprintf("macro %s is already defined", defined.name))
+		return
+	}
+	assembler.macros[name] = defined
+}
+
+//Function to expand a macro, replacing its parameters with our arguments
+func (assembler *assembler) expandMacro(line *sourceLine, expanding *macro, arguments []operand, depth int) {
+	if len(arguments) != len(expanding.parameters) {
+		assembler.addError(line, 1, fmt.Sprintf("macro %s takes %d arguments, found %d", expanding.name, len(expanding.parameters), len(arguments)))
+		return
+	}
+	if depth >= maxDepth {
+		assembler.addError(line, 1, "macros are nested too deep")
+		return
+	}
+
+	//Get the text of each argument, from the line it was written on
+	replacements := make(map[string]string)
+	for i, argument := range arguments {
+		replacements[expanding.parameters[i]] = operandText(line.text, argument)
+	}
+
+	//Each expansion has its own number, so labels ending in \@ are unique
+	assembler.expansions++
+	unique := fmt.Sprintf("_%d", assembler.expansions)
+
+	where := fmt.Sprintf("in macro %s used at %s:%d", expanding.name, line.file, line.number)
+	if line.macro != "" {
+		where = where + ", " + line.macro
+	}
+
+	expanded := make([]sourceLine, len(expanding.body))
+	for i, bodyLine := range expanding.body {
+		text := strings.Replace(bodyLine.text, "\\@", unique, -1)
+		expanded[i] = sourceLine{file: bodyLine.file, number: bodyLine.number, text: replaceNames(text, replacements), macro: where}
+	}
+
+	assembler.readLines(expanded, depth+1)
+}
+
+//Function to get the source text of an operand
+func operandText(text string, argument operand) string {
+	if len(argument.tokens) == 0 {
+		return ""
+	}
+
+	first := argument.tokens[0]
+	last := argument.tokens[len(argument.tokens)-1]
+	end := last.column - 1 + len(last.text)
+	if last.kind == tokenString {
+		end = end + 2
+	}
+
+	return text[first.column-1 : end]
+}
+
+//Function to replace whole names in a line of text, leaving comments alone
+func replaceNames(text string, replacements map[string]string) string {
+
+	var result strings.Builder
+	for i := 0; i < len(text); {
+		character := text[i]
+		if character == ';' {
+			result.WriteString(text[i:])
+			break
+		}
+		if character == '"' || character == '\'' {
+			end := strings.IndexByte(text[i+1:], character)
+			if end < 0 {
+				result.WriteString(text[i:])
+				break
+			}
+			result.WriteString(text[i : i+end+2])
+			i += end + 2
+			continue
+		}
+		if isIdentifierStart(character) {
+			end := i
+			for end < len(text) && isIdentifierPart(text[end]) {
+				end++
+			}
+			replacement, isParameter := replacements[text[i:end]]
+			if isParameter {
+				result.WriteString(replacement)
+			} else {
+				result.WriteString(text[i:end])
+			}
+			i = end
+			continue
+		}
+
+		result.WriteByte(character)
+		i++
+	}
+
+	return result.String()
+}
+
+func minimum(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,77 @@
+package main
+
+//This is helper class for the test command, see conformance/run.go
+//Every test in the manifest runs without a window, and its display is compared to the one we expect
+
+import (
+	conformance "github.com/torch2424/chipGo/conformance"
+	"fmt"
+	"os"
+	"strings"
+)
+
+//Function to run the conformance tests, and exit with 1 if any fail
+func runConformance() {
+	manifest, err := conformance.ReadManifest(*testManifest)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	passed, failed := 0, 0
+	for _, test := range manifest.Tests {
+		if *testRun != "" && !strings.Contains(test.Name, *testRun) {
+			continue
+		}
+
+		result := manifest.Run(test)
+		switch {
+		case result.Err != nil:
+			fmt.Printf("FAIL  %-16s  %s\n", test.Name, result.Err)
+			failed++
+			break
+		case *testUpdate:
+			if test.Hash != result.Hash {
+				fmt.Printf("NEW   %-16s  sha256=%s\n", test.Name, result.Hash)
+				test.Hash = result.Hash
+			} else {
+				fmt.Printf("ok    %s\n", test.Name)
+			}
+			passed++
+			break
+		case test.Hash == "":
+			//A test without a display would pass whatever we draw, so it fails until we check the display and --update
+			fmt.Printf("FAIL  %-16s  no display to compare to, was sha256=%s, check it with -v and run with --update\n", test.Name, result.Hash)
+			if *testVerbose {
+				fmt.Print(conformance.DrawDisplay(result.Cpu))
+			}
+			failed++
+			break
+		case result.Passed():
+			fmt.Printf("ok    %s\n", test.Name)
+			passed++
+			break
+		default:
+			fmt.Printf("FAIL  %-16s  display was sha256=%s\n", test.Name, result.Hash)
+			if *testVerbose {
+				fmt.Print(conformance.DrawDisplay(result.Cpu))
+			}
+			failed++
+			break
+		}
+	}
+
+	//Write our new displays, only once every test ran, so a bad run doesn't leave half a manifest
+	if *testUpdate {
+		err = manifest.Write()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+
+	fmt.Printf("%d passed, %d failed\n", passed, failed)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
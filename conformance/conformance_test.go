@@ -0,0 +1,32 @@
+package conformance
+
+//This is the test for the conformance manifest, so go test runs the same tests as chipgo test
+
+//Imports
+import (
+	"testing"
+)
+
+//Function to run every test in the manifest, and fail if a display doesn't match
+func TestConformance(t *testing.T) {
+	manifest, err := ReadManifest("testdata/manifest")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range manifest.Tests {
+		test := test
+		t.Run(test.Name, func(t *testing.T) {
+			result := manifest.Run(test)
+			if result.Err != nil {
+				t.Fatal(result.Err)
+			}
+			if test.Hash == "" {
+				t.Fatalf("no display to compare to, was sha256=%s\n%s", result.Hash, DrawDisplay(result.Cpu))
+			}
+			if !result.Passed() {
+				t.Fatalf("display was sha256=%s, expected sha256=%s\n%s", result.Hash, test.Hash, DrawDisplay(result.Cpu))
+			}
+		})
+	}
+}
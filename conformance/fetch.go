@@ -0,0 +1,150 @@
+package conformance
+
+/*
+   Conformance tests for Chip-8, SCHIP, and XO-CHIP
+
+   Runs test ROMs and games without a window, and compares a hash of the display to the one we expect
+*/
+
+//This is helper class for fetching test ROMs we don't ship, e.g. because of their license
+//A fetch line in the manifest names a directory, and a zip of ROMs to fill it from
+//  fetch  roms/timendus  https://proxy.golang.org/github.com/!timendus/chip8-test-suite/@v/v0.0.0-20251107074700-742e9eac9f5d.zip  sha256=d2e9...
+//The zip is downloaded the first time a test needs a ROM from the directory, and every ROM in it is put in the directory
+
+//Imports
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+//Fetch is a directory of ROMs we download, and the zip they come from
+type Fetch struct {
+	Dir string
+	URL string
+
+	//The hash of the zip, so we only run the ROMs we checked the displays of
+	Hash string
+}
+
+//Files we take out of a zip, anything else in it is left out
+var romExtensions = map[string]bool{".ch8": true, ".sc8": true, ".xo8": true}
+
+//Client for downloading zips, so a stuck server fails the tests instead of hanging them
+var fetchClient = &http.Client{Timeout: 2 * time.Minute}
+
+//Function to read a fetch line from its fields
+func parseFetch(fields []string) (*Fetch, error) {
+	if len(fields) != 4 || !strings.HasPrefix(fields[3], "sha256=") {
+		return nil, fmt.Errorf("expected fetch DIR URL sha256=HASH")
+	}
+
+	return &Fetch{Dir: fields[1], URL: fields[2], Hash: strings.ToLower(strings.TrimPrefix(fields[3], "sha256="))}, nil
+}
+
+//Function to download the ROMs for a ROM path, if it is in a fetched directory we don't have yet
+func (manifest *Manifest) fetchRom(romPath string) error {
+	for _, fetch := range manifest.Fetches {
+		dir := manifest.Resolve(fetch.Dir)
+		if !strings.HasPrefix(romPath, dir+string(filepath.Separator)) {
+			continue
+		}
+
+		_, err := os.Stat(dir)
+		if err == nil {
+			return nil
+		}
+		err = fetchZip(fetch, dir)
+		if err != nil {
+			return fmt.Errorf("Failed fetching %s: %s", fetch.Dir, err)
+		}
+		return nil
+	}
+
+	return nil
+}
+
+//Function to download a zip, check it is the one we expect, and put its ROMs in a directory
+func fetchZip(fetch *Fetch, dir string) error {
+	response, err := fetchClient.Get(fetch.URL)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %s", fetch.URL, response.Status)
+	}
+	data, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	if hash != fetch.Hash {
+		return fmt.Errorf("%s was sha256=%s, expected sha256=%s", fetch.URL, hash, fetch.Hash)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return err
+	}
+
+	//Unzip next to the directory, and only move it into place once every ROM is out
+	//So a fetch that fails part way is tried again next time
+	err = os.MkdirAll(filepath.Dir(dir), 0755)
+	if err != nil {
+		return err
+	}
+	temp, err := ioutil.TempDir(filepath.Dir(dir), ".fetch")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(temp)
+
+	for _, file := range archive.File {
+		name := path.Base(file.Name)
+		if !romExtensions[strings.ToLower(path.Ext(name))] {
+			continue
+		}
+		err = unzipFile(file, filepath.Join(temp, name))
+		if err != nil {
+			return err
+		}
+	}
+
+	err = os.Chmod(temp, 0755)
+	if err != nil {
+		return err
+	}
+	return os.Rename(temp, dir)
+}
+
+//Function to write a file from a zip
+func unzipFile(file *zip.File, romPath string) error {
+	reader, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	writer, err := os.Create(romPath)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(writer, reader)
+	closeErr := writer.Close()
+	if err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,235 @@
+package conformance
+
+/*
+   Conformance tests for Chip-8, SCHIP, and XO-CHIP
+
+   Runs test ROMs and games without a window, and compares a hash of the display to the one we expect
+*/
+
+//This is helper class for the manifest, the list of tests to run
+//Each line is a test, its name, its ROM, and then options, and ; starts a comment
+//  brix  ../../games/BRIX  frames=120 sha256=1f3a...
+//Options are
+//  frames=N          how many 60hz frames to run, 60 if not given
+//  mode=schip        instruction set, chip8 if not given
+//  quirks=vip        quirks preset, auto if not given
+//  speed=N           instructions a second, 600 if not given
+//  seed=N            seed for random numbers, 0 if not given
+//  input=FILE        input script of the keys to hold, see trace/script.go
+//  poke=ADDR:VALUE   a byte to write to memory before running, can be given more than once
+//  sha256=HASH       the hash of the display we expect, written by --update
+//ROMs and input scripts are relative to the manifest
+//A line starting with fetch is a directory of ROMs we download instead of ship, see fetch.go
+
+//Imports
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+//Poke is a byte written to memory before a test runs
+type Poke struct {
+	Address int
+	Value   uint8
+}
+
+//Test is a single ROM to run, and the display we expect
+type Test struct {
+	Name string
+	Rom  string
+
+	Frames int
+	Mode   string
+	Quirks string
+	Speed  int
+	Seed   uint64
+	Input  string
+	Pokes  []Poke
+
+	//The hash of the display we expect, empty if we don't know it yet
+	Hash string
+
+	//Which line of the manifest the test is on, from 0
+	line int
+}
+
+//Manifest is a list of tests, and where they came from
+type Manifest struct {
+	Path    string
+	Tests   []*Test
+	Fetches []*Fetch
+
+	//Every line, so comments are kept when we write the manifest back
+	lines []string
+}
+
+//Function to read a manifest
+func ReadManifest(path string) (*Manifest, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	manifest := &Manifest{Path: path}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		manifest.lines = append(manifest.lines, scanner.Text())
+		number := len(manifest.lines)
+
+		text := scanner.Text()
+		comment := strings.Index(text, ";")
+		if comment >= 0 {
+			text = text[:comment]
+		}
+		fields := strings.Fields(text)
+		if len(fields) == 0 {
+			continue
+		}
+
+		if fields[0] == "fetch" {
+			fetch, err := parseFetch(fields)
+			if err != nil {
+				return nil, fmt.Errorf("%s line %d: %s", path, number, err)
+			}
+			manifest.Fetches = append(manifest.Fetches, fetch)
+			continue
+		}
+
+		test, err := parseTest(fields)
+		if err != nil {
+			return nil, fmt.Errorf("%s line %d: %s", path, number, err)
+		}
+		test.line = number - 1
+		manifest.Tests = append(manifest.Tests, test)
+	}
+	if scanner.Err() != nil {
+		return nil, scanner.Err()
+	}
+
+	return manifest, nil
+}
+
+//Function to read a test from the fields of its line
+func parseTest(fields []string) (*Test, error) {
+	if len(fields) < 2 {
+		return nil, fmt.Errorf("expected a name and a ROM")
+	}
+
+	test := &Test{Name: fields[0], Rom: fields[1], Frames: 60, Mode: "chip8", Quirks: "auto", Speed: 600}
+	for _, option := range fields[2:] {
+		keyValue := strings.SplitN(option, "=", 2)
+		if len(keyValue) != 2 {
+			return nil, fmt.Errorf("expected an option like frames=60, found %s", option)
+		}
+
+		var err error
+		value := keyValue[1]
+		switch keyValue[0] {
+		case "frames":
+			test.Frames, err = strconv.Atoi(value)
+			break
+		case "mode":
+			test.Mode = value
+			break
+		case "quirks":
+			test.Quirks = value
+			break
+		case "speed":
+			test.Speed, err = strconv.Atoi(value)
+			break
+		case "seed":
+			test.Seed, err = strconv.ParseUint(value, 0, 64)
+			break
+		case "input":
+			test.Input = value
+			break
+		case "poke":
+			var poke Poke
+			poke, err = parsePoke(value)
+			test.Pokes = append(test.Pokes, poke)
+			break
+		case "sha256":
+			test.Hash = strings.ToLower(value)
+			break
+		default:
+			err = fmt.Errorf("unknown option")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("bad option %s", option)
+		}
+	}
+
+	return test, nil
+}
+
+//Function to read a poke, e.g. 0x1FF:1
+func parsePoke(text string) (Poke, error) {
+	parts := strings.SplitN(text, ":", 2)
+	if len(parts) != 2 {
+		return Poke{}, fmt.Errorf("expected ADDRESS:VALUE")
+	}
+
+	address, err := strconv.ParseUint(parts[0], 0, 16)
+	if err != nil {
+		return Poke{}, err
+	}
+	value, err := strconv.ParseUint(parts[1], 0, 8)
+	if err != nil {
+		return Poke{}, err
+	}
+
+	return Poke{Address: int(address), Value: uint8(value)}, nil
+}
+
+//Function to find a file a test uses, relative to the manifest
+func (manifest *Manifest) Resolve(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(filepath.Dir(manifest.Path), filepath.FromSlash(path))
+}
+
+//Function to write the manifest back, with the hashes of its tests
+//Only the hashes change, everything else on each line is kept
+func (manifest *Manifest) Write() error {
+	lines := make([]string, len(manifest.lines))
+	copy(lines, manifest.lines)
+
+	for _, test := range manifest.Tests {
+		lines[test.line] = withHash(lines[test.line], test.Hash)
+	}
+
+	return ioutil.WriteFile(manifest.Path, []byte(strings.Join(lines, "\n")+"\n"), 0644)
+}
+
+//Function to set the sha256 option of a test's line, adding it if it isn't there
+func withHash(line string, hash string) string {
+	code := line
+	comment := ""
+	index := strings.Index(line, ";")
+	if index >= 0 {
+		code = line[:index]
+		comment = line[index:]
+	}
+
+	//Replace the option where it is, keeping the spacing of the line
+	fields := strings.Fields(code)
+	for _, field := range fields {
+		if strings.HasPrefix(field, "sha256=") {
+			code = strings.Replace(code, field, "sha256="+hash, 1)
+			return code + comment
+		}
+	}
+
+	code = strings.TrimRight(code, " \t") + " sha256=" + hash
+	if comment != "" {
+		code = code + " "
+	}
+	return code + comment
+}
@@ -0,0 +1,145 @@
+package conformance
+
+/*
+   Conformance tests for Chip-8, SCHIP, and XO-CHIP
+
+   Runs test ROMs and games without a window, and compares a hash of the display to the one we expect
+*/
+
+//This is helper class for running a test
+//Tests run frame by frame as fast as they can, with the same seed every time, so the display always ends up the same
+
+//Imports
+import (
+	cpu "github.com/torch2424/chipGo/cpu"
+	trace "github.com/torch2424/chipGo/trace"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+//Result is how a test went
+type Result struct {
+	Test *Test
+
+	//The hash of the display after the last frame
+	Hash string
+
+	//Why the test couldn't run, e.g. the cpu hit an unknown opcode
+	Err error
+
+	//The cpu after the last frame, to show the display when a test fails
+	Cpu cpu.Cpu
+}
+
+//Function to check if a test passed, the display matched the hash we expect
+func (result Result) Passed() bool {
+	return result.Err == nil && result.Test.Hash != "" && result.Hash == result.Test.Hash
+}
+
+//Function to run a test from a manifest
+func (manifest *Manifest) Run(test *Test) Result {
+	result := Result{Test: test}
+
+	//Download the ROM first, if we don't ship it
+	romPath := manifest.Resolve(test.Rom)
+	err := manifest.fetchRom(romPath)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	game, err := ioutil.ReadFile(romPath)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	//Read the keys to hold on each frame, no keys are pressed without a script
+	script := &trace.Script{}
+	if test.Input != "" {
+		script, err = trace.ReadScriptFile(manifest.Resolve(test.Input))
+		if err != nil {
+			result.Err = err
+			return result
+		}
+	}
+
+	chipCpu, err := newCpu(test, game)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	//Run our frames, stopping early if the game exits
+	emulator := cpu.NewEmulator(chipCpu, script, nil, nil)
+	instructions := cpu.InstructionsPerFrame(test.Speed)
+	for frame := 0; frame < test.Frames && !emulator.Cpu.Exit; frame++ {
+		err = emulator.RunFrame(instructions)
+		if err != nil {
+			result.Err = fmt.Errorf("Frame %d: %s", frame, err)
+			break
+		}
+		script.Frame++
+	}
+
+	result.Cpu = emulator.Cpu
+	result.Hash = HashDisplay(emulator.Cpu)
+	return result
+}
+
+//Function to make a cpu for a test, with its settings, and load its game
+func newCpu(test *Test, game []byte) (cpu.Cpu, error) {
+	mode, err := cpu.ParseMode(test.Mode)
+	if err != nil {
+		return cpu.Cpu{}, err
+	}
+
+	chipCpu := cpu.NewCpu("chipCpu", test.Speed, mode)
+	chipCpu.Quirks, err = cpu.ParseQuirks(test.Quirks, mode)
+	if err != nil {
+		return cpu.Cpu{}, err
+	}
+	chipCpu.Random = cpu.NewRandom(cpu.RandomXorshift, test.Seed)
+
+	chipCpu, err = cpu.LoadRom(chipCpu, game)
+	if err != nil {
+		return cpu.Cpu{}, err
+	}
+
+	//Pokes go in after the game, e.g. the Timendus test suite reads which platform to test from 0x1FF
+	for _, poke := range test.Pokes {
+		chipCpu = cpu.SetMemory(chipCpu, poke.Address, poke.Value)
+	}
+
+	return chipCpu, nil
+}
+
+//Function to hash the display, SHA-256 of every pixel, column by column
+func HashDisplay(chipCpu cpu.Cpu) string {
+	hash := sha256.New()
+	for x := 0; x < cpu.HiResWidth; x++ {
+		hash.Write(chipCpu.GraphicsDisplay[x][:])
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+//Function to draw the display as text, # for lit pixels, to see what went wrong when a test fails
+//Each XO-CHIP plane lights a pixel, so planes show up the same
+func DrawDisplay(chipCpu cpu.Cpu) string {
+	width, height := cpu.DisplaySize(chipCpu)
+
+	var text strings.Builder
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if chipCpu.GraphicsDisplay[x][y] != 0 {
+				text.WriteString("#")
+			} else {
+				text.WriteString(".")
+			}
+		}
+		text.WriteString("\n")
+	}
+	return text.String()
+}
@@ -10,14 +10,20 @@ package cpu
 //unsigned Char = uint8
 
 //Import io/ioutil for file reading/writing
-//Import video for shared constants
+//The cpu only uses the standard library, so it can run without a window. See emulator.go
 import (
-	graphics "github.com/torch2424/chipGo/graphics"
-	"fmt"
 	"io/ioutil"
 	"time"
 )
 
+//Chip8 display size
+const Width int = 64
+const Height int = 32
+
+//Super Chip-8 high resolution display size
+const HiResWidth int = 128
+const HiResHeight int = 64
+
 //Font set that is loaded into chip 8 memory on initialization
 var fontSet = [80]byte{
 	0xF0, 0x90, 0x90, 0x90, 0xF0, // 0
@@ -49,13 +55,27 @@ type Cpu struct {
 	//Boolean for clearing the screen
 	ClearScreen bool
 
+	//Boolean for if the game ran the SCHIP exit opcode, and the emulator should stop
+	Exit bool
+
+	//The instruction set we are emulating (Chip-8, SCHIP, or XO-CHIP)
+	mode Mode
+
+	//How we handle opCodes that interpreters disagree on
+	Quirks Quirks
+
+	//Our random number generator for CXNN, see random.go
+	Random Random
+
 	//Our current Opcode, 2 bytes long, int16 = 16 bits = 2 bytes
 	//uint = unsigned int
 	// https://tour.golang.org/basics/11
 	currentOpcode uint16
 
 	//Capture the memory of of the CHIP-8, 4KB
-	chipMemory [4096]byte
+	//XO-CHIP has 64KB, so the array is always that big, and memorySize is how much the game can use
+	chipMemory [xoChipMemorySize]byte
+	memorySize int
 
 	//Our CPU registers, they hold values to be used by the CPU
 	//15 registers V0, V1, ... VE
@@ -78,14 +98,25 @@ type Cpu struct {
 	*/
 
 	//Chip-8 has a 64 x 32 screen size, and only black our white display. So, create an array if a pizel is black (0) or white (1)
-	GraphicsDisplay [graphics.Width][graphics.Height]uint8
+	//SCHIP can switch to a 128 x 64 screen, so the array is always the high resolution size, and low resolution uses the top left corner
+	//XO-CHIP has two bitplanes, so each pixel is a bitmask of the planes that are on (0-3)
+	GraphicsDisplay [HiResWidth][HiResHeight]uint8
+	HighRes         bool
+
+	//XO-CHIP bitmask of the planes we are drawing to. Always the first plane outside of XO-CHIP
+	planes uint8
 
 	//Chip-8 has timers, they simply count down to zero when set
 	//Timer speed used to increase or decrease clock speed
+	//The delay and sound timers always count down at 60hz, on their own TimerClock
 	delayTimer uint8
 	soundTimer uint8
 	timerSpeed float32
 	Clock      *time.Ticker
+	TimerClock *time.Ticker
+
+	//Boolean for if the timers have ticked since we last drew, for the display wait quirk
+	vblank bool
 
 	//For Goto, and jumps into functions, we need to have a stack, and point to where we currently are on the stack
 	stack        [16]uint16
@@ -94,31 +125,67 @@ type Cpu struct {
 	//Create our keypad
 	//Bool for if key is on or off. Index is used for which key
 	keyPad [16]bool
+
+	//FX0A waits for a key to be pressed and then let go, this is the key it saw pressed
+	keyWaiting bool
+	waitKey    uint8
+
+	//SCHIP RPL user flags, registers can be saved to and loaded from here with FX75 and FX85
+	rplFlags [16]uint8
+
+	//XO-CHIP audio pattern buffer, 128 1-bit samples played while the sound timer is on, and the pitch to play them at
+	audioPattern [16]byte
+	audioPitch   uint8
 }
 
-//Debug mode boolean
-var DebugMode bool
+//How many times a second our delay and sound timers count down
+const TimerSpeed = 60
 
 //Function to construct a new CPU
-func NewCpu(cpuName string, gameSpeed int, debug bool) Cpu {
-
-	cpu := Cpu{CpuName: cpuName, stackPointer: -1, timerSpeed: float32(gameSpeed)}
+//The cpu doesn't print anything, see the trace package and the debugger to watch it run
+func NewCpu(cpuName string, gameSpeed int, mode Mode) Cpu {
 
-	DebugMode = debug
+	cpu := Cpu{CpuName: cpuName, stackPointer: -1, timerSpeed: float32(gameSpeed), mode: mode, memorySize: memorySizeForMode(mode), Quirks: QuirksForMode(mode), Random: NewRandom(RandomXorshift, 0)}
 
 	return cpu
 }
 
 //Declare our function to load a game
-func LoadGame(fileName string, cpu Cpu) Cpu {
+func LoadGame(fileName string, cpu Cpu) (Cpu, error) {
 
 	//Read the bytes of the file into memory
 	game, err := ioutil.ReadFile(fileName)
 	if err != nil {
-		print("Failed loading game...\n\n")
-		panic(err)
-	} else {
-		print("Game loaded!\n\n")
+		return cpu, err
+	}
+
+	return StartGame(cpu, game)
+}
+
+//Function to start a game we already have in memory, e.g. one we compiled from source
+func StartGame(cpu Cpu, game []byte) (Cpu, error) {
+
+	//Load the game into memory
+	cpu, err := LoadRom(cpu, game)
+	if err != nil {
+		return cpu, err
+	}
+
+	//Find our clock speed, and start our clocks
+	clockSpeed := time.Duration(cpu.timerSpeed)
+	cpu.Clock = time.NewTicker(time.Second / clockSpeed)
+	cpu.TimerClock = time.NewTicker(time.Second / TimerSpeed)
+
+	return cpu, nil
+}
+
+//Function to load a game that is already in memory, and reset the cpu to run it
+//This does not start our clocks, so it can be used to step the cpu without a window. See emulator.go
+func LoadRom(cpu Cpu, game []byte) (Cpu, error) {
+
+	//Make sure the game fits in memory after the interpreter space
+	if len(game) > cpu.memorySize-0x200 {
+		return cpu, RomTooLargeError{Size: len(game), MaxSize: cpu.memorySize - 0x200}
 	}
 
 	//Set our values to the initial state
@@ -126,30 +193,50 @@ func LoadGame(fileName string, cpu Cpu) Cpu {
 	cpu.skipProgramCounter = false
 	cpu.currentOpcode = 0
 	cpu.indexRegister = 0
-	cpu.stackPointer = 0
+	cpu.stackPointer = -1
+
+	//Clear anything left over from the last game
+	cpu.chipMemory = [xoChipMemorySize]byte{}
+	cpu.registers = [16]uint8{}
+	cpu.stack = [16]uint16{}
+	cpu.keyPad = [16]bool{}
+	cpu.keyWaiting = false
+	cpu.waitKey = 0
+	cpu = ClearGraphics(cpu)
 
 	//Reset timers (60 cycles per second)
 	cpu.delayTimer = 0
 	cpu.soundTimer = 0
-	//Find our clock speed
-	clockSpeed := time.Duration(cpu.timerSpeed)
-	cpu.Clock = time.NewTicker(time.Second / clockSpeed)
+
+	//Start in low resolution, drawing to the first plane
+	cpu.HighRes = false
+	cpu.Exit = false
+	cpu.planes = 1
+
+	//Reset the XO-CHIP audio
+	cpu.audioPattern = defaultAudioPattern
+	cpu.audioPitch = defaultAudioPitch
 
 	//Load the Chip 8 fontset into memory
 	for i := 0; i < len(fontSet); i++ {
 		cpu.chipMemory[i] = fontSet[i]
 	}
 
+	//Load the SCHIP big fontset after it
+	for i := 0; i < len(bigFontSet); i++ {
+		cpu.chipMemory[bigFontStart+i] = bigFontSet[i]
+	}
+
 	//Load the game into memory
 	for i := 0; i < len(game); i++ {
 		cpu.chipMemory[i+512] = game[i]
 	}
 
-	return cpu
+	return cpu, nil
 }
 
 //Function to grab an opcode to interpret
-func EmulateCycle(cpu Cpu) Cpu {
+func EmulateCycle(cpu Cpu) (Cpu, error) {
 
 	//Reset our video booleans
 	cpu.ShouldRender = false
@@ -158,19 +245,19 @@ func EmulateCycle(cpu Cpu) Cpu {
 	//Get and Decode opcode found in package's opcode.go
 	//Key decode is done in opcode.go
 
-	//Count down our timers
-	if cpu.delayTimer > 0 {
-		cpu.delayTimer--
-	}
-	if cpu.soundTimer > 0 {
-		cpu.soundTimer--
+	//Make sure the program counter is still in memory
+	if err := checkMemory(cpu, int(cpu.programCounter), 2); err != nil {
+		return cpu, err
 	}
 
 	//Grab the opcode
 	cpu.currentOpcode = GetOpcode(cpu)
 
 	//Decode the Opcode
-	cpu = DecodeOpcode(cpu)
+	cpu, err := DecodeOpcode(cpu)
+	if err != nil {
+		return cpu, err
+	}
 
 	//Finally increase the program counter by two, if we did not jump to a specific address
 	if cpu.skipProgramCounter {
@@ -179,32 +266,33 @@ func EmulateCycle(cpu Cpu) Cpu {
 		cpu.programCounter = cpu.programCounter + 2
 	}
 
-	//First check for debug mode,
-	//If debug mode is on print the current cpu state
-	if DebugMode {
-		print("\n\nCPU State:\n\n")
+	return cpu, nil
+}
 
-		fmt.Println("ShouldRender: ", cpu.ShouldRender)
-		fmt.Println("ClearScreen: ", cpu.ClearScreen)
-		fmt.Printf("currentOpcode: 0x%X\n", cpu.currentOpcode)
-		fmt.Printf("indexRegister: 0x%X\n", cpu.indexRegister)
-		fmt.Printf("programCounter: 0x%X\n", cpu.programCounter)
+//Function to set the keys that are pressed on our keypad
+//Frontends should call this before each cycle, with the keys from their KeySource
+func SetKeys(cpu Cpu, keys [16]bool) Cpu {
+	cpu.keyPad = keys
+	return cpu
+}
 
-		//Registers
-		for i := 0; i < len(cpu.registers); i++ {
-			fmt.Printf("Register 0x%X: 0x%X\n", i, cpu.registers[i])
-		}
+//Function to count down our delay and sound timers
+//This should be called 60 times a second (TimerSpeed), no matter how fast we are running instructions
+func TickTimers(cpu Cpu) Cpu {
 
-		//Stack
-		for i := 0; i < len(cpu.stack); i++ {
-			fmt.Printf("Stack 0x%X: 0x%X\n", i, cpu.stack[i])
-		}
+	//Count down our timers
+	if cpu.delayTimer > 0 {
+		cpu.delayTimer--
+	}
+	if cpu.soundTimer > 0 {
+		cpu.soundTimer--
+	}
 
-		fmt.Println("Stack Pointer: ", cpu.stackPointer)
+	//The screen refreshes with the timers, let any sprite waiting on the display draw
+	cpu.vblank = true
 
-		//Lastly, space our for results
-		print("\n\n")
-	}
+	//The VIP counts its random numbers along every frame too, see random.go
+	cpu = tickRandom(cpu)
 
 	return cpu
 }
@@ -212,8 +300,8 @@ func EmulateCycle(cpu Cpu) Cpu {
 //Function to reset our graphics display
 func ClearGraphics(cpu Cpu) Cpu {
 
-	for i := 0; i < graphics.Width; i++ {
-		for j := 0; j < graphics.Height; j++ {
+	for i := 0; i < HiResWidth; i++ {
+		for j := 0; j < HiResHeight; j++ {
 			cpu.GraphicsDisplay[i][j] = uint8(0)
 		}
 	}
@@ -223,10 +311,6 @@ func ClearGraphics(cpu Cpu) Cpu {
 
 //Function to return if we should play a sound
 func ShouldPlaySound(cpu Cpu) bool {
-	//Play any sounds
-	//Chip 8 played sound for as long as the sound timer is not zero. but since we have single audio file. Going to play sound as soon as the timer is one
-	if cpu.soundTimer == 1 {
-		return true
-	}
-	return false
+	//Chip 8 played sound for as long as the sound timer is not zero
+	return cpu.soundTimer > 0
 }
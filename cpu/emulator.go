@@ -0,0 +1,193 @@
+package cpu
+
+/*
+   This project will be a CHIP-8 emulator in go, for a basic understanding of laearning how to code an emulator
+
+   http://www.multigesture.net/articles/how-to-write-an-emulator-chip-8-interpreter/
+*/
+
+//This is helper class for running the cpu without a window
+//The cpu package only uses the standard library, so the Emulator can be embedded in tools, servers, and tests
+//Frontends plug in their keyboard, screen, and speaker with the interfaces below
+
+//KeySource is where the emulator reads the 16 keys of the keypad from
+//Returns the keys, and if any key is pressed
+type KeySource interface {
+	GetKeyArray() ([16]bool, bool)
+}
+
+//Display is where the emulator draws the screen
+//The display is always the size of the high resolution screen, in low resolution only the top left 64x32 is used
+type Display interface {
+	Render(display [HiResWidth][HiResHeight]uint8, highRes bool)
+	Clear()
+}
+
+//Beeper plays a sound when the sound timer goes off
+type Beeper interface {
+	Beep()
+}
+
+//PatternBeeper is a Beeper that can also play XO-CHIP audio patterns
+type PatternBeeper interface {
+	Beeper
+	BeepPattern(pattern [16]byte, pitch uint8)
+}
+
+//Tracer is told about every instruction the emulator runs, e.g. to log them
+//before is the cpu before the instruction, after is the cpu after it, and err is any error it hit
+type Tracer interface {
+	Trace(before Cpu, after Cpu, err error)
+}
+
+//Emulator is a cpu wired to its keys, display, and sound
+//Any of Keys, Display, Beeper, or Tracer can be nil, to run without them
+type Emulator struct {
+	Cpu Cpu
+
+	Keys    KeySource
+	Display Display
+	Beeper  Beeper
+	Tracer  Tracer
+
+	//How many frames have run, counted each time the timers tick
+	Frames int
+}
+
+//Function to construct a new Emulator
+func NewEmulator(cpu Cpu, keys KeySource, display Display, beeper Beeper) *Emulator {
+	return &Emulator{Cpu: cpu, Keys: keys, Display: display, Beeper: beeper}
+}
+
+//Function to load a game into the emulator
+func (emulator *Emulator) LoadRom(game []byte) error {
+	var err error
+	emulator.Cpu, err = LoadRom(emulator.Cpu, game)
+	return err
+}
+
+//Function to save the state of the game
+func (emulator *Emulator) SaveState() ([]byte, error) {
+	return SaveState(emulator.Cpu)
+}
+
+//Function to load a saved state of the game, and draw its display
+func (emulator *Emulator) LoadState(state []byte) error {
+	loaded, err := LoadState(emulator.Cpu, state)
+	if err != nil {
+		return err
+	}
+
+	emulator.Cpu = loaded
+	emulator.Redraw()
+
+	return nil
+}
+
+//Function to draw the whole display, e.g. after the cpu was replaced by a save state
+func (emulator *Emulator) Redraw() {
+	if emulator.Display != nil {
+		emulator.Display.Render(emulator.Cpu.GraphicsDisplay, emulator.Cpu.HighRes)
+	}
+	emulator.Cpu.ShouldRender = false
+	emulator.Cpu.ClearScreen = false
+}
+
+//Function to run a single instruction
+//Reads the keys before, and draws the screen after if the instruction changed it
+func (emulator *Emulator) Step() error {
+
+	//Get keys
+	if emulator.Keys != nil {
+		keys, _ := emulator.Keys.GetKeyArray()
+		emulator.Cpu = SetKeys(emulator.Cpu, keys)
+	}
+
+	//Run the instruction, and tell our tracer about it
+	//The cpu is only copied when tracing, since it holds all of memory
+	var before Cpu
+	if emulator.Tracer != nil {
+		before = emulator.Cpu
+	}
+	var err error
+	soundTimer := emulator.Cpu.soundTimer
+	emulator.Cpu, err = EmulateCycle(emulator.Cpu)
+	if emulator.Tracer != nil {
+		emulator.Tracer.Trace(before, emulator.Cpu, err)
+	}
+	if err != nil {
+		return err
+	}
+
+	//Render our display
+	if emulator.Display != nil {
+		if emulator.Cpu.ShouldRender {
+			emulator.Display.Render(emulator.Cpu.GraphicsDisplay, emulator.Cpu.HighRes)
+		}
+		if emulator.Cpu.ClearScreen {
+			emulator.Display.Clear()
+		}
+	}
+	emulator.Cpu.ShouldRender = false
+	emulator.Cpu.ClearScreen = false
+
+	//Start beeping as soon as the game sets the sound timer, not on the next tick
+	if soundTimer == 0 && ShouldPlaySound(emulator.Cpu) {
+		emulator.beep()
+	}
+
+	return nil
+}
+
+//Function to count down the delay and sound timers, and play any sounds
+//This should be called 60 times a second (TimerSpeed)
+func (emulator *Emulator) TickTimers() {
+
+	//Keep beeping for as long as the sound timer was running this frame
+	if ShouldPlaySound(emulator.Cpu) {
+		emulator.beep()
+	}
+
+	emulator.Cpu = TickTimers(emulator.Cpu)
+	emulator.Frames++
+}
+
+//Function to play our sound, the beepers only start it again if it has finished
+func (emulator *Emulator) beep() {
+	if emulator.Beeper == nil {
+		return
+	}
+
+	//XO-CHIP games can set their own sound
+	patternBeeper, canPlayPatterns := emulator.Beeper.(PatternBeeper)
+	if emulator.Cpu.mode == ModeXOChip && canPlayPatterns {
+		patternBeeper.BeepPattern(GetAudioPattern(emulator.Cpu))
+	} else {
+		emulator.Beeper.Beep()
+	}
+}
+
+//Function to run one 60hz frame, a number of instructions and then a timer tick
+//Stops early if the game exits, or the cpu returns an error
+func (emulator *Emulator) RunFrame(instructions int) error {
+
+	for i := 0; i < instructions && !emulator.Cpu.Exit; i++ {
+		err := emulator.Step()
+		if err != nil {
+			return err
+		}
+	}
+
+	emulator.TickTimers()
+	return nil
+}
+
+//Function to find how many instructions run each frame, for a game speed in instructions per second
+func InstructionsPerFrame(gameSpeed int) int {
+	instructions := gameSpeed / TimerSpeed
+	if instructions < 1 {
+		instructions = 1
+	}
+
+	return instructions
+}
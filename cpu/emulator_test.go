@@ -0,0 +1,52 @@
+package cpu
+
+//This is the test for the emulator, wiring the cpu to its keys, display, and sound
+
+//Imports
+import (
+	"testing"
+)
+
+//Beeper that counts how many times it was asked to beep
+type countingBeeper struct {
+	beeps int
+}
+
+func (beeper *countingBeeper) Beep() {
+	beeper.beeps++
+}
+
+//Function to check the beep starts when FX18 sets the sound timer, and keeps going until it runs out
+func TestBeepStartsWithSoundTimer(t *testing.T) {
+	//V0 := 2, then sound := V0
+	chipCpu := newTestCpu(t, ModeChip8, Quirks{}, []byte{0x60, 0x02, 0xF0, 0x18})
+	beeper := &countingBeeper{}
+	emulator := NewEmulator(chipCpu, nil, nil, beeper)
+
+	steps := []struct {
+		tick      bool
+		wantBeeps int
+	}{
+		//Setting V0 doesn't beep, setting the sound timer beeps straight away
+		{tick: false, wantBeeps: 0},
+		{tick: false, wantBeeps: 1},
+
+		//Then each frame the timer is running beeps, until it reaches zero
+		{tick: true, wantBeeps: 2},
+		{tick: true, wantBeeps: 3},
+		{tick: true, wantBeeps: 3},
+	}
+	for i, step := range steps {
+		if step.tick {
+			emulator.TickTimers()
+		} else {
+			err := emulator.Step()
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		if beeper.beeps != step.wantBeeps {
+			t.Fatalf("step %d: beeped %d times, expected %d", i, beeper.beeps, step.wantBeeps)
+		}
+	}
+}
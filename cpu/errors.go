@@ -0,0 +1,92 @@
+package cpu
+
+/*
+   This project will be a CHIP-8 emulator in go, for a basic understanding of laearning how to code an emulator
+
+   http://www.multigesture.net/articles/how-to-write-an-emulator-chip-8-interpreter/
+*/
+
+//This is helper class for the errors our cpu can return
+//Each error keeps where it happened, so frontends can report them and keep running
+
+//Imports
+import (
+	"fmt"
+)
+
+//Error for an opCode we don't know how to decode in the current mode
+type UnknownOpcodeError struct {
+	ProgramCounter uint16
+	Opcode         uint16
+}
+
+func (err UnknownOpcodeError) Error() string {
+	return fmt.Sprintf("ChipGo Error! Unrecognized opCode 0x%04X at 0x%04X", err.Opcode, err.ProgramCounter)
+}
+
+//Error for returning from a subroutine (00EE) when we are not in one
+type StackUnderflowError struct {
+	ProgramCounter uint16
+	Opcode         uint16
+}
+
+func (err StackUnderflowError) Error() string {
+	return fmt.Sprintf("ChipGo Error! Stack underflow, opCode 0x%04X at 0x%04X returned with an empty stack", err.Opcode, err.ProgramCounter)
+}
+
+//Error for calling a subroutine (2NNN) when the stack is already full
+type StackOverflowError struct {
+	ProgramCounter uint16
+	Opcode         uint16
+}
+
+func (err StackOverflowError) Error() string {
+	return fmt.Sprintf("ChipGo Error! Stack overflow, opCode 0x%04X at 0x%04X called a subroutine with a full stack", err.Opcode, err.ProgramCounter)
+}
+
+//Error for reading or writing memory past the end of the memory for our mode
+type MemoryOutOfBoundsError struct {
+	ProgramCounter uint16
+	Opcode         uint16
+
+	//The first address we tried to use, and how many bytes
+	Address int
+	Length  int
+}
+
+func (err MemoryOutOfBoundsError) Error() string {
+	return fmt.Sprintf("ChipGo Error! Memory out of bounds, opCode 0x%04X at 0x%04X used %d bytes at 0x%04X", err.Opcode, err.ProgramCounter, err.Length, err.Address)
+}
+
+//Error for a game that does not fit in memory
+type RomTooLargeError struct {
+	Size    int
+	MaxSize int
+}
+
+func (err RomTooLargeError) Error() string {
+	return fmt.Sprintf("ChipGo Error! Game is %d bytes, but only %d bytes fit in memory", err.Size, err.MaxSize)
+}
+
+//Error for a save state we can't load
+type InvalidStateError struct {
+	Reason string
+}
+
+func (err InvalidStateError) Error() string {
+	return fmt.Sprintf("ChipGo Error! Invalid save state, %s", err.Reason)
+}
+
+//Function to return an unknown opCode error for the current opCode
+func noOpcode(cpu Cpu) (Cpu, error) {
+	return cpu, UnknownOpcodeError{ProgramCounter: cpu.programCounter, Opcode: cpu.currentOpcode}
+}
+
+//Function to check that a range of memory exists, before we read or write it
+func checkMemory(cpu Cpu, address int, length int) error {
+	if address < 0 || address+length > cpu.memorySize {
+		return MemoryOutOfBoundsError{ProgramCounter: cpu.programCounter, Opcode: cpu.currentOpcode, Address: address, Length: length}
+	}
+
+	return nil
+}
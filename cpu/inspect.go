@@ -0,0 +1,198 @@
+package cpu
+
+/*
+   This project will be a CHIP-8 emulator in go, for a basic understanding of laearning how to code an emulator
+
+   http://www.multigesture.net/articles/how-to-write-an-emulator-chip-8-interpreter/
+*/
+
+//This is helper class for looking at and changing the cpu from outside, for debuggers and tools
+//Like the rest of the cpu, the Set functions return a changed copy of the cpu
+
+//Function to get a register, V0 to VF
+func GetRegister(cpu Cpu, register int) uint8 {
+	return cpu.registers[register&0xF]
+}
+
+//Function to set a register, V0 to VF
+func SetRegister(cpu Cpu, register int, value uint8) Cpu {
+	cpu.registers[register&0xF] = value
+	return cpu
+}
+
+//Function to get the index register (I)
+func GetIndexRegister(cpu Cpu) uint16 {
+	return cpu.indexRegister
+}
+
+//Function to set the index register (I)
+func SetIndexRegister(cpu Cpu, value uint16) Cpu {
+	cpu.indexRegister = value
+	return cpu
+}
+
+//Function to get the address of the next instruction we will run
+func GetProgramCounter(cpu Cpu) uint16 {
+	return cpu.programCounter
+}
+
+//Function to set the address of the next instruction we will run
+func SetProgramCounter(cpu Cpu, value uint16) Cpu {
+	cpu.programCounter = value
+	cpu.skipProgramCounter = false
+	return cpu
+}
+
+//Function to get the return addresses on the stack, oldest first
+func GetStack(cpu Cpu) []uint16 {
+	stack := make([]uint16, cpu.stackPointer+1)
+	copy(stack, cpu.stack[:cpu.stackPointer+1])
+	return stack
+}
+
+//Function to get the delay timer
+func GetDelayTimer(cpu Cpu) uint8 {
+	return cpu.delayTimer
+}
+
+//Function to set the delay timer
+func SetDelayTimer(cpu Cpu, value uint8) Cpu {
+	cpu.delayTimer = value
+	return cpu
+}
+
+//Function to get the sound timer
+func GetSoundTimer(cpu Cpu) uint8 {
+	return cpu.soundTimer
+}
+
+//Function to set the sound timer
+func SetSoundTimer(cpu Cpu, value uint8) Cpu {
+	cpu.soundTimer = value
+	return cpu
+}
+
+//Function to get the keys pressed on the keypad
+func GetKeys(cpu Cpu) [16]bool {
+	return cpu.keyPad
+}
+
+//Function to get the instruction set we are emulating
+func GetMode(cpu Cpu) Mode {
+	return cpu.mode
+}
+
+//Function to get how much memory the game can use, 4KB, or 64KB for XO-CHIP
+func GetMemorySize(cpu Cpu) int {
+	return cpu.memorySize
+}
+
+//Function to get a byte of memory, anything past the end reads as zero
+func GetMemory(cpu Cpu, address int) byte {
+	if address < 0 || address >= cpu.memorySize {
+		return 0
+	}
+	return cpu.chipMemory[address]
+}
+
+//Function to set a byte of memory, anything past the end is ignored
+func SetMemory(cpu Cpu, address int, value byte) Cpu {
+	if address >= 0 && address < cpu.memorySize {
+		cpu.chipMemory[address] = value
+	}
+	return cpu
+}
+
+//Writes is what the next instruction will write to
+type Writes struct {
+
+	//Bitmask of the registers written, bit 0 for V0
+	Registers uint16
+
+	//True if the index register is written
+	IndexRegister bool
+
+	//Memory written, starting at MemoryAddress
+	MemoryAddress int
+	MemoryLength  int
+}
+
+//Function to find what the next instruction will write to, without running it
+//Used by debuggers to watch registers and memory
+func GetWrites(cpu Cpu) Writes {
+
+	var writes Writes
+	if checkMemory(cpu, int(cpu.programCounter), 2) != nil {
+		return writes
+	}
+
+	opCode := GetOpcode(cpu)
+	regX := int(opCode&0x0F00) >> 8
+	regY := int(opCode&0x00F0) >> 4
+	registerRange := func(first int, last int) uint16 {
+		if first > last {
+			first, last = last, first
+		}
+		var mask uint16
+		for register := first; register <= last; register++ {
+			mask = mask | 1<<uint(register)
+		}
+		return mask
+	}
+
+	switch opCode & 0xF000 {
+	case 0x5000:
+		switch opCode & 0x000F {
+		case 0x0002:
+			//Save vX to vY
+			writes.MemoryAddress = int(cpu.indexRegister)
+			writes.MemoryLength = abs(regX-regY) + 1
+		case 0x0003:
+			//Load vX to vY
+			writes.Registers = registerRange(regX, regY)
+		}
+	case 0x6000, 0x7000, 0xC000:
+		writes.Registers = 1 << uint(regX)
+	case 0x8000:
+		writes.Registers = 1 << uint(regX)
+		if opCode&0x000F != 0 {
+			writes.Registers = writes.Registers | 1<<0xF
+		}
+	case 0xA000:
+		writes.IndexRegister = true
+	case 0xD000:
+		writes.Registers = 1 << 0xF
+	case 0xF000:
+		switch opCode & 0x00FF {
+		case 0x0000:
+			if opCode == 0xF000 {
+				writes.IndexRegister = true
+			}
+		case 0x0007, 0x000A:
+			writes.Registers = 1 << uint(regX)
+		case 0x001E, 0x0029, 0x0030:
+			writes.IndexRegister = true
+		case 0x0033:
+			writes.MemoryAddress = int(cpu.indexRegister)
+			writes.MemoryLength = 3
+		case 0x0055:
+			writes.MemoryAddress = int(cpu.indexRegister)
+			writes.MemoryLength = regX + 1
+			writes.IndexRegister = memoryIncrement(cpu, uint16(regX)) != 0
+		case 0x0065:
+			writes.Registers = registerRange(0, regX)
+			writes.IndexRegister = memoryIncrement(cpu, uint16(regX)) != 0
+		case 0x0085:
+			writes.Registers = registerRange(0, regX)
+		}
+	}
+
+	return writes
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
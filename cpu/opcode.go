@@ -8,14 +8,6 @@ package cpu
 
 //This is helper class for decoding and handling opCodes for chip-8
 
-//Imports
-import (
-	graphics "github.com/torch2424/chipGo/graphics"
-	input "github.com/torch2424/chipGo/input"
-	"fmt"
-	"math/rand"
-)
-
 //Function to return an opCode
 func GetOpcode(cpu Cpu) uint16 {
 	opCode := uint16(cpu.chipMemory[cpu.programCounter])<<8 | uint16(cpu.chipMemory[cpu.programCounter+1])
@@ -32,7 +24,7 @@ func GetOpcode(cpu Cpu) uint16 {
    Much thanks to https://github.com/ejholmes/chip8/blob/master/chip8.go
    Definitely helped in understanding the operations, and what they meant
 */
-func DecodeOpcode(cpu Cpu) Cpu {
+func DecodeOpcode(cpu Cpu) (Cpu, error) {
 
 	//Using bitwise & and | in order to grab nibbles from our two byte opCode. E.g & 0xF000 will return the first nibble
 
@@ -46,16 +38,73 @@ func DecodeOpcode(cpu Cpu) Cpu {
 		switch opCode & 0x00FF {
 		case 0x00E0:
 			//Clear the screen
+			//XO-CHIP only clears the selected planes
+			if cpu.mode == ModeXOChip {
+				cpu = clearPlanes(cpu)
+				break
+			}
+			cpu = ClearGraphics(cpu)
 			cpu.ClearScreen = true
 			break
 		case 0x00EE:
 			//Exit from subroutine
 			//To do this, we need to set the program counter to the top of the stack, and then subtract one from the stack pointer
+			if cpu.stackPointer < 0 {
+				return cpu, StackUnderflowError{ProgramCounter: cpu.programCounter, Opcode: opCode}
+			}
 			cpu.programCounter = cpu.stack[cpu.stackPointer]
 			cpu.stackPointer--
 			break
+		case 0x00FB:
+			//SCHIP: Scroll the display right by 4 pixels
+			if cpu.mode == ModeChip8 {
+				return noOpcode(cpu)
+			}
+			cpu = scrollRight(cpu, 4)
+			break
+		case 0x00FC:
+			//SCHIP: Scroll the display left by 4 pixels
+			if cpu.mode == ModeChip8 {
+				return noOpcode(cpu)
+			}
+			cpu = scrollLeft(cpu, 4)
+			break
+		case 0x00FD:
+			//SCHIP: Exit the interpreter
+			if cpu.mode == ModeChip8 {
+				return noOpcode(cpu)
+			}
+			cpu.Exit = true
+
+			//Stay on this opcode, so nothing else runs if we keep emulating
+			cpu.skipProgramCounter = true
+			break
+		case 0x00FE:
+			//SCHIP: Switch to the low resolution display
+			if cpu.mode == ModeChip8 {
+				return noOpcode(cpu)
+			}
+			cpu = setHighRes(cpu, false)
+			break
+		case 0x00FF:
+			//SCHIP: Switch to the high resolution display
+			if cpu.mode == ModeChip8 {
+				return noOpcode(cpu)
+			}
+			cpu = setHighRes(cpu, true)
+			break
 		default:
-			noOpcode(opCode)
+			//SCHIP: 00CN, Scroll the display down by the last nibble
+			if cpu.mode != ModeChip8 && opCode&0x00F0 == 0x00C0 {
+				cpu = scrollDown(cpu, int(opCode&0x000F))
+				break
+			}
+			//XO-CHIP: 00DN, Scroll the display up by the last nibble
+			if cpu.mode == ModeXOChip && opCode&0x00F0 == 0x00D0 {
+				cpu = scrollUp(cpu, int(opCode&0x000F))
+				break
+			}
+			return noOpcode(cpu)
 		}
 	case 0x1000:
 		//Jump to the adress at the last 3 nibbles (NNN)
@@ -67,6 +116,11 @@ func DecodeOpcode(cpu Cpu) Cpu {
 	case 0x2000:
 		//Call subroutine in the last 3 nibbles (NNN)
 
+		//Make sure we have room on the stack
+		if cpu.stackPointer >= len(cpu.stack)-1 {
+			return cpu, StackOverflowError{ProgramCounter: cpu.programCounter, Opcode: opCode}
+		}
+
 		//Increment our stack ponter
 		cpu.stackPointer++
 
@@ -84,7 +138,7 @@ func DecodeOpcode(cpu Cpu) Cpu {
 
 		//Skip instruction by increasing program counter
 		if cpu.registers[regX] == lastByte {
-			cpu.programCounter = cpu.programCounter + 2
+			cpu = skipNextInstruction(cpu)
 		}
 		break
 	case 0x4000:
@@ -94,17 +148,36 @@ func DecodeOpcode(cpu Cpu) Cpu {
 
 		//Skip instruction by increasing program counter
 		if cpu.registers[regX] != lastByte {
-			cpu.programCounter = cpu.programCounter + 2
+			cpu = skipNextInstruction(cpu)
 		}
 		break
 	case 0x5000:
-		//Skip to the next instruction if Register X equals register Y
 		regX := (opCode & 0x0F00) >> 8
 		regY := (opCode & 0x00F0) >> 4
 
+		//XO-CHIP: Save or load the range of registers from regX to regY at the index register
+		if cpu.mode == ModeXOChip && (opCode&0x000F == 0x0002 || opCode&0x000F == 0x0003) {
+			rangeLength := int(regX) - int(regY)
+			if rangeLength < 0 {
+				rangeLength = -rangeLength
+			}
+			if err := checkMemory(cpu, int(cpu.indexRegister), rangeLength+1); err != nil {
+				return cpu, err
+			}
+		}
+		if cpu.mode == ModeXOChip && opCode&0x000F == 0x0002 {
+			cpu = saveRegisterRange(cpu, regX, regY)
+			break
+		}
+		if cpu.mode == ModeXOChip && opCode&0x000F == 0x0003 {
+			cpu = loadRegisterRange(cpu, regX, regY)
+			break
+		}
+
+		//Skip to the next instruction if Register X equals register Y
 		//Skip instruction by increasing program counter
 		if cpu.registers[regX] == cpu.registers[regY] {
-			cpu.programCounter = cpu.programCounter + 2
+			cpu = skipNextInstruction(cpu)
 		}
 		break
 	case 0x6000:
@@ -134,14 +207,29 @@ func DecodeOpcode(cpu Cpu) Cpu {
 		case 0x0001:
 			//Set regX to regX bitwise OR regY
 			cpu.registers[regX] = cpu.registers[regX] | cpu.registers[regY]
+
+			//The original interpreter used the carry flag as scratch space for logic operations
+			if cpu.Quirks.VFReset {
+				cpu.registers[15] = 0
+			}
 			break
 		case 0x0002:
 			//Set regX to regX bitwise AND regY
 			cpu.registers[regX] = cpu.registers[regX] & cpu.registers[regY]
+
+			//The original interpreter used the carry flag as scratch space for logic operations
+			if cpu.Quirks.VFReset {
+				cpu.registers[15] = 0
+			}
 			break
 		case 0x0003:
 			//Set regX to regX bitwise XOR (Exclusive or) regY
 			cpu.registers[regX] = cpu.registers[regX] ^ cpu.registers[regY]
+
+			//The original interpreter used the carry flag as scratch space for logic operations
+			if cpu.Quirks.VFReset {
+				cpu.registers[15] = 0
+			}
 			break
 		case 0x0004:
 			//regx = Add regX and regY. RegF (Carry flag) is set to 1 if there is a carry. 0 if there is not
@@ -154,16 +242,15 @@ func DecodeOpcode(cpu Cpu) Cpu {
 				carryFlag = 0
 			}
 
-			//Carry flag is in last register
-			cpu.registers[15] = carryFlag
-
+			//Carry flag is in last register, set after the result, so it wins when regX is the carry flag
 			cpu.registers[regX] = uint8(result & 0xFF)
+			cpu.registers[15] = carryFlag
 			break
 		case 0x0005:
 			//regx = Subtract regX and regY. RegF (Carry flag) is set to 1 if there is NOT a borrow. 0 if there is not
 
 			var carryFlag byte
-			if cpu.registers[regX] > cpu.registers[regY] {
+			if cpu.registers[regX] >= cpu.registers[regY] {
 				carryFlag = 1
 			} else {
 				carryFlag = 0
@@ -171,14 +258,18 @@ func DecodeOpcode(cpu Cpu) Cpu {
 
 			result := uint16(cpu.registers[regX]) - uint16(cpu.registers[regY])
 
-			//Carry flag is in last register
-			cpu.registers[15] = carryFlag
-
+			//Carry flag is in last register, set after the result, so it wins when regX is the carry flag
 			cpu.registers[regX] = uint8(result & 0xFF)
+			cpu.registers[15] = carryFlag
 			break
 		case 0x0006:
 			//Shifts regX right by one. carry flag is set to the value of the least significant bit of regX before the shift.
 
+			//The original interpreter shifted regY into regX, CHIP-48 and later shift regX in place
+			if !cpu.Quirks.Shifting {
+				cpu.registers[regX] = cpu.registers[regY]
+			}
+
 			//Carry flag is in last register
 			var carryFlag byte
 			if (cpu.registers[regX] & 0x01) == 0x01 {
@@ -187,15 +278,15 @@ func DecodeOpcode(cpu Cpu) Cpu {
 				carryFlag = 0
 			}
 
-			cpu.registers[15] = carryFlag
-
+			//Set after the result, so it wins when regX is the carry flag
 			cpu.registers[regX] = cpu.registers[regX] >> 1
+			cpu.registers[15] = carryFlag
 			break
 		case 0x0007:
 			//regx = Subtract regY and regX. RegF (Carry flag) is set to 1 if there is NOT a borrow. 0 if there is not
 
 			var carryFlag byte
-			if cpu.registers[regY] > cpu.registers[regX] {
+			if cpu.registers[regY] >= cpu.registers[regX] {
 				carryFlag = 1
 			} else {
 				carryFlag = 0
@@ -203,14 +294,18 @@ func DecodeOpcode(cpu Cpu) Cpu {
 
 			result := uint16(cpu.registers[regY]) - uint16(cpu.registers[regX])
 
-			//Carry flag is in last register
-			cpu.registers[15] = carryFlag
-
+			//Carry flag is in last register, set after the result, so it wins when regX is the carry flag
 			cpu.registers[regX] = uint8(result & 0xFF)
+			cpu.registers[15] = carryFlag
 			break
 		case 0x000E:
 			//Shifts regX left by one. carry flag is set to the value of the most significant bit of regX before the shift.
 
+			//The original interpreter shifted regY into regX, CHIP-48 and later shift regX in place
+			if !cpu.Quirks.Shifting {
+				cpu.registers[regX] = cpu.registers[regY]
+			}
+
 			//Carry flag is in last register
 			var carryFlag byte
 			if (cpu.registers[regX] & 0x80) == 0x80 {
@@ -219,10 +314,12 @@ func DecodeOpcode(cpu Cpu) Cpu {
 				carryFlag = 0
 			}
 
-			cpu.registers[15] = carryFlag
-
+			//Set after the result, so it wins when regX is the carry flag
 			cpu.registers[regX] = cpu.registers[regX] << 1
+			cpu.registers[15] = carryFlag
 			break
+		default:
+			return noOpcode(cpu)
 		}
 	case 0x9000:
 		//Skip instruction if Regx != RegY
@@ -230,7 +327,7 @@ func DecodeOpcode(cpu Cpu) Cpu {
 		regY := (opCode & 0x00F0) >> 4
 
 		if cpu.registers[regX] != cpu.registers[regY] {
-			cpu.programCounter = cpu.programCounter + 2
+			cpu = skipNextInstruction(cpu)
 		}
 		break
 	case 0xA000:
@@ -243,7 +340,13 @@ func DecodeOpcode(cpu Cpu) Cpu {
 		//Jump to the adress in last three nibbles, plus Register 0
 		lastThree := opCode & 0x0FFF
 
-		cpu.programCounter = uint16(cpu.registers[0]) + lastThree
+		//CHIP-48 and SCHIP read this as BXNN, and add regX instead
+		jumpRegister := uint16(0)
+		if cpu.Quirks.Jumping {
+			jumpRegister = (opCode & 0x0F00) >> 8
+		}
+
+		cpu.programCounter = uint16(cpu.registers[jumpRegister]) + lastThree
 
 		//Skip program counter since we jumped
 		cpu.skipProgramCounter = true
@@ -252,16 +355,24 @@ func DecodeOpcode(cpu Cpu) Cpu {
 		//Set register X to bitwise and of last byte and random number
 		regX := (opCode & 0x0F00) >> 8
 		lastByte := byte(opCode)
-		//255 because highest number in a byte
-		ranByte := rand.Intn(255)
+		//Any number in a byte, from our own generator so the same seed gives the same numbers
+		var ranByte uint8
+		cpu, ranByte = nextRandom(cpu)
 
-		cpu.registers[regX] = lastByte & byte(ranByte)
+		cpu.registers[regX] = lastByte & ranByte
 		break
 	case 0xD000:
 		//Sprites stored in memory at location in index register (I), 8bits wide. Wraps around the screen. If when drawn, clears a pixel, last register (carry flag) is set to 1 otherwise it is zero. All drawing is XOR drawing (i.e. it toggles the screen pixels). Sprites are drawn starting at position regX, regY. N is the number of 8bit rows that need to be drawn. If N is greater than 1, second line continues at position regX, regY+1, and so on.
 
 		//This gets really confusing see the section Handling graphics and input on http://www.multigesture.net/articles/how-to-write-an-emulator-chip-8-interpreter/
 
+		//The original interpreter waited for the screen to refresh before drawing, so wait here until our timers tick
+		if cpu.Quirks.DisplayWait && !cpu.vblank {
+			cpu.skipProgramCounter = true
+			break
+		}
+		cpu.vblank = false
+
 		//Get our register indexes
 		regX := (opCode & 0x0F00) >> 8
 		regY := (opCode & 0x00F0) >> 4
@@ -273,61 +384,82 @@ func DecodeOpcode(cpu Cpu) Cpu {
 		//Height of the sprite is the last nibble
 		spriteHeight := opCode & 0x000F
 
-		//Memory read to create the sprite. Starting at index Register to spriteHeight
-		//The colon in the array index [] is a slice, it will return a sub array in the range
-		spriteRegisters := cpu.chipMemory[cpu.indexRegister : cpu.indexRegister+spriteHeight]
+		//Chip-8 sprites are 8 pixels (one byte) wide
+		//SCHIP draws a 16x16 sprite when the height is zero, which is two bytes per row
+		spriteWidth := uint16(8)
+		if spriteHeight == 0 && cpu.mode != ModeChip8 {
+			spriteHeight = 16
+			spriteWidth = 16
+		}
+		bytesPerRow := spriteWidth / 8
+		spriteBytes := spriteHeight * bytesPerRow
+
+		//Get the size of the screen we are drawing to
+		displayWidth, displayHeight := DisplaySize(cpu)
+
+		//The starting position always wraps around the screen
+		xCoorStart := int(xCoorBase) % displayWidth
+		yCoorStart := int(yCoorBase) % displayHeight
 
 		//Go through our graphics array to set the values of the sprite
 		//Creating a boolean to check for collision (if a pixel was already on)
 		var collision bool
-		for i := 0; i < len(spriteRegisters); i++ {
-			//Y Axis (Column)
-			//Our current pixel byte we retrieve from memory
-			//This pixel byte is a row of 8 pixel values
-			pixelRow := spriteRegisters[i]
-
-			for j := 0; j < 8; j++ {
-				//X-axis (Rows), each sprite can only by a byte wide (8 bits)
-
-				//Go bit by bit to see if the pixel is already set to one, if it is, there is a collision, else, simply set it
-				//E.g 0x80 = 1000 000, so pixel and bit checks if first bit of pixel is 1
-				bit := 0x80 >> uint8(j)
-
-				//Check if the pixel has the bit on
-				if pixelRow&byte(bit) != 0 {
-					//Turn the pixel on
-
-					//Get our true x and y corrdinates
-					//If greater than width or height, over flow back around to zero
-					xCoor := xCoorBase + uint8(j)
-					yCoor := yCoorBase + uint8(i)
-
-					//Get out graphics as unsigned ints
-					uIntWidth := uint8(graphics.Width)
-					uIntHeight := uint8(graphics.Height)
-
-					for xCoor >= uIntWidth {
-						xCoor = xCoor - uIntWidth
-					}
 
-					for yCoor >= uIntHeight {
-						yCoor = yCoor - uIntHeight
-					}
+		//XO-CHIP can draw to both bitplanes at once, each plane's sprite comes right after the last one in memory
+		//Outside of XO-CHIP, we only ever draw to the first plane
+		spriteStart := int(cpu.indexRegister)
+		for plane := uint8(1); plane <= 2; plane = plane << 1 {
+			if cpu.planes&plane == 0 {
+				continue
+			}
+
+			//Memory read to create the sprite. Starting at index Register to spriteHeight
+			//The colon in the array index [] is a slice, it will return a sub array in the range
+			if err := checkMemory(cpu, spriteStart, int(spriteBytes)); err != nil {
+				return cpu, err
+			}
+			spriteRegisters := cpu.chipMemory[spriteStart : spriteStart+int(spriteBytes)]
+			spriteStart = spriteStart + int(spriteBytes)
+
+			for i := uint16(0); i < spriteHeight; i++ {
+				//Y Axis (Column)
 
-					//First check if the pixel was already on
-					if cpu.GraphicsDisplay[xCoor][yCoor] == 1 {
-						if DebugMode {
-							fmt.Printf("Collision! found at %d, %d\n", xCoor, yCoor)
+				for j := uint16(0); j < spriteWidth; j++ {
+					//X-axis (Rows)
+
+					//Our current pixel byte we retrieve from memory
+					//This pixel byte is a row of 8 pixel values, wide sprites have two per row
+					pixelRow := spriteRegisters[i*bytesPerRow+j/8]
+
+					//Go bit by bit to see if the pixel is already set to one, if it is, there is a collision, else, simply set it
+					//E.g 0x80 = 1000 000, so pixel and bit checks if first bit of pixel is 1
+					bit := 0x80 >> (j % 8)
+
+					//Check if the pixel has the bit on
+					if pixelRow&byte(bit) != 0 {
+						//Turn the pixel on
+
+						//Get our true x and y corrdinates
+						//If greater than width or height, either clip the pixel, or over flow back around to zero
+						xCoor := xCoorStart + int(j)
+						yCoor := yCoorStart + int(i)
+						if cpu.Quirks.Clipping && (xCoor >= displayWidth || yCoor >= displayHeight) {
+							continue
+						}
+						xCoor = xCoor % displayWidth
+						yCoor = yCoor % displayHeight
+
+						//First check if the pixel was already on in this plane
+						if cpu.GraphicsDisplay[xCoor][yCoor]&plane != 0 {
+							collision = true
 						}
-						collision = true
-					}
 
-					//Set the pixel to on using XOR, ^= in go
-					//XOR is true if values are 1 and 0. if 1 and 1, then zero. if Zero and Zero, then Zero
-					cpu.GraphicsDisplay[xCoor][yCoor] ^= 1
+						//Set the pixel to on using XOR, ^= in go
+						//XOR is true if values are 1 and 0. if 1 and 1, then zero. if Zero and Zero, then Zero
+						cpu.GraphicsDisplay[xCoor][yCoor] ^= plane
+					}
 				}
 			}
-
 		}
 
 		//Set our carry flag to true or false depending on collision
@@ -343,55 +475,86 @@ func DecodeOpcode(cpu Cpu) Cpu {
 	case 0xE000:
 		//Check for key presses at regX
 		regX := (opCode & 0x0F00) >> 8
-		regKey := cpu.registers[regX]
-
-		//Get keys
-		cpu.keyPad, _ = input.GetKeyArray()
+		//Only the low nibble is used, since there are only 16 keys
+		regKey := cpu.registers[regX] & 0x0F
 
 		switch opCode & 0x000F {
 		case 0x000E:
 			//Skips to the next instruction if the Key stored in RegX is pressed
 			if cpu.keyPad[regKey] == true {
-				cpu.programCounter = cpu.programCounter + 2
+				cpu = skipNextInstruction(cpu)
 			}
 			break
 		case 0x0001:
 			//skips if not pressed
 			if cpu.keyPad[regKey] == false {
-				cpu.programCounter = cpu.programCounter + 2
+				cpu = skipNextInstruction(cpu)
 			}
 			break
+		default:
+			return noOpcode(cpu)
 		}
 	case 0xF000:
 		//All going to be RegX manipulations
 		regX := (opCode & 0x0F00) >> 8
+
+		//XO-CHIP: F000 NNNN, Load the next two bytes into the index register, and skip over them
+		if cpu.mode == ModeXOChip && opCode == 0xF000 {
+			if err := checkMemory(cpu, int(cpu.programCounter)+2, 2); err != nil {
+				return cpu, err
+			}
+			cpu.indexRegister = uint16(cpu.chipMemory[cpu.programCounter+2])<<8 | uint16(cpu.chipMemory[cpu.programCounter+3])
+			cpu.programCounter = cpu.programCounter + 2
+			break
+		}
+
 		//Switch Based off of third nibble
 		switch opCode & 0x00FF {
+		case 0x0001:
+			//XO-CHIP: FN01, Select the bitplanes we are drawing to, N is a bitmask of the planes
+			if cpu.mode != ModeXOChip {
+				return noOpcode(cpu)
+			}
+			cpu.planes = uint8(regX) & 0x03
+			break
+		case 0x0002:
+			//XO-CHIP: F002, Load 16 bytes at the index register into the audio pattern buffer
+			if cpu.mode != ModeXOChip || regX != 0 {
+				return noOpcode(cpu)
+			}
+			if err := checkMemory(cpu, int(cpu.indexRegister), len(cpu.audioPattern)); err != nil {
+				return cpu, err
+			}
+			for i := uint16(0); i < uint16(len(cpu.audioPattern)); i++ {
+				cpu.audioPattern[i] = cpu.chipMemory[cpu.indexRegister+i]
+			}
+			break
 		case 0x0007:
 			//Set reg X to the value of the delay timer
 			cpu.registers[regX] = cpu.delayTimer
 			break
 		case 0x000A:
-			//Wait for a key press, and then set the pressed key to regX
-			var keyPressed bool
-			cpu.keyPad, keyPressed = input.GetKeyArray()
-
-			if keyPressed {
+			//Wait for a key to be pressed and let go, like the COSMAC VIP, and then set the key to regX
+			if cpu.keyWaiting {
+				if !cpu.keyPad[cpu.waitKey] {
+					//Set the key index to register X
+					cpu.keyWaiting = false
+					cpu.registers[regX] = cpu.waitKey
+					break
+				}
+			} else {
 				//Loop to find which key was pressed
-				var keyIndex uint8
 				for i := 0; i < len(cpu.keyPad); i++ {
 					if cpu.keyPad[i] == true {
-						keyIndex = uint8(i)
+						cpu.keyWaiting = true
+						cpu.waitKey = uint8(i)
 						i = len(cpu.keyPad)
 					}
 				}
-
-				//Set the key index to register X
-				cpu.registers[regX] = keyIndex
-			} else {
-				//Come back to this opcode, since we are waiting for a key press
-				cpu.programCounter = cpu.programCounter - 2
 			}
+
+			//Come back to this opcode, since we are waiting for a key
+			cpu.programCounter = cpu.programCounter - 2
 			break
 		case 0x0015:
 			//Set the delay timer to regX
@@ -411,9 +574,27 @@ func DecodeOpcode(cpu Cpu) Cpu {
 			//Not quite sure why it is 0x05 being multiplied
 			cpu.indexRegister = uint16(cpu.registers[regX]) * uint16(0x05)
 			break
+		case 0x0030:
+			//SCHIP: Sets indexRegister to the location of the 8x10 big font sprite for the digit in regX
+			if cpu.mode == ModeChip8 {
+				return noOpcode(cpu)
+			}
+			cpu.indexRegister = bigFontStart + uint16(cpu.registers[regX])*uint16(10)
+			break
+		case 0x003A:
+			//XO-CHIP: Set the audio pattern pitch to regX
+			if cpu.mode != ModeXOChip {
+				return noOpcode(cpu)
+			}
+			cpu.audioPitch = cpu.registers[regX]
+			break
 		case 0x033:
 			// I = index register. Stores the binary-coded decimal representation of regX, with the most significant of three digits at the address in index register, the middle digit at indexregoster plus 1, and the least significant digit at indexregister plus 2. (In other words, take the decimal representation of VX, place the hundreds digit in memory at location in I, the tens digit at location I+1, and the ones digit at location I+2.)
 
+			if err := checkMemory(cpu, int(cpu.indexRegister), 3); err != nil {
+				return cpu, err
+			}
+
 			//Hundreds
 			cpu.chipMemory[cpu.indexRegister] = cpu.registers[regX] / 100
 
@@ -426,28 +607,73 @@ func DecodeOpcode(cpu Cpu) Cpu {
 		case 0x0055:
 			//Store Register zero tozero to regX including regX starting at address indexregister
 
+			if err := checkMemory(cpu, int(cpu.indexRegister), int(regX)+1); err != nil {
+				return cpu, err
+			}
+
 			//Loop zero to regX
 			for i := uint16(0); i <= regX; i++ {
 				cpu.chipMemory[cpu.indexRegister+i] = cpu.registers[i]
 			}
+
+			cpu = incrementIndexAfterMemory(cpu, regX)
 			break
 		case 0x0065:
 			//Same as above, but fill the registers instead of storing
+			if err := checkMemory(cpu, int(cpu.indexRegister), int(regX)+1); err != nil {
+				return cpu, err
+			}
+
 			//Loop zero to regX
 			for i := uint16(0); i <= regX; i++ {
 				cpu.registers[i] = cpu.chipMemory[cpu.indexRegister+i]
 			}
+
+			cpu = incrementIndexAfterMemory(cpu, regX)
+			break
+		case 0x0075:
+			//SCHIP: Store Register zero to regX in the RPL user flags
+			if cpu.mode == ModeChip8 {
+				return noOpcode(cpu)
+			}
+			for i := uint16(0); i <= regX; i++ {
+				cpu.rplFlags[i] = cpu.registers[i]
+			}
+			break
+		case 0x0085:
+			//SCHIP: Load Register zero to regX from the RPL user flags
+			if cpu.mode == ModeChip8 {
+				return noOpcode(cpu)
+			}
+			for i := uint16(0); i <= regX; i++ {
+				cpu.registers[i] = cpu.rplFlags[i]
+			}
 			break
+		default:
+			return noOpcode(cpu)
 		}
 	default:
-		noOpcode(opCode)
+		return noOpcode(cpu)
 	}
 
 	//Return the cpu
+	return cpu, nil
+}
+
+//Function to move the index register past the registers we saved or loaded, for the memory quirk
+func incrementIndexAfterMemory(cpu Cpu, regX uint16) Cpu {
+	cpu.indexRegister = cpu.indexRegister + memoryIncrement(cpu, regX)
 	return cpu
 }
 
-func noOpcode(opCode uint16) {
-	err := fmt.Sprintf("\nChipGo Error! Unrecognized opCode! Decimal: %d, Hex: 0x%X\n", opCode, opCode)
-	panic(err)
+//Function to find how far FX55 and FX65 move the index register, for our quirks
+func memoryIncrement(cpu Cpu, regX uint16) uint16 {
+	if cpu.Quirks.MemoryIncrement {
+		if cpu.Quirks.MemoryIncrementByX {
+			return regX
+		}
+		return regX + 1
+	}
+
+	return 0
 }
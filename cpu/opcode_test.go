@@ -0,0 +1,215 @@
+package cpu
+
+//This is the test for opcodes, each runs a small program and checks the registers after it
+
+//Imports
+import (
+	"bytes"
+	"testing"
+)
+
+//A program to run, and what we expect after it
+type opcodeTest struct {
+	name    string
+	mode    Mode
+	quirks  Quirks
+	program []byte
+
+	//Registers and index register before the program runs
+	registers map[int]uint8
+	index     uint16
+
+	//Registers that changed, index register, and program counter we expect after every instruction has run
+	wantRegisters map[int]uint8
+	wantIndex     uint16
+	wantPC        uint16
+}
+
+var opcodeTests = []opcodeTest{
+	{name: "6XNN sets regX", program: []byte{0x6A, 0x42}, wantRegisters: map[int]uint8{0xA: 0x42}, wantPC: 0x202},
+	{name: "7XNN adds without carry", program: []byte{0x71, 0x02}, registers: map[int]uint8{1: 0xFF, 0xF: 0x07}, wantRegisters: map[int]uint8{1: 0x01, 0xF: 0x07}, wantPC: 0x202},
+	{name: "8XY1 with VF reset", quirks: Quirks{VFReset: true}, program: []byte{0x81, 0x21}, registers: map[int]uint8{1: 0x0C, 2: 0x03, 0xF: 0x01}, wantRegisters: map[int]uint8{1: 0x0F, 0xF: 0x00}, wantPC: 0x202},
+	{name: "8XY2 without VF reset", program: []byte{0x81, 0x22}, registers: map[int]uint8{1: 0x0C, 2: 0x06, 0xF: 0x01}, wantRegisters: map[int]uint8{1: 0x04, 0xF: 0x01}, wantPC: 0x202},
+	{name: "8XY4 carries", program: []byte{0x81, 0x24}, registers: map[int]uint8{1: 0xF0, 2: 0x20}, wantRegisters: map[int]uint8{1: 0x10, 0xF: 0x01}, wantPC: 0x202},
+	{name: "8XY4 into VF keeps the carry", program: []byte{0x8F, 0x24}, registers: map[int]uint8{2: 0x01, 0xF: 0x02}, wantRegisters: map[int]uint8{0xF: 0x00}, wantPC: 0x202},
+	{name: "8XY5 equal has no borrow", program: []byte{0x81, 0x25}, registers: map[int]uint8{1: 0x05, 2: 0x05}, wantRegisters: map[int]uint8{1: 0x00, 0xF: 0x01}, wantPC: 0x202},
+	{name: "8XY5 borrows", program: []byte{0x81, 0x25}, registers: map[int]uint8{1: 0x01, 2: 0x02}, wantRegisters: map[int]uint8{1: 0xFF, 0xF: 0x00}, wantPC: 0x202},
+	{name: "8XY7 subtracts regX from regY", program: []byte{0x81, 0x27}, registers: map[int]uint8{1: 0x02, 2: 0x05}, wantRegisters: map[int]uint8{1: 0x03, 0xF: 0x01}, wantPC: 0x202},
+	{name: "8XY6 shifts regY", program: []byte{0x81, 0x26}, registers: map[int]uint8{1: 0x00, 2: 0x03}, wantRegisters: map[int]uint8{1: 0x01, 0xF: 0x01}, wantPC: 0x202},
+	{name: "8XY6 shifts regX with shifting quirk", quirks: Quirks{Shifting: true}, program: []byte{0x81, 0x26}, registers: map[int]uint8{1: 0x04, 2: 0x03}, wantRegisters: map[int]uint8{1: 0x02, 0xF: 0x00}, wantPC: 0x202},
+	{name: "8XYE into VF keeps the carry", program: []byte{0x8F, 0x2E}, registers: map[int]uint8{2: 0x80}, wantRegisters: map[int]uint8{0xF: 0x01}, wantPC: 0x202},
+	{name: "3XNN skips when equal", program: []byte{0x31, 0x07}, registers: map[int]uint8{1: 0x07}, wantRegisters: map[int]uint8{1: 0x07}, wantPC: 0x204},
+	{name: "4XNN doesn't skip when equal", program: []byte{0x41, 0x07}, registers: map[int]uint8{1: 0x07}, wantRegisters: map[int]uint8{1: 0x07}, wantPC: 0x202},
+	{name: "9XY0 skips when not equal", program: []byte{0x91, 0x20}, registers: map[int]uint8{1: 0x01}, wantRegisters: map[int]uint8{1: 0x01}, wantPC: 0x204},
+	{name: "1NNN jumps", program: []byte{0x13, 0x45}, wantPC: 0x345},
+	{name: "2NNN and 00EE return", program: []byte{0x22, 0x04, 0x00, 0x00, 0x00, 0xEE}, wantPC: 0x202},
+	{name: "ANNN sets the index", program: []byte{0xA1, 0x23}, wantIndex: 0x123, wantPC: 0x202},
+	{name: "BNNN jumps from V0", program: []byte{0xB3, 0x00}, registers: map[int]uint8{0: 0x10, 3: 0x20}, wantRegisters: map[int]uint8{0: 0x10, 3: 0x20}, wantPC: 0x310},
+	{name: "BXNN jumps from regX with jumping quirk", quirks: Quirks{Jumping: true}, program: []byte{0xB3, 0x00}, registers: map[int]uint8{0: 0x10, 3: 0x20}, wantRegisters: map[int]uint8{0: 0x10, 3: 0x20}, wantPC: 0x320},
+	{name: "FX1E adds to the index", program: []byte{0xF1, 0x1E}, registers: map[int]uint8{1: 0x10}, index: 0x100, wantRegisters: map[int]uint8{1: 0x10}, wantIndex: 0x110, wantPC: 0x202},
+	{name: "FX65 leaves the index", program: []byte{0xF2, 0x65}, index: 0x300, wantIndex: 0x300, wantPC: 0x202},
+	{name: "FX65 increments the index by X + 1", quirks: Quirks{MemoryIncrement: true}, program: []byte{0xF2, 0x65}, index: 0x300, wantIndex: 0x303, wantPC: 0x202},
+	{name: "FX65 increments the index by X", quirks: Quirks{MemoryIncrement: true, MemoryIncrementByX: true}, program: []byte{0xF2, 0x65}, index: 0x300, wantIndex: 0x302, wantPC: 0x202},
+	{name: "FX65 ignores by X without memory increment", quirks: Quirks{MemoryIncrementByX: true}, program: []byte{0xF2, 0x65}, index: 0x300, wantIndex: 0x300, wantPC: 0x202},
+}
+
+//Function to load a program, and set the registers it starts with
+func newTestCpu(t *testing.T, mode Mode, quirks Quirks, program []byte) Cpu {
+	chipCpu := NewCpu("test", 60, mode)
+	chipCpu.Quirks = quirks
+	chipCpu, err := LoadRom(chipCpu, program)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return chipCpu
+}
+
+//Function to run each opcode test, one cycle per instruction in the program
+func TestOpcodes(t *testing.T) {
+	for _, test := range opcodeTests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			chipCpu := newTestCpu(t, test.mode, test.quirks, test.program)
+			for register, value := range test.registers {
+				chipCpu = SetRegister(chipCpu, register, value)
+			}
+			chipCpu = SetIndexRegister(chipCpu, test.index)
+
+			//Only count the instructions we run, a call runs its return too
+			cycles := len(test.program) / 2
+			if test.program[0]&0xF0 == 0x20 {
+				cycles = 2
+			}
+			for i := 0; i < cycles; i++ {
+				var err error
+				chipCpu, err = EmulateCycle(chipCpu)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			for register := 0; register < 16; register++ {
+				want, written := test.wantRegisters[register]
+				if !written {
+					want = test.registers[register]
+				}
+				if got := GetRegister(chipCpu, register); got != want {
+					t.Errorf("V%X was 0x%02X, expected 0x%02X", register, got, want)
+				}
+			}
+			if got := GetIndexRegister(chipCpu); got != test.wantIndex {
+				t.Errorf("index was 0x%03X, expected 0x%03X", got, test.wantIndex)
+			}
+			if got := GetProgramCounter(chipCpu); got != test.wantPC {
+				t.Errorf("program counter was 0x%03X, expected 0x%03X", got, test.wantPC)
+			}
+		})
+	}
+}
+
+//Function to check FX0A waits for a key to be pressed and let go
+func TestWaitForKey(t *testing.T) {
+	chipCpu := newTestCpu(t, ModeChip8, Quirks{}, []byte{0xF3, 0x0A})
+
+	var keys [16]bool
+	steps := []struct {
+		key    int
+		wantPC uint16
+	}{
+		//No key, then key 7 held, keeps waiting
+		{key: -1, wantPC: 0x200},
+		{key: 7, wantPC: 0x200},
+		{key: 7, wantPC: 0x200},
+
+		//Letting go of key 7 finishes
+		{key: -1, wantPC: 0x202},
+	}
+	for i, step := range steps {
+		keys = [16]bool{}
+		if step.key >= 0 {
+			keys[step.key] = true
+		}
+		chipCpu = SetKeys(chipCpu, keys)
+
+		var err error
+		chipCpu, err = EmulateCycle(chipCpu)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := GetProgramCounter(chipCpu); got != step.wantPC {
+			t.Fatalf("step %d: program counter was 0x%03X, expected 0x%03X", i, got, step.wantPC)
+		}
+	}
+	if got := GetRegister(chipCpu, 3); got != 7 {
+		t.Errorf("V3 was %d, expected key 7", got)
+	}
+}
+
+//Function to check a save state keeps the key FX0A is waiting on
+func TestWaitForKeySaveState(t *testing.T) {
+	chipCpu := newTestCpu(t, ModeChip8, Quirks{}, []byte{0xF3, 0x0A})
+
+	var keys [16]bool
+	keys[7] = true
+	chipCpu = SetKeys(chipCpu, keys)
+	chipCpu, err := EmulateCycle(chipCpu)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var state bytes.Buffer
+	err = WriteState(&state, chipCpu)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := ReadState(&state, newTestCpu(t, ModeChip8, Quirks{}, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//Letting go of key 7 finishes, as it would have before saving
+	loaded = SetKeys(loaded, [16]bool{})
+	loaded, err = EmulateCycle(loaded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := GetProgramCounter(loaded); got != 0x202 {
+		t.Errorf("program counter was 0x%03X, expected 0x202", got)
+	}
+	if got := GetRegister(loaded, 3); got != 7 {
+		t.Errorf("V3 was %d, expected key 7", got)
+	}
+}
+
+//Function to check GetWrites agrees with FX55 on the index register
+func TestWritesMemoryIncrement(t *testing.T) {
+	tests := []struct {
+		quirks    Quirks
+		wantIndex bool
+	}{
+		{quirks: Quirks{}, wantIndex: false},
+		{quirks: Quirks{MemoryIncrement: true}, wantIndex: true},
+		{quirks: Quirks{MemoryIncrement: true, MemoryIncrementByX: true}, wantIndex: true},
+		{quirks: Quirks{MemoryIncrementByX: true}, wantIndex: false},
+	}
+	for _, test := range tests {
+		chipCpu := newTestCpu(t, ModeChip8, test.quirks, []byte{0xF2, 0x55})
+		chipCpu = SetIndexRegister(chipCpu, 0x300)
+
+		writes := GetWrites(chipCpu)
+		if writes.IndexRegister != test.wantIndex {
+			t.Errorf("%+v: writes index was %v, expected %v", test.quirks, writes.IndexRegister, test.wantIndex)
+		}
+		if writes.MemoryAddress != 0x300 || writes.MemoryLength != 3 {
+			t.Errorf("%+v: writes memory was 0x%03X+%d, expected 0x300+3", test.quirks, writes.MemoryAddress, writes.MemoryLength)
+		}
+
+		chipCpu, err := EmulateCycle(chipCpu)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if changed := GetIndexRegister(chipCpu) != 0x300; changed != test.wantIndex {
+			t.Errorf("%+v: index changed was %v, expected %v", test.quirks, changed, test.wantIndex)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package cpu
+
+/*
+   This project will be a CHIP-8 emulator in go, for a basic understanding of laearning how to code an emulator
+
+   http://www.multigesture.net/articles/how-to-write-an-emulator-chip-8-interpreter/
+*/
+
+//This is helper class for quirks, opCodes that behaved differently between Chip-8 interpreters
+//See here for the differences: https://chip8.gulrak.net/#quirk-section
+
+//Imports
+import (
+	"fmt"
+)
+
+//Quirks are the choices we make for the opCodes that interpreters disagree on
+type Quirks struct {
+
+	//8XY1, 8XY2 and 8XY3 reset the carry flag (VF) to zero
+	VFReset bool
+
+	//FX55 and FX65 increment the index register by X + 1 after saving or loading
+	MemoryIncrement bool
+
+	//FX55 and FX65 increment the index register by only X (CHIP-48 bug). Only used if MemoryIncrement is on
+	MemoryIncrementByX bool
+
+	//DXYN waits for the next 60hz frame before drawing, limiting games to 60 sprites per second
+	DisplayWait bool
+
+	//Sprites are clipped at the edges of the screen, instead of wrapping around to the other side
+	Clipping bool
+
+	//8XY6 and 8XYE shift regX in place, instead of shifting regY into regX
+	Shifting bool
+
+	//BXNN jumps to XNN plus regX, instead of BNNN jumping to NNN plus register zero
+	Jumping bool
+}
+
+//Our named quirk presets, for the interpreters games were written for
+var QuirkPresets = map[string]Quirks{
+	//What chipGo has always done, so the games in games/ play the way they always have
+	//Like Super Chip-8, but sprites wrap around the screen
+	"chipgo": Quirks{
+		Shifting: true,
+	},
+	//The original Chip-8 interpreter on the COSMAC VIP
+	"vip": Quirks{
+		VFReset:         true,
+		MemoryIncrement: true,
+		DisplayWait:     true,
+		Clipping:        true,
+	},
+	//CHIP-48 on the HP-48 calculators
+	"chip48": Quirks{
+		MemoryIncrement:    true,
+		MemoryIncrementByX: true,
+		Clipping:           true,
+		Shifting:           true,
+		Jumping:            true,
+	},
+	//Super Chip-8 1.1 on the HP-48 calculators
+	"schip": Quirks{
+		Clipping: true,
+		Shifting: true,
+		Jumping:  true,
+	},
+	//XO-CHIP, as implemented by Octo
+	"xochip": Quirks{
+		MemoryIncrement: true,
+	},
+}
+
+//Function to get the quirks a mode uses, if none are picked
+//Chip-8 games get chipGo's own quirks, pick vip for the original interpreter
+func QuirksForMode(mode Mode) Quirks {
+	switch mode {
+	case ModeSchip:
+		return QuirkPresets["schip"]
+	case ModeXOChip:
+		return QuirkPresets["xochip"]
+	}
+
+	return QuirkPresets["chipgo"]
+}
+
+//Function to find a quirk preset by name
+//"auto" picks the preset for the mode we are emulating
+func ParseQuirks(name string, mode Mode) (Quirks, error) {
+	if name == "auto" {
+		return QuirksForMode(mode), nil
+	}
+
+	quirks, validQuirks := QuirkPresets[name]
+	if !validQuirks {
+		return Quirks{}, fmt.Errorf("Unknown quirks preset: %s", name)
+	}
+
+	return quirks, nil
+}
+
+//Function to pack our quirks into a byte, one bit each, in the order of the Quirks struct
+//Save states and movies keep quirks like this, so new quirks don't change their layout
+//New quirks go in the next bit, and are off in states and movies from before them
+func PackQuirks(quirks Quirks) uint8 {
+	flags := []bool{quirks.VFReset, quirks.MemoryIncrement, quirks.MemoryIncrementByX, quirks.DisplayWait, quirks.Clipping, quirks.Shifting, quirks.Jumping}
+
+	var packed uint8
+	for i, flag := range flags {
+		if flag {
+			packed = packed | 1<<uint(i)
+		}
+	}
+	return packed
+}
+
+//Function to unpack our quirks from a byte
+func UnpackQuirks(packed uint8) Quirks {
+	flag := func(i uint) bool {
+		return packed&(1<<i) != 0
+	}
+
+	return Quirks{
+		VFReset:            flag(0),
+		MemoryIncrement:    flag(1),
+		MemoryIncrementByX: flag(2),
+		DisplayWait:        flag(3),
+		Clipping:           flag(4),
+		Shifting:           flag(5),
+		Jumping:            flag(6),
+	}
+}
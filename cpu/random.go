@@ -0,0 +1,176 @@
+package cpu
+
+/*
+   This project will be a CHIP-8 emulator in go, for a basic understanding of laearning how to code an emulator
+
+   http://www.multigesture.net/articles/how-to-write-an-emulator-chip-8-interpreter/
+*/
+
+//This is helper class for random numbers, used by CXNN
+//The generator lives in the cpu by value, so save states, rewinding, and copies of the cpu all replay the same numbers
+//The same seed always gives the same numbers, so replays and tests can be repeated
+
+//Imports
+import (
+	"fmt"
+	"math/bits"
+)
+
+//RandomAlgorithm is how we make random numbers
+type RandomAlgorithm uint8
+
+const (
+	//xorshift64*, a fast generator with an even spread of numbers
+	RandomXorshift RandomAlgorithm = iota
+
+	//The CXNN routine of the COSMAC VIP's Chip-8 interpreter, see vipInterpreterPage
+	//State is the VIP's R9, which also counts up every frame, like the VIP's interrupt routine does
+	RandomVip
+
+	//The PCG generator of Go's math/rand/v2, giving the same numbers as rand.NewPCG
+	//Its two words of state are kept in StateHigh and State, so it is copied with the cpu like the others
+	RandomGo
+)
+
+//Function to find a random algorithm from it's command line name
+func ParseRandomAlgorithm(name string) (RandomAlgorithm, error) {
+	switch name {
+	case "xorshift":
+		return RandomXorshift, nil
+	case "vip":
+		return RandomVip, nil
+	case "go":
+		return RandomGo, nil
+	}
+
+	return RandomXorshift, fmt.Errorf("Unknown random algorithm: %s", name)
+}
+
+//Function to get the name of a random algorithm
+func (algorithm RandomAlgorithm) String() string {
+	switch algorithm {
+	case RandomXorshift:
+		return "xorshift"
+	case RandomVip:
+		return "vip"
+	case RandomGo:
+		return "go"
+	}
+
+	return "unknown"
+}
+
+//Random is the state of our random number generator
+//State can be saved and set again, e.g. to replay the same numbers
+type Random struct {
+	Algorithm RandomAlgorithm
+	State     uint64
+
+	//Second word of state, only used by the Go generator
+	StateHigh uint64
+}
+
+//Function to make a random number generator from a seed
+func NewRandom(algorithm RandomAlgorithm, seed uint64) Random {
+	switch algorithm {
+	case RandomVip:
+		//The VIP never sets R9, it starts wherever it was
+		return Random{Algorithm: algorithm, State: seed & 0xFFFF}
+	case RandomGo:
+		//The same as rand.NewPCG(seed, 0)
+		return Random{Algorithm: algorithm, StateHigh: seed}
+	}
+
+	//Spread the seed out with splitmix64, since xorshift gets stuck on zero and starts slowly from small seeds
+	state := seed + 0x9E3779B97F4A7C15
+	state = (state ^ (state >> 30)) * 0xBF58476D1CE4E5B9
+	state = (state ^ (state >> 27)) * 0x94D049BB133111EB
+	state = state ^ (state >> 31)
+	if state == 0 {
+		state = 1
+	}
+
+	return Random{Algorithm: algorithm, State: state}
+}
+
+//Function to get the next random byte, any number from 0 to 255
+func nextRandom(cpu Cpu) (Cpu, uint8) {
+	var value uint8
+
+	switch cpu.Random.Algorithm {
+	case RandomVip:
+		//R9 counts up, and its low byte picks a byte of the interpreter to add to its high byte
+		counter := uint16(cpu.Random.State) + 1
+		sum := uint16(vipInterpreterPage[uint8(counter)]) + counter>>8
+
+		//Then the sum is rotated right through the carry, and added to itself
+		rotated := uint8(sum)>>1 | uint8(sum>>8)<<7
+		value = rotated + uint8(sum)
+
+		cpu.Random.State = uint64(value)<<8 | uint64(uint8(counter))
+		break
+	case RandomGo:
+		//Step the 128 bit state, then mix it down with DXSM and use the top byte, like math/rand/v2
+		high, low := bits.Mul64(cpu.Random.State, pcgMultiplierLow)
+		high = high + cpu.Random.StateHigh*pcgMultiplierLow + cpu.Random.State*pcgMultiplierHigh
+		low, carry := bits.Add64(low, pcgIncrementLow, 0)
+		high, _ = bits.Add64(high, pcgIncrementHigh, carry)
+		cpu.Random.StateHigh = high
+		cpu.Random.State = low
+
+		mixed := high ^ (high >> 32)
+		mixed = mixed * 0xDA942042E4DD58B5
+		mixed = mixed ^ (mixed >> 48)
+		mixed = mixed * (low | 1)
+		value = uint8(mixed >> 56)
+		break
+	default:
+		state := cpu.Random.State
+		state = state ^ (state >> 12)
+		state = state ^ (state << 25)
+		state = state ^ (state >> 27)
+
+		cpu.Random.State = state
+		value = uint8((state * 0x2545F4914F6CDD1D) >> 56)
+		break
+	}
+
+	return cpu, value
+}
+
+//Function to count up the VIP's R9, which its interrupt routine does every frame
+func tickRandom(cpu Cpu) Cpu {
+	if cpu.Random.Algorithm == RandomVip {
+		cpu.Random.State = uint64(uint16(cpu.Random.State) + 1)
+	}
+	return cpu
+}
+
+//The 128 bit multiplier and increment of math/rand/v2's PCG
+const (
+	pcgMultiplierHigh uint64 = 2549297995355413924
+	pcgMultiplierLow  uint64 = 4865540595714422341
+	pcgIncrementHigh  uint64 = 6364136223846793005
+	pcgIncrementLow   uint64 = 1442695040888963407
+)
+
+//The second page of the COSMAC VIP's Chip-8 interpreter, 0x100 to 0x1FF, from the listing in the RCA COSMAC VIP manual
+//CXNN adds the byte R9 points to in this page, so the numbers come from the interpreter's own code
+var vipInterpreterPage = [256]byte{
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x45, 0xA3, 0x98, 0x56, 0xD4, 0xF8, 0x81, 0xBC, 0xF8, 0x95, 0xAC,
+	0x22, 0xDC, 0x12, 0x56, 0xD4, 0x06, 0xB8, 0xD4, 0x06, 0xA8, 0xD4, 0x64, 0x0A, 0x01, 0xE6, 0x8A,
+	0xF4, 0xAA, 0x3B, 0x28, 0x9A, 0xFC, 0x01, 0xBA, 0xD4, 0xF8, 0x81, 0xBA, 0x06, 0xFA, 0x0F, 0xAA,
+	0x0A, 0xAA, 0xD4, 0xE6, 0x06, 0xBF, 0x93, 0xBE, 0xF8, 0x1B, 0xAE, 0x2A, 0x1A, 0xF8, 0x00, 0x5A,
+	0x0E, 0xF5, 0x3B, 0x4B, 0x56, 0x0A, 0xFC, 0x01, 0x5A, 0x30, 0x40, 0x4E, 0xF6, 0x3B, 0x3C, 0x9F,
+	0x56, 0x2A, 0x2A, 0xD4, 0x00, 0x22, 0x86, 0x52, 0xF8, 0xF0, 0xA7, 0x07, 0x5A, 0x87, 0xF3, 0x17,
+	0x1A, 0x3A, 0x5B, 0x12, 0xD4, 0x22, 0x86, 0x52, 0xF8, 0xF0, 0xA7, 0x0A, 0x57, 0x87, 0xF3, 0x17,
+	0x1A, 0x3A, 0x6B, 0x12, 0xD4, 0x15, 0x85, 0x22, 0x73, 0x95, 0x52, 0x25, 0x45, 0xA5, 0x86, 0xFA,
+	0x0F, 0xB5, 0xD4, 0x45, 0xE6, 0xF3, 0x3A, 0x82, 0x15, 0x15, 0xD4, 0x45, 0xE6, 0xF3, 0x3A, 0x88,
+	0xD4, 0x45, 0x07, 0x30, 0x8C, 0x45, 0x07, 0x30, 0x84, 0xE6, 0x62, 0x26, 0x45, 0xA3, 0x36, 0x88,
+	0xD4, 0x3E, 0x88, 0xD4, 0xF8, 0xF0, 0xA7, 0xE7, 0x45, 0xF4, 0xA5, 0x86, 0xFA, 0x0F, 0x3B, 0xB2,
+	0xFC, 0x01, 0xB5, 0xD4, 0x45, 0x56, 0xD4, 0x45, 0xE6, 0xF4, 0x56, 0xD4, 0x45, 0xFA, 0x0F, 0x3A,
+	0xC4, 0x07, 0x56, 0xD4, 0xAF, 0x22, 0xF8, 0xD3, 0x73, 0x8F, 0xF9, 0xF0, 0x52, 0xE6, 0x07, 0xD2,
+	0x56, 0xF8, 0xFF, 0xA6, 0xF8, 0x00, 0x7E, 0x56, 0xD4, 0x19, 0x89, 0xAE, 0x93, 0xBE, 0x99, 0xEE,
+	0xF4, 0x56, 0x76, 0xE6, 0xF4, 0xB9, 0x56, 0x45, 0xF2, 0x56, 0xD4, 0x45, 0xAA, 0x86, 0xFA, 0x0F,
+	0xBA, 0xD4, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xE0, 0x00, 0x4B,
+}
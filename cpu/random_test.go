@@ -0,0 +1,45 @@
+package cpu
+
+//This is the test for random numbers, each generator is checked against the numbers of the one it copies
+
+//Imports
+import (
+	"testing"
+)
+
+var randomTests = []struct {
+	name      string
+	algorithm RandomAlgorithm
+	seed      uint64
+
+	//Tick the timers after each number, like a game that picks one number a frame
+	tick bool
+
+	want []uint8
+}{
+	//From rand.NewPCG(seed, 0).Uint64() >> 56
+	{name: "go seed 0", algorithm: RandomGo, seed: 0, want: []uint8{0x38, 0xAC, 0x57, 0x70, 0x66, 0x13, 0xBA, 0x7B}},
+	{name: "go seed 42", algorithm: RandomGo, seed: 42, want: []uint8{0xDB, 0xF5, 0x22, 0x16, 0x2F, 0xF7, 0xED, 0x5D}},
+
+	//From running the interpreter's CXNN routine on an 1802, with R9 starting at 0x1234, and R9 counting up between each
+	{name: "vip", algorithm: RandomVip, seed: 0x1234, tick: true, want: []uint8{0x39, 0x72, 0xD3, 0x7B, 0x2C, 0xC9, 0x9D, 0x5C}},
+}
+
+//Function to check each generator gives the numbers we expect
+func TestRandom(t *testing.T) {
+	for _, test := range randomTests {
+		chipCpu := NewCpu("test", 60, ModeChip8)
+		chipCpu.Random = NewRandom(test.algorithm, test.seed)
+
+		for i, want := range test.want {
+			var got uint8
+			chipCpu, got = nextRandom(chipCpu)
+			if got != want {
+				t.Errorf("%s: number %d was 0x%02X, expected 0x%02X", test.name, i, got, want)
+			}
+			if test.tick {
+				chipCpu = TickTimers(chipCpu)
+			}
+		}
+	}
+}
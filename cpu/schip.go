@@ -0,0 +1,158 @@
+package cpu
+
+/*
+   This project will be a CHIP-8 emulator in go, for a basic understanding of laearning how to code an emulator
+
+   http://www.multigesture.net/articles/how-to-write-an-emulator-chip-8-interpreter/
+*/
+
+//This is helper class for the Super Chip-8 (SCHIP 1.1) extensions
+//See here for the extra opCodes: http://devernay.free.fr/hacks/chip8/schip.txt
+
+//Imports
+import (
+	"fmt"
+)
+
+//Mode is the instruction set the cpu is emulating
+type Mode int
+
+const (
+	//Original Chip-8, 64x32 display
+	ModeChip8 Mode = iota
+	//Super Chip-8 1.1, adds the 128x64 high resolution display, scrolling, and the big font
+	ModeSchip
+	//XO-CHIP, SCHIP plus 64KB of memory, two bitplanes, and audio patterns
+	ModeXOChip
+)
+
+//Names used for our modes on the command line
+var modeNames = map[string]Mode{
+	"chip8":  ModeChip8,
+	"schip":  ModeSchip,
+	"xochip": ModeXOChip,
+}
+
+//Function to find a mode from it's command line name
+func ParseMode(name string) (Mode, error) {
+	mode, validMode := modeNames[name]
+	if !validMode {
+		return ModeChip8, fmt.Errorf("Unknown cpu mode: %s", name)
+	}
+
+	return mode, nil
+}
+
+//Function to get the name of a mode
+func (mode Mode) String() string {
+	for name, value := range modeNames {
+		if value == mode {
+			return name
+		}
+	}
+
+	return fmt.Sprintf("Mode(%d)", int(mode))
+}
+
+//Where the big font is stored in memory, right after our small font set
+const bigFontStart = 0x50
+
+//Big 8x10 font set for the high resolution mode, loaded after our regular font set
+//SCHIP 1.1 only had the digits, A-F are from the later Octo font
+var bigFontSet = [160]byte{
+	0x3C, 0x7E, 0xE7, 0xC3, 0xC3, 0xC3, 0xC3, 0xE7, 0x7E, 0x3C, // 0
+	0x18, 0x38, 0x58, 0x18, 0x18, 0x18, 0x18, 0x18, 0x18, 0x3C, // 1
+	0x3E, 0x7F, 0xC3, 0x06, 0x0C, 0x18, 0x30, 0x60, 0xFF, 0xFF, // 2
+	0x3C, 0x7E, 0xC3, 0x03, 0x0E, 0x0E, 0x03, 0xC3, 0x7E, 0x3C, // 3
+	0x06, 0x0E, 0x1E, 0x36, 0x66, 0xC6, 0xFF, 0xFF, 0x06, 0x06, // 4
+	0xFF, 0xFF, 0xC0, 0xC0, 0xFC, 0xFE, 0x03, 0xC3, 0x7E, 0x3C, // 5
+	0x3E, 0x7C, 0xE0, 0xC0, 0xFC, 0xFE, 0xC3, 0xC3, 0x7E, 0x3C, // 6
+	0xFF, 0xFF, 0x03, 0x06, 0x0C, 0x18, 0x30, 0x60, 0x60, 0x60, // 7
+	0x3C, 0x7E, 0xC3, 0xC3, 0x7E, 0x7E, 0xC3, 0xC3, 0x7E, 0x3C, // 8
+	0x3C, 0x7E, 0xC3, 0xC3, 0x7F, 0x3F, 0x03, 0x03, 0x3E, 0x7C, // 9
+	0x7E, 0xFF, 0xC3, 0xC3, 0xC3, 0xFF, 0xFF, 0xC3, 0xC3, 0xC3, // A
+	0xFC, 0xFC, 0xC3, 0xC3, 0xFC, 0xFC, 0xC3, 0xC3, 0xFC, 0xFC, // B
+	0x3C, 0xFF, 0xC3, 0xC0, 0xC0, 0xC0, 0xC0, 0xC3, 0xFF, 0x3C, // C
+	0xFC, 0xFE, 0xC3, 0xC3, 0xC3, 0xC3, 0xC3, 0xC3, 0xFE, 0xFC, // D
+	0xFF, 0xFF, 0xC0, 0xC0, 0xFF, 0xFF, 0xC0, 0xC0, 0xFF, 0xFF, // E
+	0xFF, 0xFF, 0xC0, 0xC0, 0xFF, 0xFF, 0xC0, 0xC0, 0xC0, 0xC0, // F
+}
+
+//Function to return the size of the display the cpu is currently drawing to
+func DisplaySize(cpu Cpu) (int, int) {
+	if cpu.HighRes {
+		return HiResWidth, HiResHeight
+	}
+
+	return Width, Height
+}
+
+//Function to scroll the display down by a number of pixels, pixels scrolled off the bottom are lost
+//Only the selected planes are scrolled, which is always just the first plane outside of XO-CHIP
+func scrollDown(cpu Cpu, pixels int) Cpu {
+
+	displayWidth, displayHeight := DisplaySize(cpu)
+
+	//Go from the bottom up, so we don't copy a row we already moved
+	for y := displayHeight - 1; y >= 0; y-- {
+		for x := 0; x < displayWidth; x++ {
+			var moved uint8
+			if y-pixels >= 0 {
+				moved = cpu.GraphicsDisplay[x][y-pixels]
+			}
+			cpu.GraphicsDisplay[x][y] = scrollPixel(cpu, cpu.GraphicsDisplay[x][y], moved)
+		}
+	}
+
+	cpu.ShouldRender = true
+	return cpu
+}
+
+//Function to scroll the display right by a number of pixels
+func scrollRight(cpu Cpu, pixels int) Cpu {
+
+	displayWidth, displayHeight := DisplaySize(cpu)
+
+	//Go from the right to the left, so we don't copy a column we already moved
+	for x := displayWidth - 1; x >= 0; x-- {
+		for y := 0; y < displayHeight; y++ {
+			var moved uint8
+			if x-pixels >= 0 {
+				moved = cpu.GraphicsDisplay[x-pixels][y]
+			}
+			cpu.GraphicsDisplay[x][y] = scrollPixel(cpu, cpu.GraphicsDisplay[x][y], moved)
+		}
+	}
+
+	cpu.ShouldRender = true
+	return cpu
+}
+
+//Function to scroll the display left by a number of pixels
+func scrollLeft(cpu Cpu, pixels int) Cpu {
+
+	displayWidth, displayHeight := DisplaySize(cpu)
+
+	for x := 0; x < displayWidth; x++ {
+		for y := 0; y < displayHeight; y++ {
+			var moved uint8
+			if x+pixels < displayWidth {
+				moved = cpu.GraphicsDisplay[x+pixels][y]
+			}
+			cpu.GraphicsDisplay[x][y] = scrollPixel(cpu, cpu.GraphicsDisplay[x][y], moved)
+		}
+	}
+
+	cpu.ShouldRender = true
+	return cpu
+}
+
+//Function to switch between the low and high resolution displays
+//Changing resolution clears the screen, like Octo and most SCHIP games expect
+func setHighRes(cpu Cpu, highRes bool) Cpu {
+	cpu.HighRes = highRes
+	cpu = ClearGraphics(cpu)
+	cpu.ClearScreen = true
+	cpu.ShouldRender = true
+	return cpu
+}
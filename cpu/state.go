@@ -0,0 +1,216 @@
+package cpu
+
+/*
+   This project will be a CHIP-8 emulator in go, for a basic understanding of laearning how to code an emulator
+
+   http://www.multigesture.net/articles/how-to-write-an-emulator-chip-8-interpreter/
+*/
+
+//This is helper class for save states, snapshots of everything a game needs to keep running
+//States are a small header, a fixed size block of the cpu and our random number generator, and then the memory the game can use
+//Everything is big endian, written with encoding/binary
+//Our name, clocks, and speed are not saved, they belong to the emulator and not the game
+
+//Imports
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+//Every save state starts with this, so we don't load some other file
+var stateMagic = [4]byte{'C', 'G', 'S', 'T'}
+
+//Version of our save state layout, increase this when the layout changes
+const StateVersion uint16 = 1
+
+//Header at the start of every save state
+type stateHeader struct {
+	Magic   [4]byte
+	Version uint16
+}
+
+//The cpu, as it is written in a save state
+//Only fixed size fields, so encoding/binary can write it in one go
+type stateCpu struct {
+	Mode   uint8
+	Quirks uint8
+
+	Registers          [16]uint8
+	IndexRegister      uint16
+	ProgramCounter     uint16
+	SkipProgramCounter bool
+	CurrentOpcode      uint16
+
+	Stack        [16]uint16
+	StackPointer int8
+
+	DelayTimer uint8
+	SoundTimer uint8
+	Vblank     bool
+
+	KeyPad [16]bool
+
+	//The key FX0A is waiting on
+	KeyWaiting bool
+	WaitKey    uint8
+
+	GraphicsDisplay [HiResWidth][HiResHeight]uint8
+	HighRes         bool
+	Planes          uint8
+
+	RplFlags     [16]uint8
+	AudioPattern [16]byte
+	AudioPitch   uint8
+	Exit         bool
+
+	//The random number generator, see random.go
+	RandomAlgorithm uint8
+	RandomState     uint64
+	RandomStateHigh uint64
+
+	//How many bytes of memory follow
+	MemorySize uint32
+}
+
+//Function to write a save state of the cpu
+func WriteState(writer io.Writer, cpu Cpu) error {
+
+	err := binary.Write(writer, binary.BigEndian, stateHeader{Magic: stateMagic, Version: StateVersion})
+	if err != nil {
+		return err
+	}
+
+	state := stateCpu{
+		Mode:               uint8(cpu.mode),
+		Quirks:             PackQuirks(cpu.Quirks),
+		Registers:          cpu.registers,
+		IndexRegister:      cpu.indexRegister,
+		ProgramCounter:     cpu.programCounter,
+		SkipProgramCounter: cpu.skipProgramCounter,
+		CurrentOpcode:      cpu.currentOpcode,
+		Stack:              cpu.stack,
+		StackPointer:       int8(cpu.stackPointer),
+		DelayTimer:         cpu.delayTimer,
+		SoundTimer:         cpu.soundTimer,
+		Vblank:             cpu.vblank,
+		KeyPad:             cpu.keyPad,
+		KeyWaiting:         cpu.keyWaiting,
+		WaitKey:            cpu.waitKey,
+		GraphicsDisplay:    cpu.GraphicsDisplay,
+		HighRes:            cpu.HighRes,
+		Planes:             cpu.planes,
+		RplFlags:           cpu.rplFlags,
+		AudioPattern:       cpu.audioPattern,
+		AudioPitch:         cpu.audioPitch,
+		Exit:               cpu.Exit,
+		RandomAlgorithm:    uint8(cpu.Random.Algorithm),
+		RandomState:        cpu.Random.State,
+		RandomStateHigh:    cpu.Random.StateHigh,
+		MemorySize:         uint32(cpu.memorySize),
+	}
+	err = binary.Write(writer, binary.BigEndian, &state)
+	if err != nil {
+		return err
+	}
+
+	_, err = writer.Write(cpu.chipMemory[:cpu.memorySize])
+	return err
+}
+
+//Function to read a save state into the cpu
+//The cpu keeps its name, clocks, and speed, everything else comes from the state
+func ReadState(reader io.Reader, cpu Cpu) (Cpu, error) {
+
+	var header stateHeader
+	err := binary.Read(reader, binary.BigEndian, &header)
+	if err != nil {
+		return cpu, InvalidStateError{Reason: "it is too short"}
+	}
+	if header.Magic != stateMagic {
+		return cpu, InvalidStateError{Reason: "it is not a chipGo save state"}
+	}
+	if header.Version != StateVersion {
+		return cpu, InvalidStateError{Reason: fmt.Sprintf("it is version %d, and we can only load version %d", header.Version, StateVersion)}
+	}
+
+	var state stateCpu
+	err = binary.Read(reader, binary.BigEndian, &state)
+	if err != nil {
+		return cpu, InvalidStateError{Reason: "it is too short"}
+	}
+	random := Random{Algorithm: RandomAlgorithm(state.RandomAlgorithm), State: state.RandomState, StateHigh: state.RandomStateHigh}
+
+	//Check the state makes sense before we change anything
+	mode := Mode(state.Mode)
+	if mode != ModeChip8 && mode != ModeSchip && mode != ModeXOChip {
+		return cpu, InvalidStateError{Reason: fmt.Sprintf("unknown mode %d", state.Mode)}
+	}
+	if int(state.MemorySize) != memorySizeForMode(mode) {
+		return cpu, InvalidStateError{Reason: fmt.Sprintf("%d bytes of memory is the wrong size for %s", state.MemorySize, mode)}
+	}
+	if state.StackPointer < -1 || int(state.StackPointer) >= len(state.Stack) {
+		return cpu, InvalidStateError{Reason: fmt.Sprintf("stack pointer %d is out of range", state.StackPointer)}
+	}
+	if random.Algorithm != RandomXorshift && random.Algorithm != RandomVip && random.Algorithm != RandomGo {
+		return cpu, InvalidStateError{Reason: fmt.Sprintf("unknown random algorithm %d", random.Algorithm)}
+	}
+	if state.WaitKey >= 16 {
+		return cpu, InvalidStateError{Reason: fmt.Sprintf("key %d is out of range", state.WaitKey)}
+	}
+	if random.Algorithm == RandomXorshift && random.State == 0 {
+		//xorshift would only give zeros, see NewRandom
+		return cpu, InvalidStateError{Reason: "random number generator state is zero"}
+	}
+
+	var memory [xoChipMemorySize]byte
+	_, err = io.ReadFull(reader, memory[:state.MemorySize])
+	if err != nil {
+		return cpu, InvalidStateError{Reason: "it is too short"}
+	}
+
+	cpu.mode = mode
+	cpu.memorySize = int(state.MemorySize)
+	cpu.chipMemory = memory
+	cpu.Quirks = UnpackQuirks(state.Quirks)
+	cpu.Random = random
+	cpu.registers = state.Registers
+	cpu.indexRegister = state.IndexRegister
+	cpu.programCounter = state.ProgramCounter
+	cpu.skipProgramCounter = state.SkipProgramCounter
+	cpu.currentOpcode = state.CurrentOpcode
+	cpu.stack = state.Stack
+	cpu.stackPointer = int(state.StackPointer)
+	cpu.delayTimer = state.DelayTimer
+	cpu.soundTimer = state.SoundTimer
+	cpu.vblank = state.Vblank
+	cpu.keyPad = state.KeyPad
+	cpu.keyWaiting = state.KeyWaiting
+	cpu.waitKey = state.WaitKey
+	cpu.GraphicsDisplay = state.GraphicsDisplay
+	cpu.HighRes = state.HighRes
+	cpu.planes = state.Planes
+	cpu.rplFlags = state.RplFlags
+	cpu.audioPattern = state.AudioPattern
+	cpu.audioPitch = state.AudioPitch
+	cpu.Exit = state.Exit
+
+	//Draw the display we loaded
+	cpu.ShouldRender = true
+	cpu.ClearScreen = false
+
+	return cpu, nil
+}
+
+//Function to save the cpu into a save state
+func SaveState(cpu Cpu) ([]byte, error) {
+	var state bytes.Buffer
+	err := WriteState(&state, cpu)
+	return state.Bytes(), err
+}
+
+//Function to load a save state into the cpu
+func LoadState(cpu Cpu, state []byte) (Cpu, error) {
+	return ReadState(bytes.NewReader(state), cpu)
+}
@@ -0,0 +1,129 @@
+package cpu
+
+/*
+   This project will be a CHIP-8 emulator in go, for a basic understanding of laearning how to code an emulator
+
+   http://www.multigesture.net/articles/how-to-write-an-emulator-chip-8-interpreter/
+*/
+
+//This is helper class for the XO-CHIP extensions, used by games written in Octo
+//See here for the extra opCodes: https://johnearnest.github.io/Octo/docs/XO-ChipSpecification.html
+
+//Memory sizes for our modes. Chip-8 and SCHIP have 4KB, XO-CHIP has 64KB
+const chipMemorySize = 4096
+const xoChipMemorySize = 65536
+
+//Default XO-CHIP audio pattern, a square wave, so games that never set a pattern still beep
+var defaultAudioPattern = [16]byte{
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF,
+}
+
+//Default XO-CHIP pitch, 64 plays the audio pattern at 4000 samples per second
+const defaultAudioPitch = 64
+
+//Function to get the amount of memory for a mode
+func memorySizeForMode(mode Mode) int {
+	if mode == ModeXOChip {
+		return xoChipMemorySize
+	}
+
+	return chipMemorySize
+}
+
+//Function to get the XO-CHIP audio pattern buffer, and the pitch it should be played at
+func GetAudioPattern(cpu Cpu) ([16]byte, uint8) {
+	return cpu.audioPattern, cpu.audioPitch
+}
+
+//Function to skip the next instruction
+//XO-CHIP has a four byte instruction (F000 NNNN), so skips need to jump over the whole thing
+func skipNextInstruction(cpu Cpu) Cpu {
+	cpu.programCounter = cpu.programCounter + 2
+
+	if cpu.mode == ModeXOChip && GetOpcode(cpu) == 0xF000 {
+		cpu.programCounter = cpu.programCounter + 2
+	}
+
+	return cpu
+}
+
+//Function to scroll the selected planes of the display up by a number of pixels
+func scrollUp(cpu Cpu, pixels int) Cpu {
+
+	displayWidth, displayHeight := DisplaySize(cpu)
+
+	for y := 0; y < displayHeight; y++ {
+		for x := 0; x < displayWidth; x++ {
+			var moved uint8
+			if y+pixels < displayHeight {
+				moved = cpu.GraphicsDisplay[x][y+pixels]
+			}
+			cpu.GraphicsDisplay[x][y] = scrollPixel(cpu, cpu.GraphicsDisplay[x][y], moved)
+		}
+	}
+
+	cpu.ShouldRender = true
+	return cpu
+}
+
+//Function to move a scrolled pixel, only changing the bits of the selected planes
+func scrollPixel(cpu Cpu, pixel uint8, moved uint8) uint8 {
+	return (pixel &^ cpu.planes) | (moved & cpu.planes)
+}
+
+//Function to clear the selected planes of the display
+func clearPlanes(cpu Cpu) Cpu {
+
+	for i := 0; i < len(cpu.GraphicsDisplay); i++ {
+		for j := 0; j < len(cpu.GraphicsDisplay[i]); j++ {
+			cpu.GraphicsDisplay[i][j] &^= cpu.planes
+		}
+	}
+
+	cpu.ShouldRender = true
+	return cpu
+}
+
+//Function to save the registers from regX to regY in memory at the index register, in either order
+//Unlike FX55, the index register is not changed
+func saveRegisterRange(cpu Cpu, regX uint16, regY uint16) Cpu {
+
+	step := 1
+	if regX > regY {
+		step = -1
+	}
+
+	address := cpu.indexRegister
+	for i := int(regX); ; i += step {
+		cpu.chipMemory[address] = cpu.registers[i]
+		address++
+
+		if i == int(regY) {
+			break
+		}
+	}
+
+	return cpu
+}
+
+//Function to load the registers from regX to regY from memory at the index register, in either order
+func loadRegisterRange(cpu Cpu, regX uint16, regY uint16) Cpu {
+
+	step := 1
+	if regX > regY {
+		step = -1
+	}
+
+	address := cpu.indexRegister
+	for i := int(regX); ; i += step {
+		cpu.registers[i] = cpu.chipMemory[address]
+		address++
+
+		if i == int(regY) {
+			break
+		}
+	}
+
+	return cpu
+}
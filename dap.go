@@ -0,0 +1,73 @@
+package main
+
+//This is helper class for debugging in an editor, see the dap package
+//With --dap stdio the editor runs chipGo and talks to it over stdin and stdout
+//With --dap and an address, the game waits for the editor to connect, e.g. --dap 4711
+//Source lines come from the line map next to the game, e.g. games/pong.ch8.map, or from the Octo compiler
+
+import (
+	cpu "github.com/torch2424/chipGo/cpu"
+	dap "github.com/torch2424/chipGo/dap"
+	linemap "github.com/torch2424/chipGo/linemap"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+)
+
+//Where the editor reads from with --dap stdio
+//runGame sends everything else we print to stderr, so it doesn't get mixed in
+var dapStdout = os.Stdout
+
+//Function to read the line map next to a game
+//Returns nil if there isn't one
+func loadLineMap(gamePath string) *linemap.Map {
+	path := linemap.PathFor(gamePath)
+	_, err := os.Stat(path)
+	if err != nil {
+		return nil
+	}
+
+	lineMap, err := linemap.ReadFile(path)
+	if err != nil {
+		fmt.Println("Failed loading line map, debugging by address only:", err)
+		return nil
+	}
+	print("Loaded line map ", path, "...\n")
+
+	return lineMap
+}
+
+//Function to start our debug adapter, and wait for the editor to connect
+//Returns nil if we are not debugging in an editor
+func newDapServer(emulator *cpu.Emulator, lineMap *linemap.Map) (*dap.Server, error) {
+	if *dapAddress == "" {
+		return nil, nil
+	}
+
+	if *dapAddress == "stdio" {
+		print("Debugging over stdio, waiting for the editor...\n")
+		return dap.New(emulator, lineMap, os.Stdin, dapStdout), nil
+	}
+
+	//Just a port listens on this machine only
+	address := *dapAddress
+	if !strings.Contains(address, ":") {
+		address = "localhost:" + address
+	}
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("Failed starting debug adapter: %s", err)
+	}
+	defer listener.Close()
+
+	print("Waiting for editor on ", listener.Addr().String(), "...\n")
+	connection, err := listener.Accept()
+	if err != nil {
+		return nil, fmt.Errorf("Failed waiting for editor: %s", err)
+	}
+	print("Editor connected!\n\n")
+
+	return dap.New(emulator, lineMap, connection, connection), nil
+}
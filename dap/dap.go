@@ -0,0 +1,270 @@
+package dap
+
+/*
+   Debug Adapter Protocol server for Chip-8, SCHIP, and XO-CHIP games
+
+   Lets editors like VS Code debug a running game, showing the source it came from
+   https://microsoft.github.io/debug-adapter-protocol/specification
+*/
+
+//The frontend calls Step instead of running instructions itself
+//While the game is stopped, Step waits for requests, and runs again when the editor continues or steps
+//Source lines come from a line map, without one the editor can still debug by address and disassembly
+
+//Imports
+import (
+	cpu "github.com/torch2424/chipGo/cpu"
+	linemap "github.com/torch2424/chipGo/linemap"
+	"fmt"
+	"io"
+)
+
+//Chip-8 has one thread, this is its id
+const threadID = 1
+
+//How the game is running
+type runMode int
+
+const (
+	//Run until a breakpoint, or the editor pauses us
+	runContinue runMode = iota
+	//Run one instruction
+	runInstruction
+	//Run until we reach a different line, stepping into calls
+	runStepIn
+	//Run until we reach a different line in this subroutine, stepping over calls
+	runNext
+	//Run until we return from this subroutine
+	runStepOut
+)
+
+//Server is a debug adapter, wrapping an emulator
+type Server struct {
+	emulator *cpu.Emulator
+	lineMap  *linemap.Map
+
+	writer   io.Writer
+	requests chan *request
+	seq      int
+
+	//Addresses we stop at, and the breakpoint id for each
+	breakpoints            map[int]int
+	sourceBreakpoints      map[string][]int
+	instructionBreakpoints []int
+	nextBreakpointID       int
+
+	//True until the editor has sent its breakpoints, and is ready for us to run
+	configuring bool
+	stopOnEntry bool
+
+	//If we are stopped, and how we are stepping
+	//Steps are by line, or by instruction when the editor is showing disassembly
+	stopped       bool
+	mode          runMode
+	byInstruction bool
+	startLine     linemap.Line
+	startDepth    int
+
+	//True once the editor has disconnected
+	disconnected bool
+
+	//The last error from the cpu, returned once the editor lets go of the game
+	err error
+}
+
+//Function to construct a new Server, reading requests from reader and writing to writer
+//Source lines come from lineMap, which can be nil
+//The game doesn't run until the editor has sent its breakpoints
+func New(emulator *cpu.Emulator, lineMap *linemap.Map, reader io.Reader, writer io.Writer) *Server {
+	if lineMap == nil {
+		lineMap = linemap.New(nil, nil)
+	}
+
+	server := &Server{
+		emulator:          emulator,
+		lineMap:           lineMap,
+		writer:            writer,
+		requests:          make(chan *request),
+		breakpoints:       make(map[int]int),
+		sourceBreakpoints: make(map[string][]int),
+		nextBreakpointID:  1,
+		configuring:       true,
+		stopped:           true,
+	}
+	go readRequests(reader, server.requests)
+
+	return server
+}
+
+//Function to pause the game, e.g. from a hotkey, and tell the editor
+func (server *Server) Interrupt() {
+	if !server.disconnected && !server.stopped && !server.configuring {
+		server.stop("pause", "", nil)
+	}
+}
+
+//Function to tell the editor the game has ended
+func (server *Server) Close() {
+	if server.disconnected {
+		return
+	}
+
+	server.sendEvent("terminated", nil)
+	server.sendEvent("exited", map[string]interface{}{"exitCode": 0})
+	server.disconnected = true
+}
+
+//Function to run a single instruction, unless the game is stopped or hits a breakpoint
+//Returns the error from the cpu, once the editor has disconnected
+func (server *Server) Step() error {
+
+	//Without an editor, just run the game
+	if server.disconnected {
+		if server.err != nil {
+			return server.err
+		}
+		return server.emulator.Step()
+	}
+
+	//Check if the editor sent us anything while running, e.g. pause
+	if !server.stopped {
+		select {
+		case next, isOpen := <-server.requests:
+			if !isOpen {
+				server.disconnect()
+				return server.Step()
+			}
+			server.handleRequest(next)
+			break
+		default:
+			break
+		}
+	}
+
+	//Stop at breakpoints, and the end of steps, the instruction we resume from is run first
+	if !server.stopped {
+		server.checkStop()
+	}
+
+	//Wait for the editor to continue or step
+	for server.stopped && !server.disconnected {
+		next, isOpen := <-server.requests
+		if !isOpen {
+			server.disconnect()
+			break
+		}
+		server.handleRequest(next)
+	}
+	if server.disconnected || server.emulator.Cpu.Exit {
+		return server.err
+	}
+
+	//Run the instruction, stopping if the cpu hit an error
+	err := server.emulator.Step()
+	if err != nil {
+		server.err = err
+		server.sendEvent("output", map[string]interface{}{"category": "stderr", "output": err.Error() + "\n"})
+		server.stop("exception", err.Error(), nil)
+		return nil
+	}
+	server.err = nil
+
+	if server.mode == runInstruction {
+		server.stop("step", "", nil)
+	}
+
+	return nil
+}
+
+//Function to check if we should stop before the next instruction, after a step or at a breakpoint
+func (server *Server) checkStop() {
+	chipCpu := server.emulator.Cpu
+	programCounter := int(cpu.GetProgramCounter(chipCpu))
+	depth := len(cpu.GetStack(chipCpu))
+
+	id, isBreakpoint := server.breakpoints[programCounter]
+	if isBreakpoint {
+		server.stop("breakpoint", "", []int{id})
+		return
+	}
+
+	//Stepping by line stops at the start of a different line, or anywhere we don't have a line for
+	line, hasLine := server.lineMap.Find(programCounter)
+	newLine := server.byInstruction || !hasLine || (server.lineMap.IsLineStart(programCounter) && line != server.startLine)
+	switch server.mode {
+	case runStepIn:
+		if newLine {
+			server.stop("step", "", nil)
+		}
+		break
+	case runNext:
+		if depth <= server.startDepth && newLine {
+			server.stop("step", "", nil)
+		}
+		break
+	case runStepOut:
+		if depth < server.startDepth {
+			server.stop("step", "", nil)
+		}
+		break
+	}
+}
+
+//Function to resume the game
+func (server *Server) resume(mode runMode) {
+	chipCpu := server.emulator.Cpu
+	programCounter := int(cpu.GetProgramCounter(chipCpu))
+
+	line, hasLine := server.lineMap.Find(programCounter)
+	server.mode = mode
+	server.startDepth = len(cpu.GetStack(chipCpu))
+	server.startLine = line
+	server.stopped = false
+
+	//Without a line map, stepping into a line is stepping an instruction
+	if mode == runStepIn && (!hasLine || server.byInstruction) {
+		server.mode = runInstruction
+	}
+}
+
+//Function to stop the game, and tell the editor why
+func (server *Server) stop(reason string, text string, breakpoints []int) {
+	server.stopped = true
+
+	body := map[string]interface{}{"reason": reason, "threadId": threadID, "allThreadsStopped": true}
+	if text != "" {
+		body["text"] = text
+		body["description"] = fmt.Sprintf("Paused on %s", reason)
+	}
+	if len(breakpoints) > 0 {
+		body["hitBreakpointIds"] = breakpoints
+	}
+	server.sendEvent("stopped", body)
+}
+
+//Function to let go of the game, when the editor disconnects
+func (server *Server) disconnect() {
+	if !server.disconnected && !server.emulator.Cpu.Exit {
+		print("Editor disconnected, the game keeps running...\n")
+	}
+	server.disconnected = true
+	server.stopped = false
+}
+
+//Function to send an event to the editor
+func (server *Server) sendEvent(name string, body interface{}) {
+	server.seq++
+	writeMessage(server.writer, event{Seq: server.seq, Type: "event", Event: name, Body: body})
+}
+
+//Function to reply to a request
+func (server *Server) respond(to *request, body interface{}) {
+	server.seq++
+	writeMessage(server.writer, response{Seq: server.seq, Type: "response", RequestSeq: to.Seq, Success: true, Command: to.Command, Body: body})
+}
+
+//Function to reply that a request failed
+func (server *Server) fail(to *request, message string) {
+	server.seq++
+	writeMessage(server.writer, response{Seq: server.seq, Type: "response", RequestSeq: to.Seq, Success: false, Command: to.Command, Message: message})
+}
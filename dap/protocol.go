@@ -0,0 +1,111 @@
+package dap
+
+/*
+   Debug Adapter Protocol server for Chip-8, SCHIP, and XO-CHIP games
+
+   Lets editors like VS Code debug a running game, showing the source it came from
+   https://microsoft.github.io/debug-adapter-protocol/specification
+*/
+
+//This is helper class for reading and writing messages
+//Each message is JSON, after a Content-Length header and a blank line
+
+//Imports
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+//A request from the editor
+type request struct {
+	Seq       int             `json:"seq"`
+	Type      string          `json:"type"`
+	Command   string          `json:"command"`
+	Arguments json.RawMessage `json:"arguments"`
+}
+
+//Our reply to a request
+type response struct {
+	Seq        int         `json:"seq"`
+	Type       string      `json:"type"`
+	RequestSeq int         `json:"request_seq"`
+	Success    bool        `json:"success"`
+	Command    string      `json:"command"`
+	Message    string      `json:"message,omitempty"`
+	Body       interface{} `json:"body,omitempty"`
+}
+
+//Something that happened, that we tell the editor about
+type event struct {
+	Seq   int         `json:"seq"`
+	Type  string      `json:"type"`
+	Event string      `json:"event"`
+	Body  interface{} `json:"body,omitempty"`
+}
+
+//Function to read the next message
+func readMessage(reader *bufio.Reader) ([]byte, error) {
+
+	//Headers, until a blank line
+	length := -1
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			break
+		}
+
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) == 2 && strings.EqualFold(strings.TrimSpace(parts[0]), "Content-Length") {
+			length, err = strconv.Atoi(strings.TrimSpace(parts[1]))
+			if err != nil {
+				return nil, fmt.Errorf("bad Content-Length %s", parts[1])
+			}
+		}
+	}
+	if length < 0 {
+		return nil, fmt.Errorf("message is missing a Content-Length")
+	}
+
+	message := make([]byte, length)
+	_, err := io.ReadFull(reader, message)
+	return message, err
+}
+
+//Function to read requests, and send them down a channel until the editor goes away
+func readRequests(reader io.Reader, requests chan<- *request) {
+	defer close(requests)
+
+	buffered := bufio.NewReader(reader)
+	for {
+		message, err := readMessage(buffered)
+		if err != nil {
+			return
+		}
+
+		next := &request{}
+		err = json.Unmarshal(message, next)
+		if err != nil || next.Type != "request" {
+			continue
+		}
+		requests <- next
+	}
+}
+
+//Function to write a message
+func writeMessage(writer io.Writer, message interface{}) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(writer, "Content-Length: %d\r\n\r\n%s", len(data), data)
+	return err
+}
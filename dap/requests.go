@@ -0,0 +1,379 @@
+package dap
+
+/*
+   Debug Adapter Protocol server for Chip-8, SCHIP, and XO-CHIP games
+
+   Lets editors like VS Code debug a running game, showing the source it came from
+   https://microsoft.github.io/debug-adapter-protocol/specification
+*/
+
+//This is helper class for the requests the editor sends us, except for variables and memory
+
+//Imports
+import (
+	cpu "github.com/torch2424/chipGo/cpu"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strconv"
+)
+
+//What we can do, sent in reply to initialize
+//Anything not listed is not supported
+var capabilities = map[string]interface{}{
+	"supportsConfigurationDoneRequest": true,
+	"supportsFunctionBreakpoints":      true,
+	"supportsInstructionBreakpoints":   true,
+	"supportsSetVariable":              true,
+	"supportsEvaluateForHovers":        true,
+	"supportsReadMemoryRequest":        true,
+	"supportsWriteMemoryRequest":       true,
+	"supportsDisassembleRequest":       true,
+	"supportsSteppingGranularity":      true,
+	"supportsTerminateRequest":         true,
+	"supportTerminateDebuggee":         true,
+}
+
+//A source file, as the editor names it
+type source struct {
+	Name string `json:"name,omitempty"`
+	Path string `json:"path,omitempty"`
+}
+
+//A breakpoint, as we tell the editor about it
+type breakpoint struct {
+	ID                   int     `json:"id,omitempty"`
+	Verified             bool    `json:"verified"`
+	Message              string  `json:"message,omitempty"`
+	Source               *source `json:"source,omitempty"`
+	Line                 int     `json:"line,omitempty"`
+	InstructionReference string  `json:"instructionReference,omitempty"`
+}
+
+//A frame of our stack, the current instruction or a subroutine call
+type stackFrame struct {
+	ID                          int     `json:"id"`
+	Name                        string  `json:"name"`
+	Source                      *source `json:"source,omitempty"`
+	Line                        int     `json:"line"`
+	Column                      int     `json:"column"`
+	InstructionPointerReference string  `json:"instructionPointerReference"`
+	PresentationHint            string  `json:"presentationHint,omitempty"`
+}
+
+//Function to handle a request from the editor, and reply
+func (server *Server) handleRequest(next *request) {
+	switch next.Command {
+	case "initialize":
+		server.respond(next, capabilities)
+		server.sendEvent("initialized", nil)
+		break
+	case "launch", "attach":
+		var arguments struct {
+			StopOnEntry bool `json:"stopOnEntry"`
+		}
+		json.Unmarshal(next.Arguments, &arguments)
+		server.stopOnEntry = arguments.StopOnEntry
+		server.respond(next, nil)
+		break
+	case "configurationDone":
+		server.respond(next, nil)
+		server.configuring = false
+		if server.stopOnEntry {
+			server.stop("entry", "", nil)
+		} else {
+			server.resume(runContinue)
+		}
+		break
+	case "setBreakpoints":
+		server.setBreakpoints(next)
+		break
+	case "setFunctionBreakpoints":
+		server.setFunctionBreakpoints(next)
+		break
+	case "setInstructionBreakpoints":
+		server.setInstructionBreakpoints(next)
+		break
+	case "setExceptionBreakpoints":
+		server.respond(next, map[string]interface{}{"breakpoints": []breakpoint{}})
+		break
+	case "threads":
+		threads := []map[string]interface{}{{"id": threadID, "name": server.emulator.Cpu.CpuName}}
+		server.respond(next, map[string]interface{}{"threads": threads})
+		break
+	case "stackTrace":
+		server.stackTrace(next)
+		break
+	case "scopes":
+		server.respond(next, map[string]interface{}{"scopes": scopes})
+		break
+	case "variables":
+		server.variables(next)
+		break
+	case "setVariable":
+		server.setVariable(next)
+		break
+	case "evaluate":
+		server.evaluate(next)
+		break
+	case "readMemory":
+		server.readMemory(next)
+		break
+	case "writeMemory":
+		server.writeMemory(next)
+		break
+	case "disassemble":
+		server.disassemble(next)
+		break
+	case "continue":
+		server.respond(next, map[string]interface{}{"allThreadsContinued": true})
+		server.resume(runContinue)
+		break
+	case "next", "stepIn", "stepOut":
+		var arguments struct {
+			Granularity string `json:"granularity"`
+		}
+		json.Unmarshal(next.Arguments, &arguments)
+		server.byInstruction = arguments.Granularity == "instruction"
+
+		modes := map[string]runMode{"next": runNext, "stepIn": runStepIn, "stepOut": runStepOut}
+		if next.Command == "stepOut" && len(cpu.GetStack(server.emulator.Cpu)) == 0 {
+			server.fail(next, "We are not in a subroutine")
+			break
+		}
+		server.respond(next, nil)
+		server.resume(modes[next.Command])
+		break
+	case "pause":
+		server.respond(next, nil)
+		server.Interrupt()
+		break
+	case "disconnect":
+		var arguments struct {
+			TerminateDebuggee *bool `json:"terminateDebuggee"`
+		}
+		json.Unmarshal(next.Arguments, &arguments)
+		server.respond(next, nil)
+
+		//Games we launched end with the editor, unless it asks us to keep running
+		if arguments.TerminateDebuggee == nil || *arguments.TerminateDebuggee {
+			server.emulator.Cpu.Exit = true
+		}
+		server.disconnect()
+		break
+	case "terminate":
+		server.respond(next, nil)
+		server.emulator.Cpu.Exit = true
+		break
+	default:
+		server.fail(next, fmt.Sprintf("%s is not supported", next.Command))
+		break
+	}
+}
+
+//Function to set the breakpoints in a source file
+//Breakpoints on lines without code move to the next line with code
+func (server *Server) setBreakpoints(next *request) {
+	var arguments struct {
+		Source      source `json:"source"`
+		Breakpoints []struct {
+			Line int `json:"line"`
+		} `json:"breakpoints"`
+	}
+	err := json.Unmarshal(next.Arguments, &arguments)
+	if err != nil {
+		server.fail(next, err.Error())
+		return
+	}
+
+	path := filepath.Clean(arguments.Source.Path)
+	addresses := make([]int, 0)
+	replies := make([]breakpoint, 0)
+	for _, wanted := range arguments.Breakpoints {
+		reply := breakpoint{Source: &arguments.Source, Line: wanted.Line}
+
+		line, hasCode := server.lineMap.NextLine(path, wanted.Line)
+		if hasCode {
+			reply.Line = line
+			lineAddresses := server.lineMap.Addresses(path, line)
+			reply.InstructionReference = formatAddress(lineAddresses[0])
+			addresses = append(addresses, lineAddresses...)
+			reply.Verified = true
+		} else {
+			reply.Message = "No code for this line, is there a line map next to the game?"
+		}
+		replies = append(replies, reply)
+	}
+
+	server.sourceBreakpoints[path] = addresses
+	ids := server.updateBreakpoints()
+	for i := range replies {
+		if replies[i].Verified {
+			address, _ := parseAddress(replies[i].InstructionReference)
+			replies[i].ID = ids[address]
+		}
+	}
+	server.respond(next, map[string]interface{}{"breakpoints": replies})
+}
+
+//Function to set breakpoints on labels, e.g. main
+func (server *Server) setFunctionBreakpoints(next *request) {
+	var arguments struct {
+		Breakpoints []struct {
+			Name string `json:"name"`
+		} `json:"breakpoints"`
+	}
+	err := json.Unmarshal(next.Arguments, &arguments)
+	if err != nil {
+		server.fail(next, err.Error())
+		return
+	}
+
+	addresses := make([]int, 0)
+	for _, wanted := range arguments.Breakpoints {
+		address := -1
+		for _, label := range server.lineMap.Labels {
+			if label.Name == wanted.Name {
+				address = label.Address
+			}
+		}
+		addresses = append(addresses, address)
+	}
+
+	server.sourceBreakpoints[functionBreakpoints] = filterAddresses(addresses)
+	server.respondAddresses(next, addresses, "No label with this name, is there a line map next to the game?")
+}
+
+//Function to set breakpoints on addresses, e.g. from the disassembly view
+func (server *Server) setInstructionBreakpoints(next *request) {
+	var arguments struct {
+		Breakpoints []struct {
+			InstructionReference string `json:"instructionReference"`
+			Offset               int    `json:"offset"`
+		} `json:"breakpoints"`
+	}
+	err := json.Unmarshal(next.Arguments, &arguments)
+	if err != nil {
+		server.fail(next, err.Error())
+		return
+	}
+
+	addresses := make([]int, 0)
+	for _, wanted := range arguments.Breakpoints {
+		address, err := parseAddress(wanted.InstructionReference)
+		if err != nil {
+			address = -1
+		} else {
+			address = address + wanted.Offset
+		}
+		addresses = append(addresses, address)
+	}
+
+	server.instructionBreakpoints = filterAddresses(addresses)
+	server.respondAddresses(next, addresses, "Not an address")
+}
+
+//Key in sourceBreakpoints for function breakpoints, which can't be a file path
+const functionBreakpoints = "\x00functions"
+
+//Function to reply with breakpoints at addresses, -1 for breakpoints we couldn't set
+func (server *Server) respondAddresses(next *request, addresses []int, message string) {
+	ids := server.updateBreakpoints()
+
+	replies := make([]breakpoint, 0, len(addresses))
+	for _, address := range addresses {
+		if address < 0 {
+			replies = append(replies, breakpoint{Verified: false, Message: message})
+			continue
+		}
+		replies = append(replies, breakpoint{ID: ids[address], Verified: true, InstructionReference: formatAddress(address)})
+	}
+	server.respond(next, map[string]interface{}{"breakpoints": replies})
+}
+
+//Function to rebuild every address we stop at, keeping the ids of breakpoints we already had
+func (server *Server) updateBreakpoints() map[int]int {
+	updated := make(map[int]int)
+	add := func(address int) {
+		id, exists := server.breakpoints[address]
+		if !exists {
+			id, exists = updated[address]
+		}
+		if !exists {
+			id = server.nextBreakpointID
+			server.nextBreakpointID++
+		}
+		updated[address] = id
+	}
+
+	for _, addresses := range server.sourceBreakpoints {
+		for _, address := range addresses {
+			add(address)
+		}
+	}
+	for _, address := range server.instructionBreakpoints {
+		add(address)
+	}
+
+	server.breakpoints = updated
+	return updated
+}
+
+//Function to show our stack, the current instruction and then each subroutine call, newest first
+func (server *Server) stackTrace(next *request) {
+	chipCpu := server.emulator.Cpu
+
+	addresses := []int{int(cpu.GetProgramCounter(chipCpu))}
+	stack := cpu.GetStack(chipCpu)
+	for i := len(stack) - 1; i >= 0; i-- {
+		addresses = append(addresses, int(stack[i]))
+	}
+
+	frames := make([]stackFrame, 0, len(addresses))
+	for id, address := range addresses {
+		frame := stackFrame{ID: id, Name: formatAddress(address), InstructionPointerReference: formatAddress(address)}
+
+		label, hasLabel := server.lineMap.Label(address)
+		if hasLabel {
+			frame.Name = label.Name
+			if label.Address != address {
+				frame.Name = fmt.Sprintf("%s+%d", label.Name, address-label.Address)
+			}
+		}
+
+		line, hasLine := server.lineMap.Find(address)
+		if hasLine {
+			frame.Source = &source{Name: filepath.Base(line.File), Path: line.File}
+			frame.Line = line.Line
+			frame.Column = 1
+		} else {
+			frame.PresentationHint = "subtle"
+		}
+
+		frames = append(frames, frame)
+	}
+
+	server.respond(next, map[string]interface{}{"stackFrames": frames, "totalFrames": len(frames)})
+}
+
+//Function to remove the addresses we couldn't find
+func filterAddresses(addresses []int) []int {
+	found := make([]int, 0, len(addresses))
+	for _, address := range addresses {
+		if address >= 0 {
+			found = append(found, address)
+		}
+	}
+	return found
+}
+
+//Function to format an address, for memory and instruction references
+func formatAddress(address int) string {
+	return fmt.Sprintf("0x%04X", address)
+}
+
+//Function to read an address, e.g. 0x02A4
+func parseAddress(text string) (int, error) {
+	address, err := strconv.ParseUint(text, 0, 32)
+	return int(address), err
+}
@@ -0,0 +1,333 @@
+package dap
+
+/*
+   Debug Adapter Protocol server for Chip-8, SCHIP, and XO-CHIP games
+
+   Lets editors like VS Code debug a running game, showing the source it came from
+   https://microsoft.github.io/debug-adapter-protocol/specification
+*/
+
+//This is helper class for the variables, memory, and disassembly views
+//Variables are the registers and timers, and memory is referenced by address, e.g. 0x0300
+
+//Imports
+import (
+	cpu "github.com/torch2424/chipGo/cpu"
+	disasm "github.com/torch2424/chipGo/disasm"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+//References for our scopes, 0 means no children
+const (
+	registersReference = 1
+	timersReference    = 2
+)
+
+//Our scopes, the same for every frame since Chip-8 has no locals
+var scopes = []map[string]interface{}{
+	{"name": "Registers", "presentationHint": "registers", "variablesReference": registersReference, "expensive": false},
+	{"name": "Timers", "variablesReference": timersReference, "expensive": false},
+}
+
+//A variable in the variables view
+type variable struct {
+	Name               string `json:"name"`
+	Value              string `json:"value"`
+	Type               string `json:"type"`
+	EvaluateName       string `json:"evaluateName"`
+	VariablesReference int    `json:"variablesReference"`
+	MemoryReference    string `json:"memoryReference,omitempty"`
+}
+
+//Function to find the names of the variables in a scope
+func variableNames(reference int) []string {
+	if reference == timersReference {
+		return []string{"dt", "st"}
+	}
+
+	names := make([]string, 0, 19)
+	for i := 0; i < 16; i++ {
+		names = append(names, fmt.Sprintf("v%X", i))
+	}
+	return append(names, "i", "pc", "sp")
+}
+
+//Function to read a variable, e.g. v3, i, dt, or [0x300] for a byte of memory
+//Returns the value, its size in bytes, and false if there is no such variable
+func readVariable(chipCpu cpu.Cpu, name string) (int, int, bool) {
+	lower := strings.ToLower(strings.TrimSpace(name))
+
+	switch lower {
+	case "i":
+		return int(cpu.GetIndexRegister(chipCpu)), 2, true
+	case "pc":
+		return int(cpu.GetProgramCounter(chipCpu)), 2, true
+	case "sp":
+		return len(cpu.GetStack(chipCpu)), 1, true
+	case "dt":
+		return int(cpu.GetDelayTimer(chipCpu)), 1, true
+	case "st":
+		return int(cpu.GetSoundTimer(chipCpu)), 1, true
+	}
+
+	if len(lower) == 2 && lower[0] == 'v' {
+		register, err := strconv.ParseUint(lower[1:], 16, 4)
+		if err == nil {
+			return int(cpu.GetRegister(chipCpu, int(register))), 1, true
+		}
+	}
+
+	if strings.HasPrefix(lower, "[") && strings.HasSuffix(lower, "]") {
+		address, err := parseAddress(lower[1 : len(lower)-1])
+		if err == nil && address < cpu.GetMemorySize(chipCpu) {
+			return int(cpu.GetMemory(chipCpu, address)), 1, true
+		}
+	}
+
+	return 0, 0, false
+}
+
+//Function to write a variable
+func writeVariable(chipCpu cpu.Cpu, name string, value int) (cpu.Cpu, error) {
+	_, size, exists := readVariable(chipCpu, name)
+	if !exists {
+		return chipCpu, fmt.Errorf("Unknown variable %s", name)
+	}
+	if value < 0 || value >= 1<<uint(size*8) {
+		return chipCpu, fmt.Errorf("%d doesn't fit in %s", value, name)
+	}
+
+	lower := strings.ToLower(strings.TrimSpace(name))
+	switch lower {
+	case "i":
+		return cpu.SetIndexRegister(chipCpu, uint16(value)), nil
+	case "pc":
+		return cpu.SetProgramCounter(chipCpu, uint16(value)), nil
+	case "sp":
+		return chipCpu, fmt.Errorf("sp can't be changed")
+	case "dt":
+		return cpu.SetDelayTimer(chipCpu, uint8(value)), nil
+	case "st":
+		return cpu.SetSoundTimer(chipCpu, uint8(value)), nil
+	}
+
+	if strings.HasPrefix(lower, "[") {
+		address, _ := parseAddress(lower[1 : len(lower)-1])
+		return cpu.SetMemory(chipCpu, address, uint8(value)), nil
+	}
+
+	register, _ := strconv.ParseUint(lower[1:], 16, 4)
+	return cpu.SetRegister(chipCpu, int(register), uint8(value)), nil
+}
+
+//Function to make a variable for the variables view
+func makeVariable(chipCpu cpu.Cpu, name string) variable {
+	value, size, _ := readVariable(chipCpu, name)
+
+	made := variable{Name: name, Value: fmt.Sprintf("0x%02X", value), Type: "uint8", EvaluateName: name}
+	if size == 2 {
+		made.Value = fmt.Sprintf("0x%04X", value)
+		made.Type = "uint16"
+		made.MemoryReference = formatAddress(value)
+	}
+	return made
+}
+
+//Function to list the variables in a scope
+func (server *Server) variables(next *request) {
+	var arguments struct {
+		VariablesReference int `json:"variablesReference"`
+	}
+	json.Unmarshal(next.Arguments, &arguments)
+
+	list := make([]variable, 0)
+	if arguments.VariablesReference == registersReference || arguments.VariablesReference == timersReference {
+		for _, name := range variableNames(arguments.VariablesReference) {
+			list = append(list, makeVariable(server.emulator.Cpu, name))
+		}
+	}
+
+	server.respond(next, map[string]interface{}{"variables": list})
+}
+
+//Function to change a variable from the variables view
+func (server *Server) setVariable(next *request) {
+	var arguments struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+	json.Unmarshal(next.Arguments, &arguments)
+
+	value, err := parseAddress(strings.TrimSpace(arguments.Value))
+	if err != nil {
+		server.fail(next, fmt.Sprintf("%s is not a number", arguments.Value))
+		return
+	}
+	chipCpu, err := writeVariable(server.emulator.Cpu, arguments.Name, value)
+	if err != nil {
+		server.fail(next, err.Error())
+		return
+	}
+
+	server.emulator.Cpu = chipCpu
+	made := makeVariable(chipCpu, arguments.Name)
+	server.respond(next, map[string]interface{}{"value": made.Value, "type": made.Type})
+}
+
+//Function to work out an expression, for hovers and watches
+//Expressions are a variable, e.g. v3 or [0x300], or a number
+func (server *Server) evaluate(next *request) {
+	var arguments struct {
+		Expression string `json:"expression"`
+	}
+	json.Unmarshal(next.Arguments, &arguments)
+
+	_, _, exists := readVariable(server.emulator.Cpu, arguments.Expression)
+	if exists {
+		made := makeVariable(server.emulator.Cpu, arguments.Expression)
+		body := map[string]interface{}{"result": made.Value, "type": made.Type, "variablesReference": 0}
+		if made.MemoryReference != "" {
+			body["memoryReference"] = made.MemoryReference
+		}
+		server.respond(next, body)
+		return
+	}
+
+	number, err := parseAddress(strings.TrimSpace(arguments.Expression))
+	if err == nil {
+		server.respond(next, map[string]interface{}{"result": fmt.Sprintf("0x%X (%d)", number, number), "variablesReference": 0})
+		return
+	}
+
+	server.fail(next, "Expressions can be v0-vf, i, pc, sp, dt, st, [address], or a number")
+}
+
+//Function to read memory for the memory view
+func (server *Server) readMemory(next *request) {
+	var arguments struct {
+		MemoryReference string `json:"memoryReference"`
+		Offset          int    `json:"offset"`
+		Count           int    `json:"count"`
+	}
+	json.Unmarshal(next.Arguments, &arguments)
+
+	start, err := parseAddress(arguments.MemoryReference)
+	if err != nil {
+		server.fail(next, fmt.Sprintf("Bad memory reference %s", arguments.MemoryReference))
+		return
+	}
+	start = start + arguments.Offset
+
+	//Anything past the end of memory can't be read
+	memorySize := cpu.GetMemorySize(server.emulator.Cpu)
+	count := arguments.Count
+	if start < 0 || start >= memorySize {
+		count = 0
+	} else if start+count > memorySize {
+		count = memorySize - start
+	}
+
+	data := make([]byte, count)
+	for i := range data {
+		data[i] = cpu.GetMemory(server.emulator.Cpu, start+i)
+	}
+
+	server.respond(next, map[string]interface{}{
+		"address":         formatAddress(start),
+		"data":            base64.StdEncoding.EncodeToString(data),
+		"unreadableBytes": arguments.Count - count,
+	})
+}
+
+//Function to write memory from the memory view
+func (server *Server) writeMemory(next *request) {
+	var arguments struct {
+		MemoryReference string `json:"memoryReference"`
+		Offset          int    `json:"offset"`
+		Data            string `json:"data"`
+	}
+	json.Unmarshal(next.Arguments, &arguments)
+
+	start, err := parseAddress(arguments.MemoryReference)
+	if err != nil {
+		server.fail(next, fmt.Sprintf("Bad memory reference %s", arguments.MemoryReference))
+		return
+	}
+	start = start + arguments.Offset
+
+	data, err := base64.StdEncoding.DecodeString(arguments.Data)
+	if err != nil {
+		server.fail(next, err.Error())
+		return
+	}
+	if start < 0 || start+len(data) > cpu.GetMemorySize(server.emulator.Cpu) {
+		server.fail(next, "Memory can't be written past the end")
+		return
+	}
+
+	for i, value := range data {
+		server.emulator.Cpu = cpu.SetMemory(server.emulator.Cpu, start+i, value)
+	}
+	server.respond(next, map[string]interface{}{"offset": arguments.Offset, "bytesWritten": len(data)})
+}
+
+//Function to disassemble memory, for the disassembly view
+//Instructions are always 2 bytes here, so the view lines up with addresses
+func (server *Server) disassemble(next *request) {
+	var arguments struct {
+		MemoryReference   string `json:"memoryReference"`
+		Offset            int    `json:"offset"`
+		InstructionOffset int    `json:"instructionOffset"`
+		InstructionCount  int    `json:"instructionCount"`
+	}
+	json.Unmarshal(next.Arguments, &arguments)
+
+	start, err := parseAddress(arguments.MemoryReference)
+	if err != nil {
+		server.fail(next, fmt.Sprintf("Bad memory reference %s", arguments.MemoryReference))
+		return
+	}
+	start = start + arguments.Offset + arguments.InstructionOffset*2
+
+	chipCpu := server.emulator.Cpu
+	memorySize := cpu.GetMemorySize(chipCpu)
+	instructions := make([]map[string]interface{}, 0, arguments.InstructionCount)
+	for i := 0; i < arguments.InstructionCount; i++ {
+		address := start + i*2
+		instruction := map[string]interface{}{"address": formatAddress(address)}
+		if address < 0 || address+1 >= memorySize {
+			instruction["instruction"] = "??"
+			instruction["presentationHint"] = "invalid"
+			instructions = append(instructions, instruction)
+			continue
+		}
+
+		opCode := uint16(cpu.GetMemory(chipCpu, address))<<8 | uint16(cpu.GetMemory(chipCpu, address+1))
+		following := uint16(cpu.GetMemory(chipCpu, address+2))<<8 | uint16(cpu.GetMemory(chipCpu, address+3))
+		text, _, isInstruction := disasm.Mnemonic(opCode, following, disasm.SyntaxOcto)
+		if !isInstruction {
+			text = fmt.Sprintf("0x%02X 0x%02X", opCode>>8, opCode&0xFF)
+		}
+		instruction["instruction"] = text
+		instruction["instructionBytes"] = fmt.Sprintf("%02X %02X", opCode>>8, opCode&0xFF)
+
+		label, hasLabel := server.lineMap.Label(address)
+		if hasLabel && label.Address == address {
+			instruction["symbol"] = label.Name
+		}
+		if server.lineMap.IsLineStart(address) {
+			line, _ := server.lineMap.Find(address)
+			instruction["location"] = source{Name: filepath.Base(line.File), Path: line.File}
+			instruction["line"] = line.Line
+		}
+
+		instructions = append(instructions, instruction)
+	}
+
+	server.respond(next, map[string]interface{}{"instructions": instructions})
+}
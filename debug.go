@@ -0,0 +1,81 @@
+package main
+
+//This is helper class for debugging, see the debugger package
+//With --debug the game starts paused in the debugger, and F10 pauses it again
+//F10 also pauses the game for gdb or the editor, see gdb.go and dap.go
+
+import (
+	cpu "github.com/torch2424/chipGo/cpu"
+	dap "github.com/torch2424/chipGo/dap"
+	debugger "github.com/torch2424/chipGo/debugger"
+	gdbstub "github.com/torch2424/chipGo/gdbstub"
+	input "github.com/torch2424/chipGo/input"
+	"os"
+)
+
+//Key to press to pause in the debugger
+const debugKey = input.KeyF10
+
+//Anything that can run an instruction, our emulator, debugger, gdb stub, or debug adapter
+type instructionRunner interface {
+	Step() error
+}
+
+//Function to make our debugger, reading commands from the terminal
+//Returns nil if we are not debugging
+func newDebugger(emulator *cpu.Emulator) *debugger.Debugger {
+	if !*debugMode {
+		return nil
+	}
+
+	print("Debugger started, type help for commands, or press F10 in the game to pause\n\n")
+	return debugger.New(emulator, os.Stdin, os.Stdout)
+}
+
+//Function to count how many ways of debugging were asked for, only one can run the game
+func countDebuggers() int {
+	count := 0
+	if *debugMode {
+		count++
+	}
+	if *gdbAddress != "" {
+		count++
+	}
+	if *dapAddress != "" {
+		count++
+	}
+	return count
+}
+
+//Function to pause in the debugger, gdb, or the editor if the debug hotkey was pressed
+func handleDebugHotkeys(chipDebugger *debugger.Debugger, gdbServer *gdbstub.Server, dapServer *dap.Server, hotkeys []input.Hotkey) {
+	for _, hotkey := range hotkeys {
+		if hotkey.Key != debugKey {
+			continue
+		}
+
+		if chipDebugger != nil {
+			chipDebugger.Interrupt()
+		}
+		if gdbServer != nil {
+			gdbServer.Interrupt()
+		}
+		if dapServer != nil {
+			dapServer.Interrupt()
+		}
+	}
+}
+
+//Function to find what runs our instructions, the debugger, gdb, or the editor if we are debugging
+func newInstructionRunner(emulator *cpu.Emulator, chipDebugger *debugger.Debugger, gdbServer *gdbstub.Server, dapServer *dap.Server) instructionRunner {
+	if chipDebugger != nil {
+		return chipDebugger
+	}
+	if gdbServer != nil {
+		return gdbServer
+	}
+	if dapServer != nil {
+		return dapServer
+	}
+	return emulator
+}
@@ -0,0 +1,176 @@
+package debugger
+
+/*
+   Debugger for Chip-8, SCHIP, and XO-CHIP games
+
+   A command line for breakpoints, watchpoints, and stepping through a running game
+*/
+
+//This is helper class for breakpoints and watchpoints
+//Breakpoints stop before an instruction runs, at an address or on an opCode pattern
+//Watchpoints stop after an instruction writes to a register or memory
+
+//Imports
+import (
+	cpu "github.com/torch2424/chipGo/cpu"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+//A place we stop before running an instruction
+type breakpoint struct {
+	id      int
+	enabled bool
+	hits    int
+
+	//Either an address, or an opCode pattern
+	address    int
+	hasAddress bool
+
+	//opCode & mask == value, e.g. Dxyn is value 0xD000 mask 0xF000
+	pattern     string
+	patternMask uint16
+	value       uint16
+
+	//Only stop if this is true, or always if nil
+	condition     *condition
+	conditionText string
+}
+
+//A register or memory we stop after writing to
+type watchpoint struct {
+	id      int
+	enabled bool
+	hits    int
+	place   location
+
+	//How many bytes to watch, for memory
+	length int
+}
+
+//Function to read an opCode pattern, 4 hex digits where anything else matches any digit
+//e.g. Dxyn matches every draw, Fx0A matches every wait for key, 00E0 is just clear screen
+func parsePattern(text string) (uint16, uint16, error) {
+	if len(text) != 4 {
+		return 0, 0, fmt.Errorf("opcode patterns are 4 digits, e.g. Dxyn or 00E0, found %s", text)
+	}
+
+	var value uint16
+	var mask uint16
+	for _, digit := range text {
+		value = value << 4
+		mask = mask << 4
+
+		nibble, err := strconv.ParseUint(string(digit), 16, 4)
+		if err == nil {
+			value = value | uint16(nibble)
+			mask = mask | 0xF
+		}
+	}
+
+	return value, mask, nil
+}
+
+//Function to check if we should stop at a breakpoint before the next instruction
+func (stop *breakpoint) matches(chipCpu cpu.Cpu, opCode uint16) bool {
+	if !stop.enabled {
+		return false
+	}
+
+	if stop.hasAddress {
+		if int(cpu.GetProgramCounter(chipCpu)) != stop.address {
+			return false
+		}
+	} else if opCode&stop.patternMask != stop.value {
+		return false
+	}
+
+	return stop.condition == nil || stop.condition.isTrue(chipCpu)
+}
+
+func (stop *breakpoint) String() string {
+	var text string
+	if stop.hasAddress {
+		text = fmt.Sprintf("Breakpoint %d at 0x%03X", stop.id, stop.address)
+	} else {
+		text = fmt.Sprintf("Breakpoint %d on opcode %s", stop.id, stop.pattern)
+	}
+
+	if stop.conditionText != "" {
+		text = text + " if " + stop.conditionText
+	}
+	if !stop.enabled {
+		text = text + " (disabled)"
+	}
+	if stop.hits > 0 {
+		text = text + formatHits(stop.hits)
+	}
+
+	return text
+}
+
+//Function to check if the next instruction writes to a watchpoint
+func (watch *watchpoint) isWritten(writes cpu.Writes) bool {
+	if !watch.enabled {
+		return false
+	}
+
+	switch watch.place.kind {
+	case locationRegister:
+		return writes.Registers&(1<<uint(watch.place.register)) != 0
+	case locationIndex:
+		return writes.IndexRegister
+	case locationMemory:
+		return writes.MemoryLength > 0 &&
+			writes.MemoryAddress < watch.place.address+watch.length &&
+			watch.place.address < writes.MemoryAddress+writes.MemoryLength
+	}
+
+	return false
+}
+
+//Function to read the value of a watchpoint, so we can show what changed
+func (watch *watchpoint) read(chipCpu cpu.Cpu) []int {
+	if watch.place.kind != locationMemory {
+		return []int{watch.place.get(chipCpu)}
+	}
+
+	values := make([]int, watch.length)
+	for i := range values {
+		values[i] = int(cpu.GetMemory(chipCpu, watch.place.address+i))
+	}
+	return values
+}
+
+func (watch *watchpoint) String() string {
+	text := fmt.Sprintf("Watchpoint %d on %s", watch.id, watch.place)
+	if watch.length > 1 {
+		text = text + fmt.Sprintf(", %d bytes", watch.length)
+	}
+	if !watch.enabled {
+		text = text + " (disabled)"
+	}
+	if watch.hits > 0 {
+		text = text + formatHits(watch.hits)
+	}
+
+	return text
+}
+
+//Function to format how many times we stopped at a breakpoint or watchpoint
+func formatHits(hits int) string {
+	if hits == 1 {
+		return ", hit 1 time"
+	}
+	return fmt.Sprintf(", hit %d times", hits)
+}
+
+//Function to format the values of a watchpoint
+func formatValues(values []int) string {
+	parts := make([]string, len(values))
+	for i, value := range values {
+		parts[i] = fmt.Sprintf("0x%02X", value)
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,455 @@
+package debugger
+
+/*
+   Debugger for Chip-8, SCHIP, and XO-CHIP games
+
+   A command line for breakpoints, watchpoints, and stepping through a running game
+*/
+
+//This is helper class for the commands typed at the prompt
+
+//Imports
+import (
+	cpu "github.com/torch2424/chipGo/cpu"
+	"fmt"
+	"strings"
+)
+
+//Text for the help command
+const helpText = `Running:
+  continue, c              run until a breakpoint or watchpoint
+  step, s [count]          run one instruction, or a number of instructions, stepping into calls
+  next, n                  run one instruction, stepping over calls
+  finish, out              run until this subroutine returns
+  quit, q                  stop the game
+Breakpoints and watchpoints:
+  break, b ADDRESS [if CONDITION]   stop before the instruction at an address
+  break opcode PATTERN [if CONDITION]
+                           stop before any instruction matching a pattern, e.g. Dxyn or Fx0A
+  watch LOCATION [length]  stop after an instruction writes to v0-vf, i, or [address]
+  delete [id]              delete a breakpoint or watchpoint, or all of them
+  enable id, disable id    turn a breakpoint or watchpoint on or off
+  info break               list breakpoints and watchpoints
+Looking around:
+  info registers, regs     show the registers, timers, and stack
+  print, p LOCATION...     show v0-vf, i, pc, dt, st, sp, [address], or [i]
+  set LOCATION = VALUE     change a register, timer, or byte of memory
+  x ADDRESS [count]        show memory
+  list, l [ADDRESS] [count]
+                           show instructions, from the program counter by default
+  backtrace, bt            show the subroutine calls on the stack
+Conditions compare locations and numbers with == != < <= > >=, joined with && and ||
+  e.g. break 0x2A4 if v3 == 7 && [i] != 0
+Press enter to repeat a step, next, or x`
+
+//Function to run a command line
+func (debugger *Debugger) runCommand(line string) error {
+	fields := strings.Fields(line)
+	command := strings.ToLower(fields[0])
+	args := fields[1:]
+
+	if repeatableCommands[command] {
+		debugger.lastCommand = line
+	} else {
+		debugger.lastCommand = ""
+	}
+
+	switch command {
+	case "continue", "c":
+		debugger.mode = runContinue
+		break
+	case "step", "s":
+		count := 1
+		if len(args) > 0 {
+			var err error
+			count, err = parseNumber(args[0])
+			if err != nil {
+				return err
+			}
+		}
+		debugger.stepsLeft = count
+		debugger.mode = runStep
+		break
+	case "next", "n":
+		debugger.stackDepth = len(cpu.GetStack(debugger.emulator.Cpu))
+		debugger.mode = runNext
+		break
+	case "finish", "out":
+		debugger.stackDepth = len(cpu.GetStack(debugger.emulator.Cpu))
+		if debugger.stackDepth == 0 {
+			return fmt.Errorf("we are not in a subroutine")
+		}
+		debugger.mode = runFinish
+		break
+	case "quit", "q":
+		debugger.emulator.Cpu.Exit = true
+		break
+	case "break", "b":
+		return debugger.addBreakpoint(line)
+	case "watch":
+		return debugger.addWatchpoint(args)
+	case "delete", "d":
+		return debugger.deletePoints(args)
+	case "enable", "disable":
+		return debugger.enablePoint(args, command == "enable")
+	case "info", "i":
+		if len(args) == 0 {
+			return fmt.Errorf("info what? break, or registers")
+		}
+		switch strings.ToLower(args[0]) {
+		case "break", "breakpoints", "b", "watch", "watchpoints":
+			debugger.printPoints()
+			break
+		case "registers", "regs", "r":
+			debugger.printRegisters()
+			break
+		default:
+			return fmt.Errorf("info what? break, or registers")
+		}
+		break
+	case "registers", "regs":
+		debugger.printRegisters()
+		break
+	case "print", "p":
+		return debugger.print(args)
+	case "set":
+		return debugger.set(args)
+	case "x":
+		return debugger.examine(args)
+	case "list", "l":
+		return debugger.list(args)
+	case "backtrace", "bt":
+		debugger.backtrace()
+		break
+	case "help", "h", "?":
+		fmt.Fprintln(debugger.output, helpText)
+		break
+	default:
+		return fmt.Errorf("unknown command %s, try help", fields[0])
+	}
+
+	return nil
+}
+
+//Function to split the condition off a command, e.g. break 0x2A4 if v3 == 7
+func splitCondition(line string) (string, *condition, string, error) {
+	index := strings.Index(line, " if ")
+	if index < 0 {
+		return line, nil, "", nil
+	}
+
+	conditionText := strings.TrimSpace(line[index+len(" if "):])
+	parsed, err := parseCondition(conditionText)
+	if err != nil {
+		return line, nil, "", err
+	}
+	return line[:index], parsed, conditionText, nil
+}
+
+//Function to add a breakpoint, e.g. break 0x2A4, break opcode Dxyn if v0 > 10
+func (debugger *Debugger) addBreakpoint(line string) error {
+	line, parsed, conditionText, err := splitCondition(line)
+	if err != nil {
+		return err
+	}
+
+	args := strings.Fields(line)[1:]
+	stop := &breakpoint{id: debugger.nextID, enabled: true, condition: parsed, conditionText: conditionText}
+	if len(args) == 2 && strings.ToLower(args[0]) == "opcode" {
+		stop.pattern = args[1]
+		stop.value, stop.patternMask, err = parsePattern(args[1])
+		if err != nil {
+			return err
+		}
+	} else if len(args) == 1 {
+		stop.hasAddress = true
+		stop.address, err = parseNumber(args[0])
+		if err != nil {
+			return err
+		}
+	} else {
+		return fmt.Errorf("usage: break ADDRESS [if CONDITION], or break opcode PATTERN [if CONDITION]")
+	}
+
+	debugger.breakpoints = append(debugger.breakpoints, stop)
+	debugger.nextID++
+	fmt.Fprintln(debugger.output, stop)
+	return nil
+}
+
+//Function to add a watchpoint, e.g. watch v3, watch i, watch 0x300 4
+func (debugger *Debugger) addWatchpoint(args []string) error {
+	if len(args) == 0 || len(args) > 2 {
+		return fmt.Errorf("usage: watch LOCATION [length]")
+	}
+
+	//Addresses can be written with or without brackets
+	text := args[0]
+	_, err := parseNumber(text)
+	if err == nil {
+		text = "[" + text + "]"
+	}
+	place, err := parseLocation(text)
+	if err != nil {
+		return err
+	}
+
+	watch := &watchpoint{id: debugger.nextID, enabled: true, place: place, length: 1}
+	switch {
+	case place.kind == locationMemory && place.indexed:
+		return fmt.Errorf("[i] changes as the game runs, watch the address instead")
+	case place.kind != locationRegister && place.kind != locationIndex && place.kind != locationMemory:
+		return fmt.Errorf("only v0-vf, i, and memory can be watched")
+	}
+
+	if len(args) == 2 {
+		if place.kind != locationMemory {
+			return fmt.Errorf("only memory can be watched with a length")
+		}
+		watch.length, err = parseNumber(args[1])
+		if err != nil {
+			return err
+		}
+		if watch.length < 1 {
+			return fmt.Errorf("length must be at least 1")
+		}
+	}
+
+	debugger.watchpoints = append(debugger.watchpoints, watch)
+	debugger.nextID++
+	fmt.Fprintln(debugger.output, watch)
+	return nil
+}
+
+//Function to delete a breakpoint or watchpoint, or all of them
+func (debugger *Debugger) deletePoints(args []string) error {
+	if len(args) == 0 {
+		debugger.breakpoints = nil
+		debugger.watchpoints = nil
+		fmt.Fprintln(debugger.output, "Deleted all breakpoints and watchpoints")
+		return nil
+	}
+
+	id, err := parseNumber(args[0])
+	if err != nil {
+		return err
+	}
+
+	for i, stop := range debugger.breakpoints {
+		if stop.id == id {
+			debugger.breakpoints = append(debugger.breakpoints[:i], debugger.breakpoints[i+1:]...)
+			return nil
+		}
+	}
+	for i, watch := range debugger.watchpoints {
+		if watch.id == id {
+			debugger.watchpoints = append(debugger.watchpoints[:i], debugger.watchpoints[i+1:]...)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("no breakpoint or watchpoint %d", id)
+}
+
+//Function to turn a breakpoint or watchpoint on or off
+func (debugger *Debugger) enablePoint(args []string, enabled bool) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: enable id, or disable id")
+	}
+
+	id, err := parseNumber(args[0])
+	if err != nil {
+		return err
+	}
+
+	for _, stop := range debugger.breakpoints {
+		if stop.id == id {
+			stop.enabled = enabled
+			return nil
+		}
+	}
+	for _, watch := range debugger.watchpoints {
+		if watch.id == id {
+			watch.enabled = enabled
+			return nil
+		}
+	}
+
+	return fmt.Errorf("no breakpoint or watchpoint %d", id)
+}
+
+//Function to list our breakpoints and watchpoints
+func (debugger *Debugger) printPoints() {
+	if len(debugger.breakpoints) == 0 && len(debugger.watchpoints) == 0 {
+		fmt.Fprintln(debugger.output, "No breakpoints or watchpoints")
+		return
+	}
+
+	for _, stop := range debugger.breakpoints {
+		fmt.Fprintln(debugger.output, stop)
+	}
+	for _, watch := range debugger.watchpoints {
+		fmt.Fprintln(debugger.output, watch)
+	}
+}
+
+//Function to show the registers, timers, and stack
+func (debugger *Debugger) printRegisters() {
+	chipCpu := debugger.emulator.Cpu
+
+	for row := 0; row < 16; row += 4 {
+		for register := row; register < row+4; register++ {
+			fmt.Fprintf(debugger.output, "v%X 0x%02X  ", register, cpu.GetRegister(chipCpu, register))
+		}
+		fmt.Fprintln(debugger.output)
+	}
+
+	fmt.Fprintf(debugger.output, "i  0x%04X  pc 0x%04X  dt 0x%02X  st 0x%02X\n",
+		cpu.GetIndexRegister(chipCpu), cpu.GetProgramCounter(chipCpu), cpu.GetDelayTimer(chipCpu), cpu.GetSoundTimer(chipCpu))
+
+	stack := cpu.GetStack(chipCpu)
+	fmt.Fprintf(debugger.output, "sp %d", len(stack))
+	for _, address := range stack {
+		fmt.Fprintf(debugger.output, "  0x%03X", address)
+	}
+	fmt.Fprintln(debugger.output)
+}
+
+//Function to show locations, e.g. print v3 i [0x300]
+func (debugger *Debugger) print(args []string) error {
+	if len(args) == 0 {
+		debugger.printRegisters()
+		return nil
+	}
+
+	for _, arg := range args {
+		place, err := parseLocation(arg)
+		if err != nil {
+			return err
+		}
+
+		value := place.get(debugger.emulator.Cpu)
+		fmt.Fprintf(debugger.output, "%s = 0x%02X (%d)\n", place, value, value)
+	}
+
+	return nil
+}
+
+//Function to change a location, e.g. set v3 = 7
+func (debugger *Debugger) set(args []string) error {
+	if len(args) == 3 && args[1] == "=" {
+		args = []string{args[0], args[2]}
+	}
+	if len(args) != 2 {
+		return fmt.Errorf("usage: set LOCATION = VALUE")
+	}
+
+	place, err := parseLocation(args[0])
+	if err != nil {
+		return err
+	}
+	value, err := parseNumber(args[1])
+	if err != nil {
+		return err
+	}
+
+	debugger.emulator.Cpu, err = place.set(debugger.emulator.Cpu, value)
+	if err != nil {
+		return err
+	}
+
+	//Show where we are now, if we jumped
+	if place.kind == locationProgramCounter {
+		debugger.printLocation()
+	}
+	return nil
+}
+
+//Function to show memory, 16 bytes a row, e.g. x 0x300 32, or x i
+func (debugger *Debugger) examine(args []string) error {
+	if len(args) == 0 || len(args) > 2 {
+		return fmt.Errorf("usage: x ADDRESS [count]")
+	}
+
+	start, err := parseOperand(args[0])
+	if err != nil {
+		return err
+	}
+	address := start.get(debugger.emulator.Cpu)
+
+	count := 16
+	if len(args) == 2 {
+		count, err = parseNumber(args[1])
+		if err != nil {
+			return err
+		}
+	}
+
+	for i := 0; i < count; i++ {
+		if i%16 == 0 {
+			if i > 0 {
+				fmt.Fprintln(debugger.output)
+			}
+			fmt.Fprintf(debugger.output, "0x%03X:", address+i)
+		}
+		fmt.Fprintf(debugger.output, " %02X", cpu.GetMemory(debugger.emulator.Cpu, address+i))
+	}
+	fmt.Fprintln(debugger.output)
+
+	//Pressing enter shows the memory after this
+	debugger.lastCommand = fmt.Sprintf("x 0x%X %d", address+count, count)
+	return nil
+}
+
+//Function to show instructions, e.g. list, list 0x2A4 20
+func (debugger *Debugger) list(args []string) error {
+	address := int(cpu.GetProgramCounter(debugger.emulator.Cpu))
+	count := 10
+
+	var err error
+	if len(args) > 0 {
+		start, err := parseOperand(args[0])
+		if err != nil {
+			return err
+		}
+		address = start.get(debugger.emulator.Cpu)
+	}
+	if len(args) > 1 {
+		count, err = parseNumber(args[1])
+		if err != nil {
+			return err
+		}
+	}
+
+	programCounter := int(cpu.GetProgramCounter(debugger.emulator.Cpu))
+	for i := 0; i < count && address < cpu.GetMemorySize(debugger.emulator.Cpu); i++ {
+		marker := ""
+		if address == programCounter {
+			marker = "=>"
+		}
+		for _, stop := range debugger.breakpoints {
+			if stop.hasAddress && stop.address == address {
+				marker = marker + "*"
+				break
+			}
+		}
+
+		fmt.Fprintln(debugger.output, debugger.formatInstruction(address, marker))
+		address = address + debugger.instructionSize(address)
+	}
+
+	return nil
+}
+
+//Function to show the subroutine calls on the stack, newest first
+func (debugger *Debugger) backtrace() {
+	chipCpu := debugger.emulator.Cpu
+
+	fmt.Fprintf(debugger.output, "#0  0x%03X\n", cpu.GetProgramCounter(chipCpu))
+
+	//The stack holds the address of each call, we return to the instruction after it
+	stack := cpu.GetStack(chipCpu)
+	for i := len(stack) - 1; i >= 0; i-- {
+		fmt.Fprintf(debugger.output, "#%d  0x%03X  called from 0x%03X\n", len(stack)-i, stack[i]+2, stack[i])
+	}
+}
@@ -0,0 +1,228 @@
+package debugger
+
+/*
+   Debugger for Chip-8, SCHIP, and XO-CHIP games
+
+   A command line for breakpoints, watchpoints, and stepping through a running game
+*/
+
+//The frontend calls Step instead of running instructions itself
+//When we stop, Step reads commands until the game should run again
+//Type help at the prompt for the commands
+
+//Imports
+import (
+	cpu "github.com/torch2424/chipGo/cpu"
+	disasm "github.com/torch2424/chipGo/disasm"
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+//How the game is running between prompts
+type runMode int
+
+const (
+	//Stop before the next instruction
+	runPaused runMode = iota
+	//Run a number of instructions
+	runStep
+	//Run until we are back in this subroutine, stepping over any calls
+	runNext
+	//Run until we return from this subroutine
+	runFinish
+	//Run until a breakpoint or watchpoint
+	runContinue
+)
+
+//Commands that run again if we just press enter
+var repeatableCommands = map[string]bool{"step": true, "s": true, "next": true, "n": true, "x": true}
+
+//Debugger wraps an emulator, stopping it at breakpoints and watchpoints
+type Debugger struct {
+	emulator *cpu.Emulator
+	input    *bufio.Scanner
+	output   io.Writer
+
+	//How we are running, and how many steps are left, or the stack depth for next and finish
+	mode       runMode
+	stepsLeft  int
+	stackDepth int
+
+	breakpoints []*breakpoint
+	watchpoints []*watchpoint
+	nextID      int
+
+	lastCommand string
+}
+
+//Function to construct a new Debugger, reading commands from in and writing to out
+//The debugger starts paused, so breakpoints can be set before the game runs
+func New(emulator *cpu.Emulator, in io.Reader, out io.Writer) *Debugger {
+	return &Debugger{
+		emulator: emulator,
+		input:    bufio.NewScanner(in),
+		output:   out,
+		mode:     runPaused,
+		nextID:   1,
+	}
+}
+
+//Function to stop the game before the next instruction, e.g. from a hotkey
+func (debugger *Debugger) Interrupt() {
+	debugger.mode = runPaused
+}
+
+//Function to run a single instruction, stopping for commands first if we hit a breakpoint
+//Returns the error from the cpu, after letting the user look around
+func (debugger *Debugger) Step() error {
+
+	if debugger.shouldStop() {
+		debugger.prompt()
+		if debugger.emulator.Cpu.Exit {
+			return nil
+		}
+	}
+
+	//Find what the instruction will write to, before running it
+	writes := cpu.GetWrites(debugger.emulator.Cpu)
+	written := make([]*watchpoint, 0)
+	before := make([][]int, 0)
+	for _, watch := range debugger.watchpoints {
+		if watch.isWritten(writes) {
+			written = append(written, watch)
+			before = append(before, watch.read(debugger.emulator.Cpu))
+		}
+	}
+	programCounter := cpu.GetProgramCounter(debugger.emulator.Cpu)
+
+	//Run the instruction
+	err := debugger.emulator.Step()
+	if err != nil {
+		fmt.Fprintln(debugger.output, err)
+		debugger.mode = runPaused
+		debugger.prompt()
+		return err
+	}
+
+	//Stop on any watchpoints we wrote to
+	for i, watch := range written {
+		watch.hits++
+		fmt.Fprintf(debugger.output, "\nWatchpoint %d: %s written at 0x%03X\nOld value = %s\nNew value = %s\n",
+			watch.id, watch.place, programCounter, formatValues(before[i]), formatValues(watch.read(debugger.emulator.Cpu)))
+		debugger.mode = runPaused
+	}
+
+	return nil
+}
+
+//Function to check if we should stop before the next instruction
+func (debugger *Debugger) shouldStop() bool {
+	chipCpu := debugger.emulator.Cpu
+
+	switch debugger.mode {
+	case runPaused:
+		return true
+	case runStep:
+		debugger.stepsLeft--
+		if debugger.stepsLeft <= 0 {
+			return true
+		}
+		break
+	case runNext:
+		if len(cpu.GetStack(chipCpu)) <= debugger.stackDepth {
+			return true
+		}
+		break
+	case runFinish:
+		if len(cpu.GetStack(chipCpu)) < debugger.stackDepth {
+			return true
+		}
+		break
+	}
+
+	//Check our breakpoints, unless the instruction is outside of memory, the cpu will report that
+	programCounter := int(cpu.GetProgramCounter(chipCpu))
+	if programCounter+1 >= cpu.GetMemorySize(chipCpu) {
+		return false
+	}
+	opCode := cpu.GetOpcode(chipCpu)
+	for _, stop := range debugger.breakpoints {
+		if stop.matches(chipCpu, opCode) {
+			stop.hits++
+			fmt.Fprintf(debugger.output, "\nBreakpoint %d, 0x%03X\n", stop.id, programCounter)
+			return true
+		}
+	}
+
+	return false
+}
+
+//Function to read commands until the game should run again
+func (debugger *Debugger) prompt() {
+	debugger.mode = runPaused
+	debugger.printLocation()
+
+	for debugger.mode == runPaused && !debugger.emulator.Cpu.Exit {
+		fmt.Fprint(debugger.output, "(chipgo) ")
+
+		//Nothing left to read, e.g. ctrl+d, so quit like we were asked to
+		if !debugger.input.Scan() {
+			fmt.Fprintln(debugger.output)
+			debugger.emulator.Cpu.Exit = true
+			return
+		}
+
+		//Enter repeats the last step, so we can keep stepping
+		line := strings.TrimSpace(debugger.input.Text())
+		if line == "" {
+			line = debugger.lastCommand
+		}
+		if line == "" {
+			continue
+		}
+
+		err := debugger.runCommand(line)
+		if err != nil {
+			fmt.Fprintln(debugger.output, err)
+		}
+	}
+}
+
+//Function to print where we are stopped
+func (debugger *Debugger) printLocation() {
+	programCounter := int(cpu.GetProgramCounter(debugger.emulator.Cpu))
+	fmt.Fprintln(debugger.output, debugger.formatInstruction(programCounter, "=>"))
+}
+
+//Function to format the instruction at an address, e.g. 0x2A4  D015  sprite v0 v1 0x5
+func (debugger *Debugger) formatInstruction(address int, marker string) string {
+	chipCpu := debugger.emulator.Cpu
+
+	opCode := uint16(cpu.GetMemory(chipCpu, address))<<8 | uint16(cpu.GetMemory(chipCpu, address+1))
+	next := uint16(cpu.GetMemory(chipCpu, address+2))<<8 | uint16(cpu.GetMemory(chipCpu, address+3))
+	text, size, isInstruction := disasm.Mnemonic(opCode, next, disasm.SyntaxOcto)
+	if !isInstruction {
+		text = "(not an instruction)"
+	}
+
+	code := fmt.Sprintf("%04X", opCode)
+	if size == 4 {
+		code = fmt.Sprintf("%04X %04X", opCode, next)
+	}
+
+	return fmt.Sprintf("%-3s 0x%03X  %-9s  %s", marker, address, code, text)
+}
+
+//Function to find the size of the instruction at an address, in bytes
+func (debugger *Debugger) instructionSize(address int) int {
+	chipCpu := debugger.emulator.Cpu
+
+	opCode := uint16(cpu.GetMemory(chipCpu, address))<<8 | uint16(cpu.GetMemory(chipCpu, address+1))
+	_, size, isInstruction := disasm.Mnemonic(opCode, 0, disasm.SyntaxOcto)
+	if !isInstruction || size < 2 {
+		return 2
+	}
+	return size
+}
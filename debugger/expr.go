@@ -0,0 +1,309 @@
+package debugger
+
+/*
+   Debugger for Chip-8, SCHIP, and XO-CHIP games
+
+   A command line for breakpoints, watchpoints, and stepping through a running game
+*/
+
+//This is helper class for the values and conditions used by commands
+//e.g. print v3, set [0x300] = 0xFF, break 0x2A4 if v3 == 7 && i > 0x300
+
+//Imports
+import (
+	cpu "github.com/torch2424/chipGo/cpu"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+//Kinds of places in the cpu we can read and write
+type locationKind int
+
+const (
+	locationRegister locationKind = iota
+	locationIndex
+	locationProgramCounter
+	locationDelayTimer
+	locationSoundTimer
+	locationStackPointer
+	locationMemory
+)
+
+//A place in the cpu, e.g. v3, i, or [0x300]
+type location struct {
+	kind     locationKind
+	register int
+
+	//For memory, the address, or the index register if indexed is true, e.g. [i]
+	address int
+	indexed bool
+}
+
+//Function to read a location, e.g. v3, i, pc, dt, st, sp, [0x300], or [i]
+func parseLocation(text string) (location, error) {
+	lower := strings.ToLower(text)
+
+	switch lower {
+	case "i":
+		return location{kind: locationIndex}, nil
+	case "pc":
+		return location{kind: locationProgramCounter}, nil
+	case "dt", "delay":
+		return location{kind: locationDelayTimer}, nil
+	case "st", "sound":
+		return location{kind: locationSoundTimer}, nil
+	case "sp":
+		return location{kind: locationStackPointer}, nil
+	case "[i]":
+		return location{kind: locationMemory, indexed: true}, nil
+	}
+
+	if len(lower) == 2 && lower[0] == 'v' {
+		register, err := strconv.ParseUint(lower[1:], 16, 4)
+		if err == nil {
+			return location{kind: locationRegister, register: int(register)}, nil
+		}
+	}
+
+	if strings.HasPrefix(lower, "[") && strings.HasSuffix(lower, "]") {
+		address, err := parseNumber(lower[1 : len(lower)-1])
+		if err == nil {
+			return location{kind: locationMemory, address: address}, nil
+		}
+	}
+
+	return location{}, fmt.Errorf("unknown location %s, try v0-vf, i, pc, dt, st, sp, [address], or [i]", text)
+}
+
+//Function to find the memory address of a location
+func (place location) memoryAddress(chipCpu cpu.Cpu) int {
+	if place.indexed {
+		return int(cpu.GetIndexRegister(chipCpu))
+	}
+	return place.address
+}
+
+//Function to read the value at a location
+func (place location) get(chipCpu cpu.Cpu) int {
+	switch place.kind {
+	case locationRegister:
+		return int(cpu.GetRegister(chipCpu, place.register))
+	case locationIndex:
+		return int(cpu.GetIndexRegister(chipCpu))
+	case locationProgramCounter:
+		return int(cpu.GetProgramCounter(chipCpu))
+	case locationDelayTimer:
+		return int(cpu.GetDelayTimer(chipCpu))
+	case locationSoundTimer:
+		return int(cpu.GetSoundTimer(chipCpu))
+	case locationStackPointer:
+		return len(cpu.GetStack(chipCpu))
+	case locationMemory:
+		return int(cpu.GetMemory(chipCpu, place.memoryAddress(chipCpu)))
+	}
+
+	return 0
+}
+
+//Function to write a value to a location
+//Returns the cpu unchanged if the value doesn't fit
+func (place location) set(chipCpu cpu.Cpu, value int) (cpu.Cpu, error) {
+	highest := 0xFF
+	if place.kind == locationIndex || place.kind == locationProgramCounter {
+		highest = 0xFFFF
+	}
+	if value < 0 || value > highest {
+		return chipCpu, fmt.Errorf("%d is out of range for %s, must be from 0 to %d", value, place, highest)
+	}
+
+	switch place.kind {
+	case locationRegister:
+		return cpu.SetRegister(chipCpu, place.register, uint8(value)), nil
+	case locationIndex:
+		return cpu.SetIndexRegister(chipCpu, uint16(value)), nil
+	case locationProgramCounter:
+		return cpu.SetProgramCounter(chipCpu, uint16(value)), nil
+	case locationDelayTimer:
+		return cpu.SetDelayTimer(chipCpu, uint8(value)), nil
+	case locationSoundTimer:
+		return cpu.SetSoundTimer(chipCpu, uint8(value)), nil
+	case locationMemory:
+		address := place.memoryAddress(chipCpu)
+		if address >= cpu.GetMemorySize(chipCpu) {
+			return chipCpu, fmt.Errorf("0x%X is past the end of memory", address)
+		}
+		return cpu.SetMemory(chipCpu, address, byte(value)), nil
+	}
+
+	return chipCpu, fmt.Errorf("%s can't be set", place)
+}
+
+func (place location) String() string {
+	switch place.kind {
+	case locationRegister:
+		return fmt.Sprintf("v%X", place.register)
+	case locationIndex:
+		return "i"
+	case locationProgramCounter:
+		return "pc"
+	case locationDelayTimer:
+		return "dt"
+	case locationSoundTimer:
+		return "st"
+	case locationStackPointer:
+		return "sp"
+	case locationMemory:
+		if place.indexed {
+			return "[i]"
+		}
+		return fmt.Sprintf("[0x%03X]", place.address)
+	}
+
+	return "?"
+}
+
+//Function to read a number, decimal, 0x hex, or 0b binary
+func parseNumber(text string) (int, error) {
+	lower := strings.ToLower(text)
+
+	var value uint64
+	var err error
+	if strings.HasPrefix(lower, "0x") {
+		value, err = strconv.ParseUint(lower[2:], 16, 32)
+	} else if strings.HasPrefix(lower, "0b") {
+		value, err = strconv.ParseUint(lower[2:], 2, 32)
+	} else {
+		value, err = strconv.ParseUint(lower, 10, 32)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("bad number %s", text)
+	}
+
+	return int(value), nil
+}
+
+//A value in a condition, a number or a location
+type operand struct {
+	number   int
+	place    location
+	isNumber bool
+}
+
+//Function to read a number or a location
+func parseOperand(text string) (operand, error) {
+	number, err := parseNumber(text)
+	if err == nil {
+		return operand{number: number, isNumber: true}, nil
+	}
+
+	place, err := parseLocation(text)
+	if err != nil {
+		return operand{}, fmt.Errorf("expected a number or location, found %s", text)
+	}
+	return operand{place: place}, nil
+}
+
+func (value operand) get(chipCpu cpu.Cpu) int {
+	if value.isNumber {
+		return value.number
+	}
+	return value.place.get(chipCpu)
+}
+
+//A single comparison, e.g. v3 == 7
+type comparison struct {
+	left     operand
+	operator string
+	right    operand
+}
+
+//A condition is comparisons joined by && and ||
+//Any of the groups can be true, and everything in a group must be true
+type condition struct {
+	groups [][]comparison
+}
+
+//Operators we can compare with, longest first
+var comparisonOperators = []string{"==", "!=", "<=", ">=", "<", ">"}
+
+//Function to read a condition, e.g. v3 == 7 && [0x300] != 0 || i > 0x400
+func parseCondition(text string) (*condition, error) {
+
+	parsed := &condition{}
+	for _, groupText := range strings.Split(text, "||") {
+		group := make([]comparison, 0)
+		for _, comparisonText := range strings.Split(groupText, "&&") {
+			next, err := parseComparison(strings.TrimSpace(comparisonText))
+			if err != nil {
+				return nil, err
+			}
+			group = append(group, next)
+		}
+		parsed.groups = append(parsed.groups, group)
+	}
+
+	return parsed, nil
+}
+
+//Function to read a single comparison
+func parseComparison(text string) (comparison, error) {
+	for _, operator := range comparisonOperators {
+		index := strings.Index(text, operator)
+		if index < 0 {
+			continue
+		}
+
+		left, err := parseOperand(strings.TrimSpace(text[:index]))
+		if err != nil {
+			return comparison{}, err
+		}
+		right, err := parseOperand(strings.TrimSpace(text[index+len(operator):]))
+		if err != nil {
+			return comparison{}, err
+		}
+		return comparison{left: left, operator: operator, right: right}, nil
+	}
+
+	return comparison{}, fmt.Errorf("expected a comparison like v3 == 7, found %q", text)
+}
+
+//Function to check a condition against the cpu
+func (check *condition) isTrue(chipCpu cpu.Cpu) bool {
+	for _, group := range check.groups {
+		allTrue := true
+		for _, next := range group {
+			if !next.isTrue(chipCpu) {
+				allTrue = false
+				break
+			}
+		}
+		if allTrue {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (check comparison) isTrue(chipCpu cpu.Cpu) bool {
+	left := check.left.get(chipCpu)
+	right := check.right.get(chipCpu)
+
+	switch check.operator {
+	case "==":
+		return left == right
+	case "!=":
+		return left != right
+	case "<=":
+		return left <= right
+	case ">=":
+		return left >= right
+	case "<":
+		return left < right
+	case ">":
+		return left > right
+	}
+
+	return false
+}
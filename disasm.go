@@ -0,0 +1,42 @@
+package main
+
+//This is helper class for the disasm command, see the disasm package
+
+import (
+	cpu "github.com/torch2424/chipGo/cpu"
+	disasm "github.com/torch2424/chipGo/disasm"
+	"fmt"
+	"io/ioutil"
+	"os"
+)
+
+//Function to disassemble a game, and print the listing
+func runDisasm() {
+
+	//Read our game
+	game, err := ioutil.ReadFile(*disasmPath)
+	if err != nil {
+		fmt.Println("File Not Found: ", *disasmPath)
+		os.Exit(1)
+	}
+
+	//Find our options
+	mode, err := cpu.ParseMode(*cpuMode)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	syntax, err := disasm.ParseSyntax(*disasmSyntax)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	options := disasm.Options{Syntax: syntax, Mode: mode, Addresses: !*disasmNoAddrs}
+
+	//Print the listing
+	err = disasm.Write(os.Stdout, disasm.Disassemble(game, options), options)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
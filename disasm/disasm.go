@@ -0,0 +1,348 @@
+package disasm
+
+/*
+   Disassembler for Chip-8, SCHIP, and XO-CHIP games
+
+   Decodes opCodes into either Octo (https://github.com/JohnEarnest/Octo) or
+   Cowgod (http://devernay.free.fr/hacks/chip8/C8TECH10.HTM) syntax
+*/
+
+//Chip-8 games mix their code and sprites together, so we can't just decode every two bytes
+//Instead, we follow the code from where the game starts, through jumps, calls, and skips
+//Anything we never reach is treated as data
+
+//Imports
+import (
+	cpu "github.com/torch2424/chipGo/cpu"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+//Where games are loaded into memory
+const romStart = 0x200
+
+//Options for disassembling a game
+type Options struct {
+
+	//Assembly language to write
+	Syntax Syntax
+
+	//Instruction set the game was written for, extension opCodes are treated as data outside of their mode
+	Mode cpu.Mode
+
+	//Add the address and opCode of each line as a comment
+	Addresses bool
+}
+
+//Line is a single instruction, or a run of data bytes, in our listing
+type Line struct {
+	Address uint16
+	Bytes   []byte
+
+	//Label for this address, if anything jumps to, calls, or loads it
+	Label string
+
+	//Instruction or data text
+	Text string
+	Code bool
+}
+
+//Function to disassemble a game into lines of instructions and data
+func Disassemble(rom []byte, options Options) []Line {
+
+	//Find which addresses are the start of an instruction
+	code := traceCode(rom, options.Mode)
+
+	//Find everything that is jumped to, called, or loaded into the index register
+	targets := findTargets(rom, code)
+
+	//Find the address of each line we will write, so we only label addresses that start a line
+	//Inside an instruction that can't happen, but data lines are split on every target
+	labels := make(map[uint16]string)
+	for offset := 0; offset < len(rom); {
+		address := uint16(romStart + offset)
+		label, isTarget := targets[address]
+		if isTarget {
+			labels[address] = label
+		}
+
+		if code[address] {
+			_, size, _ := decode(readOpcode(rom, offset), readOpcode(rom, offset+2), options.Syntax, formatAddress)
+			offset += size
+		} else {
+			offset += dataLength(rom, offset, code, targets)
+		}
+	}
+
+	labelFor := func(address uint16) string {
+		label, hasLabel := labels[address]
+		if hasLabel {
+			return label
+		}
+		return formatAddress(address)
+	}
+
+	//Finally, write out each line
+	lines := make([]Line, 0)
+	for offset := 0; offset < len(rom); {
+		address := uint16(romStart + offset)
+		line := Line{Address: address, Label: labels[address]}
+
+		if code[address] {
+			text, size, _ := decode(readOpcode(rom, offset), readOpcode(rom, offset+2), options.Syntax, labelFor)
+			line.Text = text
+			line.Code = true
+			line.Bytes = rom[offset:minimum(offset+size, len(rom))]
+			offset += size
+		} else {
+			length := dataLength(rom, offset, code, targets)
+			line.Bytes = rom[offset : offset+length]
+			line.Text = formatData(line.Bytes, options.Syntax)
+			offset += length
+		}
+
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+//Function to write a listing of lines
+func Write(writer io.Writer, lines []Line, options Options) error {
+
+	for _, line := range lines {
+
+		//Labels are on their own line
+		if line.Label != "" {
+			var err error
+			if options.Syntax == SyntaxCowgod {
+				_, err = fmt.Fprintf(writer, "%s:\n", line.Label)
+			} else {
+				_, err = fmt.Fprintf(writer, ": %s\n", line.Label)
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		text := "\t" + line.Text
+		if options.Addresses {
+			//Octo comments start with a #, Cowgod with a ;
+			comment := "#"
+			if options.Syntax == SyntaxCowgod {
+				comment = ";"
+			}
+
+			hexBytes := make([]string, len(line.Bytes))
+			for i, value := range line.Bytes {
+				hexBytes[i] = fmt.Sprintf("%02X", value)
+			}
+			text = fmt.Sprintf("%-32s %s 0x%03X  %s", text, comment, line.Address, strings.Join(hexBytes, " "))
+		}
+
+		_, err := fmt.Fprintln(writer, text)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//Function to disassemble a game straight into a listing
+func Format(rom []byte, options Options) string {
+	var listing bytes.Buffer
+	Write(&listing, Disassemble(rom, options), options)
+	return listing.String()
+}
+
+//Function to read the opCode at an offset in the game, anything past the end reads as zero
+func readOpcode(rom []byte, offset int) uint16 {
+	var opCode uint16
+	if offset < len(rom) {
+		opCode = uint16(rom[offset]) << 8
+	}
+	if offset+1 < len(rom) {
+		opCode = opCode | uint16(rom[offset+1])
+	}
+
+	return opCode
+}
+
+//Function to follow the code of a game from where it starts, returning every address an instruction starts at
+func traceCode(rom []byte, mode cpu.Mode) map[uint16]bool {
+
+	code := make(map[uint16]bool)
+
+	//Addresses we still need to follow
+	pending := []uint16{romStart}
+
+	for len(pending) > 0 {
+		address := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+
+		//Follow this path until it jumps away, returns, or runs into something that is not code
+		for {
+			offset := int(address) - romStart
+			if offset < 0 || offset+1 >= len(rom) || code[address] {
+				break
+			}
+
+			opCode := readOpcode(rom, offset)
+			_, size, known := decode(opCode, readOpcode(rom, offset+2), SyntaxOcto, formatAddress)
+			if !known || !validForMode(opCode, mode) {
+				break
+			}
+			code[address] = true
+
+			//The next instruction, after this one
+			nextAddress := address + uint16(size)
+			target := opCode & 0x0FFF
+
+			if opCode == 0x00EE || opCode == 0x00FD {
+				//Return and exit, nothing runs after them
+				break
+			} else if opCode&0xF000 == 0x1000 {
+				//Jump, only follow where it goes
+				address = target
+				continue
+			} else if opCode&0xF000 == 0x2000 {
+				//Call, follow both the subroutine, and where it returns to
+				pending = append(pending, target)
+			} else if opCode&0xF000 == 0xB000 {
+				//Jump with an offset, we can only guess the first entry of a jump table
+				pending = append(pending, target)
+				break
+			} else if isSkip(opCode) {
+				//Skip, follow both the next instruction and the one after it
+				skipAddress := nextAddress + 2
+				if readOpcode(rom, int(nextAddress)-romStart) == 0xF000 {
+					skipAddress = nextAddress + 4
+				}
+				pending = append(pending, skipAddress)
+			}
+
+			address = nextAddress
+		}
+	}
+
+	return code
+}
+
+//Function to check if an opCode is a skip instruction
+func isSkip(opCode uint16) bool {
+	switch opCode & 0xF000 {
+	case 0x3000, 0x4000:
+		return true
+	case 0x5000, 0x9000:
+		return opCode&0x000F == 0
+	case 0xE000:
+		return opCode&0x00FF == 0x9E || opCode&0x00FF == 0xA1
+	}
+
+	return false
+}
+
+//Function to check if an opCode exists in the instruction set we are disassembling
+func validForMode(opCode uint16, mode cpu.Mode) bool {
+
+	//XO-CHIP only
+	xoChip := opCode&0xFFF0 == 0x00D0 ||
+		(opCode&0xF000 == 0x5000 && opCode&0x000F != 0) ||
+		opCode == 0xF000 || opCode&0xF0FF == 0xF001 || opCode == 0xF002 || opCode&0xF0FF == 0xF03A
+	if xoChip {
+		return mode == cpu.ModeXOChip
+	}
+
+	//SCHIP and XO-CHIP
+	schip := opCode&0xFFF0 == 0x00C0 || (opCode >= 0x00FB && opCode <= 0x00FF) ||
+		opCode&0xF0FF == 0xF030 || opCode&0xF0FF == 0xF075 || opCode&0xF0FF == 0xF085
+	if schip {
+		return mode != cpu.ModeChip8
+	}
+
+	return true
+}
+
+//Function to name every address in the game that code jumps to, calls, or loads into the index register
+func findTargets(rom []byte, code map[uint16]bool) map[uint16]string {
+
+	labels := make(map[uint16]string)
+	addLabel := func(address uint16, name string) {
+		_, hasLabel := labels[address]
+		offset := int(address) - romStart
+		if offset >= 0 && offset < len(rom) && !hasLabel {
+			labels[address] = fmt.Sprintf("%s_%03X", name, address)
+		}
+	}
+
+	//The game always starts at main
+	labels[romStart] = "main"
+
+	//Subroutines first, so they keep their names if they are also jumped to
+	for offset := 0; offset < len(rom); offset++ {
+		address := uint16(romStart + offset)
+		if code[address] && readOpcode(rom, offset)&0xF000 == 0x2000 {
+			addLabel(readOpcode(rom, offset)&0x0FFF, "sub")
+		}
+	}
+
+	for offset := 0; offset < len(rom); offset++ {
+		address := uint16(romStart + offset)
+		if !code[address] {
+			continue
+		}
+
+		opCode := readOpcode(rom, offset)
+		switch opCode & 0xF000 {
+		case 0x1000, 0xB000:
+			addLabel(opCode&0x0FFF, "label")
+		case 0xA000:
+			addLabel(opCode&0x0FFF, "data")
+		case 0xF000:
+			if opCode == 0xF000 {
+				addLabel(readOpcode(rom, offset+2), "data")
+			}
+		}
+	}
+
+	return labels
+}
+
+//Function to find how many bytes of data start at an offset
+//Data runs until the next instruction or label, up to 8 bytes per line
+func dataLength(rom []byte, offset int, code map[uint16]bool, targets map[uint16]string) int {
+	length := 1
+	for offset+length < len(rom) && length < 8 {
+		address := uint16(romStart + offset + length)
+		_, isTarget := targets[address]
+		if code[address] || isTarget {
+			break
+		}
+		length++
+	}
+
+	return length
+}
+
+//Function to write data bytes
+func formatData(data []byte, syntax Syntax) string {
+	values := make([]string, len(data))
+	for i, value := range data {
+		values[i] = formatByte(uint16(value))
+	}
+
+	if syntax == SyntaxCowgod {
+		return "DB " + strings.Join(values, ", ")
+	}
+	return strings.Join(values, " ")
+}
+
+func minimum(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
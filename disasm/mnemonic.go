@@ -0,0 +1,203 @@
+package disasm
+
+/*
+   Disassembler for Chip-8, SCHIP, and XO-CHIP games
+
+   Decodes opCodes into either Octo (https://github.com/JohnEarnest/Octo) or
+   Cowgod (http://devernay.free.fr/hacks/chip8/C8TECH10.HTM) syntax
+*/
+
+//This is helper class for turning a single opCode into text
+
+//Imports
+import (
+	"fmt"
+)
+
+//Syntax is the assembly language we write our listings in
+type Syntax int
+
+const (
+	//Octo, the high level assembler most modern Chip-8 games are written in
+	SyntaxOcto Syntax = iota
+	//Cowgod's Chip-8 technical reference mnemonics, e.g. LD V0, 0x12
+	SyntaxCowgod
+)
+
+//Function to find a syntax from it's command line name
+func ParseSyntax(name string) (Syntax, error) {
+	switch name {
+	case "octo":
+		return SyntaxOcto, nil
+	case "cowgod":
+		return SyntaxCowgod, nil
+	}
+
+	return SyntaxOcto, fmt.Errorf("Unknown disassembler syntax: %s", name)
+}
+
+//Function to format an address, used when an address has no label
+func formatAddress(address uint16) string {
+	return fmt.Sprintf("0x%03X", address)
+}
+
+//Function to format a byte
+func formatByte(value uint16) string {
+	return fmt.Sprintf("0x%02X", value)
+}
+
+//Function to decode an opCode into text, addresses are written as numbers
+//next is the opCode after this one, used by the four byte XO-CHIP long index load (F000 NNNN)
+//Returns the text, the size of the instruction in bytes, and false if it is not an instruction
+func Mnemonic(opCode uint16, next uint16, syntax Syntax) (string, int, bool) {
+	return decode(opCode, next, syntax, formatAddress)
+}
+
+//Function to decode an opCode, using a function to name the addresses it jumps to or loads
+func decode(opCode uint16, next uint16, syntax Syntax, address func(uint16) string) (string, int, bool) {
+
+	//Our opCode nibbles and bytes
+	regX := (opCode & 0x0F00) >> 8
+	regY := (opCode & 0x00F0) >> 4
+	lastNibble := opCode & 0x000F
+	lastByte := opCode & 0x00FF
+	lastThree := opCode & 0x0FFF
+
+	//Each case picks the Octo text, then the Cowgod text
+	text := func(octo string, cowgod string, arguments ...interface{}) (string, int, bool) {
+		if syntax == SyntaxCowgod {
+			return fmt.Sprintf(cowgod, arguments...), 2, true
+		}
+		return fmt.Sprintf(octo, arguments...), 2, true
+	}
+
+	switch opCode & 0xF000 {
+	case 0x0000:
+		switch {
+		case opCode == 0x00E0:
+			return text("clear", "CLS")
+		case opCode == 0x00EE:
+			return text("return", "RET")
+		case opCode == 0x00FB:
+			return text("scroll-right", "SCR")
+		case opCode == 0x00FC:
+			return text("scroll-left", "SCL")
+		case opCode == 0x00FD:
+			return text("exit", "EXIT")
+		case opCode == 0x00FE:
+			return text("lores", "LOW")
+		case opCode == 0x00FF:
+			return text("hires", "HIGH")
+		case opCode&0xFFF0 == 0x00C0:
+			return text("scroll-down %d", "SCD %d", lastNibble)
+		case opCode&0xFFF0 == 0x00D0:
+			return text("scroll-up %d", "SCU %d", lastNibble)
+		}
+	case 0x1000:
+		return text("jump %s", "JP %s", address(lastThree))
+	case 0x2000:
+		return text(":call %s", "CALL %s", address(lastThree))
+	case 0x3000:
+		//Octo writes skips as the condition for running the next instruction
+		return text("if v%X != %s then", "SE V%X, %s", regX, formatByte(lastByte))
+	case 0x4000:
+		return text("if v%X == %s then", "SNE V%X, %s", regX, formatByte(lastByte))
+	case 0x5000:
+		switch lastNibble {
+		case 0x0:
+			return text("if v%X != v%X then", "SE V%X, V%X", regX, regY)
+		case 0x2:
+			return text("save v%X - v%X", "SAVE V%X, V%X", regX, regY)
+		case 0x3:
+			return text("load v%X - v%X", "LOAD V%X, V%X", regX, regY)
+		}
+	case 0x6000:
+		return text("v%X := %s", "LD V%X, %s", regX, formatByte(lastByte))
+	case 0x7000:
+		return text("v%X += %s", "ADD V%X, %s", regX, formatByte(lastByte))
+	case 0x8000:
+		switch lastNibble {
+		case 0x0:
+			return text("v%X := v%X", "LD V%X, V%X", regX, regY)
+		case 0x1:
+			return text("v%X |= v%X", "OR V%X, V%X", regX, regY)
+		case 0x2:
+			return text("v%X &= v%X", "AND V%X, V%X", regX, regY)
+		case 0x3:
+			return text("v%X ^= v%X", "XOR V%X, V%X", regX, regY)
+		case 0x4:
+			return text("v%X += v%X", "ADD V%X, V%X", regX, regY)
+		case 0x5:
+			return text("v%X -= v%X", "SUB V%X, V%X", regX, regY)
+		case 0x6:
+			return text("v%X >>= v%X", "SHR V%X, V%X", regX, regY)
+		case 0x7:
+			return text("v%X =- v%X", "SUBN V%X, V%X", regX, regY)
+		case 0xE:
+			return text("v%X <<= v%X", "SHL V%X, V%X", regX, regY)
+		}
+	case 0x9000:
+		if lastNibble == 0 {
+			return text("if v%X == v%X then", "SNE V%X, V%X", regX, regY)
+		}
+	case 0xA000:
+		return text("i := %s", "LD I, %s", address(lastThree))
+	case 0xB000:
+		return text("jump0 %s", "JP V0, %s", address(lastThree))
+	case 0xC000:
+		return text("v%X := random %s", "RND V%X, %s", regX, formatByte(lastByte))
+	case 0xD000:
+		return text("sprite v%X v%X %d", "DRW V%X, V%X, %d", regX, regY, lastNibble)
+	case 0xE000:
+		switch lastByte {
+		case 0x9E:
+			return text("if v%X -key then", "SKP V%X", regX)
+		case 0xA1:
+			return text("if v%X key then", "SKNP V%X", regX)
+		}
+	case 0xF000:
+		//XO-CHIP long index load is four bytes
+		if opCode == 0xF000 {
+			instruction, _, _ := text("i := long %s", "LD I, LONG %s", address(next))
+			return instruction, 4, true
+		}
+
+		switch lastByte {
+		case 0x01:
+			return text("plane %d", "PLANE %d", regX)
+		case 0x02:
+			if regX == 0 {
+				return text("audio", "AUDIO")
+			}
+		case 0x07:
+			return text("v%X := delay", "LD V%X, DT", regX)
+		case 0x0A:
+			return text("v%X := key", "LD V%X, K", regX)
+		case 0x15:
+			return text("delay := v%X", "LD DT, V%X", regX)
+		case 0x18:
+			return text("buzzer := v%X", "LD ST, V%X", regX)
+		case 0x1E:
+			return text("i += v%X", "ADD I, V%X", regX)
+		case 0x29:
+			return text("i := hex v%X", "LD F, V%X", regX)
+		case 0x30:
+			return text("i := bighex v%X", "LD HF, V%X", regX)
+		case 0x33:
+			return text("bcd v%X", "LD B, V%X", regX)
+		case 0x3A:
+			return text("pitch := v%X", "LD PITCH, V%X", regX)
+		case 0x55:
+			return text("save v%X", "LD [I], V%X", regX)
+		case 0x65:
+			return text("load v%X", "LD V%X, [I]", regX)
+		case 0x75:
+			return text("saveflags v%X", "LD R, V%X", regX)
+		case 0x85:
+			return text("loadflags v%X", "LD V%X, R", regX)
+		}
+	}
+
+	//Not an instruction we know, this is probably data
+	return "", 2, false
+}
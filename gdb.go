@@ -0,0 +1,40 @@
+package main
+
+//This is helper class for debugging with gdb, see the gdbstub package
+//With --gdb the game waits for gdb to connect, e.g. target remote localhost:1234
+
+import (
+	cpu "github.com/torch2424/chipGo/cpu"
+	gdbstub "github.com/torch2424/chipGo/gdbstub"
+	"fmt"
+	"strings"
+)
+
+//Function to start our gdb stub, and wait for gdb to connect
+//Returns nil if we are not debugging with gdb
+func newGdbServer(emulator *cpu.Emulator) (*gdbstub.Server, error) {
+	if *gdbAddress == "" {
+		return nil, nil
+	}
+
+	//Just a port listens on this machine only
+	address := *gdbAddress
+	if !strings.Contains(address, ":") {
+		address = "localhost:" + address
+	}
+
+	server, err := gdbstub.Listen(emulator, address)
+	if err != nil {
+		return nil, fmt.Errorf("Failed starting gdb stub: %s", err)
+	}
+
+	print("Waiting for gdb on ", server.Addr().String(), "...\n")
+	err = server.Accept()
+	if err != nil {
+		server.Close()
+		return nil, fmt.Errorf("Failed waiting for gdb: %s", err)
+	}
+	print("gdb connected!\n\n")
+
+	return server, nil
+}
@@ -0,0 +1,291 @@
+package gdbstub
+
+/*
+   GDB remote serial protocol stub for Chip-8, SCHIP, and XO-CHIP games
+
+   Lets gdb, or anything else that speaks the protocol, debug a running game over TCP
+   https://sourceware.org/gdb/onlinedocs/gdb/Remote-Protocol.html
+*/
+
+//This is helper class for the packets gdb sends us
+//Anything we don't support gets an empty reply, which tells gdb to try something else
+
+//Imports
+import (
+	cpu "github.com/torch2424/chipGo/cpu"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+//Reply for a packet that went wrong
+const errorReply = "E01"
+
+//Features we tell gdb we support
+const supported = "PacketSize=1000;qXfer:features:read+;QStartNoAckMode+;swbreak+"
+
+//Function to handle a packet from gdb, and send the reply
+func (server *Server) handlePacket(packet string) {
+	if packet == interruptPacket {
+		server.Interrupt()
+		return
+	}
+	if packet == "" {
+		server.send("")
+		return
+	}
+
+	switch packet[0] {
+	case '?':
+		//Why we stopped
+		server.send(server.lastStop)
+		break
+	case 'c':
+		//Continue, from an address if one is given
+		server.resume(packet[1:], false)
+		break
+	case 's':
+		//Step one instruction, from an address if one is given
+		server.resume(packet[1:], true)
+		break
+	case 'g':
+		server.send(server.readRegisters())
+		break
+	case 'G':
+		server.send(server.writeRegisters(packet[1:]))
+		break
+	case 'p':
+		server.send(server.readRegister(packet[1:]))
+		break
+	case 'P':
+		server.send(server.writeRegister(packet[1:]))
+		break
+	case 'm':
+		server.send(server.readMemory(packet[1:]))
+		break
+	case 'M':
+		server.send(server.writeMemory(packet[1:]))
+		break
+	case 'Z', 'z':
+		server.send(server.setBreakpoint(packet[1:], packet[0] == 'Z'))
+		break
+	case 'H', 'T':
+		//We only have one thread
+		server.send("OK")
+		break
+	case 'D':
+		//Detach, and let the game keep running
+		server.send("OK")
+		server.disconnect()
+		break
+	case 'k':
+		//Kill, stop the game
+		server.emulator.Cpu.Exit = true
+		server.disconnect()
+		break
+	case 'q', 'Q':
+		server.send(server.query(packet))
+		break
+	default:
+		server.send("")
+		break
+	}
+}
+
+//Function to answer a query, e.g. qSupported
+func (server *Server) query(packet string) string {
+	switch {
+	case strings.HasPrefix(packet, "qSupported"):
+		return supported
+	case packet == "QStartNoAckMode":
+		//Our packet reader has already stopped acknowledging
+		return "OK"
+	case packet == "qAttached":
+		return "1"
+	case packet == "qC":
+		return "QC1"
+	case packet == "qfThreadInfo":
+		return "m1"
+	case packet == "qsThreadInfo":
+		return "l"
+	case strings.HasPrefix(packet, "qXfer:features:read:target.xml:"):
+		return readPart(targetXML(), strings.TrimPrefix(packet, "qXfer:features:read:target.xml:"))
+	}
+
+	return ""
+}
+
+//Function to read part of a file for qXfer, offset,length
+//Replies m if there is more to read, or l for the last part
+func readPart(file string, arguments string) string {
+	offset, length, err := parseRange(arguments)
+	if err != nil {
+		return errorReply
+	}
+
+	if offset >= len(file) {
+		return "l"
+	}
+	if offset+length >= len(file) {
+		return "l" + file[offset:]
+	}
+	return "m" + file[offset:offset+length]
+}
+
+//Function to continue or step, from an address if one is given
+func (server *Server) resume(address string, step bool) {
+	if address != "" {
+		programCounter, err := strconv.ParseUint(address, 16, 16)
+		if err != nil {
+			server.send(errorReply)
+			return
+		}
+		server.emulator.Cpu = cpu.SetProgramCounter(server.emulator.Cpu, uint16(programCounter))
+	}
+
+	server.stopped = false
+	server.stepping = step
+}
+
+//Function to read all of our registers
+func (server *Server) readRegisters() string {
+	values := ""
+	for number := range registers {
+		values = values + encodeRegister(server.emulator.Cpu, number)
+	}
+	return values
+}
+
+//Function to write all of our registers
+func (server *Server) writeRegisters(values string) string {
+	chipCpu := server.emulator.Cpu
+	for number, next := range registers {
+		if len(values) < next.size*2 {
+			return errorReply
+		}
+
+		value, err := decodeRegister(values[:next.size*2], number)
+		if err == nil {
+			chipCpu, err = writeRegister(chipCpu, number, value)
+		}
+		if err != nil {
+			return errorReply
+		}
+		values = values[next.size*2:]
+	}
+
+	server.emulator.Cpu = chipCpu
+	return "OK"
+}
+
+//Function to read one register, e.g. p12 for pc
+func (server *Server) readRegister(arguments string) string {
+	number, err := strconv.ParseUint(arguments, 16, 8)
+	if err != nil || int(number) >= len(registers) {
+		return errorReply
+	}
+	return encodeRegister(server.emulator.Cpu, int(number))
+}
+
+//Function to write one register, e.g. P3=07
+func (server *Server) writeRegister(arguments string) string {
+	parts := strings.SplitN(arguments, "=", 2)
+	if len(parts) != 2 {
+		return errorReply
+	}
+	number, err := strconv.ParseUint(parts[0], 16, 8)
+	if err != nil || int(number) >= len(registers) {
+		return errorReply
+	}
+
+	value, err := decodeRegister(parts[1], int(number))
+	if err != nil {
+		return errorReply
+	}
+	chipCpu, err := writeRegister(server.emulator.Cpu, int(number), value)
+	if err != nil {
+		return errorReply
+	}
+
+	server.emulator.Cpu = chipCpu
+	return "OK"
+}
+
+//Function to read memory, e.g. m200,10
+//Reads stop at the end of memory, and fail if they start past it
+func (server *Server) readMemory(arguments string) string {
+	address, length, err := parseRange(arguments)
+	memorySize := cpu.GetMemorySize(server.emulator.Cpu)
+	if err != nil || address >= memorySize {
+		return errorReply
+	}
+	if address+length > memorySize {
+		length = memorySize - address
+	}
+
+	data := make([]byte, length)
+	for i := range data {
+		data[i] = cpu.GetMemory(server.emulator.Cpu, address+i)
+	}
+	return hex.EncodeToString(data)
+}
+
+//Function to write memory, e.g. M300,2:abcd
+func (server *Server) writeMemory(arguments string) string {
+	parts := strings.SplitN(arguments, ":", 2)
+	if len(parts) != 2 {
+		return errorReply
+	}
+	address, length, err := parseRange(parts[0])
+	if err != nil {
+		return errorReply
+	}
+	data, err := hex.DecodeString(parts[1])
+	if err != nil || len(data) != length || address+length > cpu.GetMemorySize(server.emulator.Cpu) {
+		return errorReply
+	}
+
+	for i, value := range data {
+		server.emulator.Cpu = cpu.SetMemory(server.emulator.Cpu, address+i, value)
+	}
+	return "OK"
+}
+
+//Function to add or remove a breakpoint, e.g. Z0,2a4,2
+//Software and hardware breakpoints are the same to us, watchpoints are not supported
+func (server *Server) setBreakpoint(arguments string, add bool) string {
+	parts := strings.SplitN(arguments, ",", 3)
+	if len(parts) != 3 || (parts[0] != "0" && parts[0] != "1") {
+		return ""
+	}
+	address, err := strconv.ParseUint(parts[1], 16, 16)
+	if err != nil {
+		return errorReply
+	}
+
+	if add {
+		server.breakpoints[int(address)] = true
+	} else {
+		delete(server.breakpoints, int(address))
+	}
+	return "OK"
+}
+
+//Function to read an address and length, e.g. 200,10
+func parseRange(arguments string) (int, int, error) {
+	parts := strings.SplitN(arguments, ",", 2)
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("expected address,length")
+	}
+
+	address, err := strconv.ParseUint(parts[0], 16, 32)
+	if err != nil {
+		return 0, 0, err
+	}
+	length, err := strconv.ParseUint(parts[1], 16, 32)
+	if err != nil {
+		return 0, 0, err
+	}
+	return int(address), int(length), nil
+}
@@ -0,0 +1,199 @@
+package gdbstub
+
+/*
+   GDB remote serial protocol stub for Chip-8, SCHIP, and XO-CHIP games
+
+   Lets gdb, or anything else that speaks the protocol, debug a running game over TCP
+   https://sourceware.org/gdb/onlinedocs/gdb/Remote-Protocol.html
+*/
+
+//The frontend calls Step instead of running instructions itself
+//While gdb has the game stopped, Step waits for its commands, and runs again when gdb continues or steps
+//Only one gdb can connect, once it detaches or disconnects the game keeps running on its own
+
+//Imports
+import (
+	cpu "github.com/torch2424/chipGo/cpu"
+	"fmt"
+	"net"
+)
+
+//Signals we stop with, as gdb numbers them
+const (
+	signalInterrupt = 0x02
+	signalIllegal   = 0x04
+	signalTrap      = 0x05
+	signalSegfault  = 0x0B
+)
+
+//Server is a gdb stub, wrapping an emulator
+type Server struct {
+	emulator *cpu.Emulator
+
+	listener   net.Listener
+	connection net.Conn
+	packets    chan string
+
+	//Addresses we stop at before running
+	breakpoints map[int]bool
+
+	//If we are stopped, and if we stop again after one instruction
+	stopped  bool
+	stepping bool
+	lastStop string
+
+	//The last error from the cpu, returned once gdb lets go of the game
+	err error
+}
+
+//Function to listen for gdb on an address, e.g. localhost:1234
+func Listen(emulator *cpu.Emulator, address string) (*Server, error) {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Server{emulator: emulator, listener: listener, breakpoints: make(map[int]bool)}, nil
+}
+
+//Function to find the address we are listening on
+func (server *Server) Addr() net.Addr {
+	return server.listener.Addr()
+}
+
+//Function to wait for gdb to connect
+//The game starts stopped, so gdb can set breakpoints before anything runs
+func (server *Server) Accept() error {
+	connection, err := server.listener.Accept()
+	if err != nil {
+		return err
+	}
+
+	server.connection = connection
+	server.packets = make(chan string)
+	server.stopped = true
+	server.lastStop = stopReply(signalTrap, false)
+	go readPackets(connection, server.packets)
+
+	return nil
+}
+
+//Function to stop listening, and disconnect gdb
+func (server *Server) Close() error {
+	if server.connection != nil {
+		server.connection.Close()
+	}
+	return server.listener.Close()
+}
+
+//Function to stop the game, e.g. from a hotkey, and tell gdb
+func (server *Server) Interrupt() {
+	if server.connection != nil && !server.stopped {
+		server.stop(signalInterrupt, false)
+	}
+}
+
+//Function to run a single instruction, unless gdb has the game stopped or hits a breakpoint
+//Returns the error from the cpu, once gdb has detached
+func (server *Server) Step() error {
+
+	//Without gdb, just run the game
+	if server.connection == nil {
+		if server.err != nil {
+			return server.err
+		}
+		return server.emulator.Step()
+	}
+
+	//Check if gdb sent us anything while running, e.g. ctrl+c
+	if !server.stopped {
+		select {
+		case packet, isOpen := <-server.packets:
+			if !isOpen {
+				server.disconnect()
+				return server.Step()
+			}
+			server.handlePacket(packet)
+			break
+		default:
+			break
+		}
+	}
+
+	//Stop at breakpoints, the instruction we continue from is run first
+	if !server.stopped && server.breakpoints[int(cpu.GetProgramCounter(server.emulator.Cpu))] {
+		server.stop(signalTrap, true)
+	}
+
+	//Wait for gdb to continue or step
+	for server.stopped {
+		packet, isOpen := <-server.packets
+		if !isOpen {
+			server.disconnect()
+			return server.Step()
+		}
+		server.handlePacket(packet)
+	}
+	if server.connection == nil || server.emulator.Cpu.Exit {
+		return server.err
+	}
+
+	//Run the instruction, stopping if the cpu hit an error
+	err := server.emulator.Step()
+	if err != nil {
+		server.err = err
+		server.stop(errorSignal(err), false)
+		return nil
+	}
+	server.err = nil
+
+	if server.stepping {
+		server.stepping = false
+		server.stop(signalTrap, false)
+	}
+
+	return nil
+}
+
+//Function to stop the game, and tell gdb why
+func (server *Server) stop(signal int, breakpoint bool) {
+	server.stopped = true
+	server.lastStop = stopReply(signal, breakpoint)
+	server.send(server.lastStop)
+}
+
+//Function to let go of the game, when gdb disconnects
+func (server *Server) disconnect() {
+	if server.connection != nil {
+		server.connection.Close()
+		print("gdb disconnected, the game keeps running...\n")
+	}
+	server.connection = nil
+	server.stopped = false
+	server.stepping = false
+}
+
+//Function to send a packet to gdb
+//If gdb has gone away, the next read will find out and disconnect
+func (server *Server) send(data string) {
+	if server.connection != nil {
+		writePacket(server.connection, data)
+	}
+}
+
+//Function to find the reply for why we stopped, e.g. T05swbreak:;
+func stopReply(signal int, breakpoint bool) string {
+	if breakpoint {
+		return fmt.Sprintf("T%02xswbreak:;", signal)
+	}
+	return fmt.Sprintf("S%02x", signal)
+}
+
+//Function to find the signal to stop with for a cpu error
+func errorSignal(err error) int {
+	_, isMemory := err.(cpu.MemoryOutOfBoundsError)
+	if isMemory {
+		return signalSegfault
+	}
+	return signalIllegal
+}
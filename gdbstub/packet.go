@@ -0,0 +1,120 @@
+package gdbstub
+
+/*
+   GDB remote serial protocol stub for Chip-8, SCHIP, and XO-CHIP games
+
+   Lets gdb, or anything else that speaks the protocol, debug a running game over TCP
+   https://sourceware.org/gdb/onlinedocs/gdb/Remote-Protocol.html
+*/
+
+//This is helper class for reading and writing packets
+//Packets are $data#checksum, where the checksum is the sum of the data bytes as two hex digits
+//A lone 0x03 byte is gdb asking us to stop, e.g. ctrl+c
+
+//Imports
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+//Byte gdb sends to interrupt a running game
+const interruptByte = 0x03
+
+//Packet we pass on to be handled, the interrupt packet is sent for 0x03
+const interruptPacket = "\x03"
+
+//Function to read packets from gdb, and send them down a channel until the connection closes
+//Acknowledges each packet, until gdb asks us to stop acknowledging
+func readPackets(connection io.ReadWriter, packets chan<- string) {
+	defer close(packets)
+
+	reader := bufio.NewReader(connection)
+	acknowledge := true
+	for {
+		next, err := reader.ReadByte()
+		if err != nil {
+			return
+		}
+
+		switch next {
+		case interruptByte:
+			packets <- interruptPacket
+			continue
+		case '$':
+			break
+		default:
+			//Acknowledgements from gdb, and anything else between packets
+			continue
+		}
+
+		data, err := reader.ReadBytes('#')
+		if err != nil {
+			return
+		}
+		data = data[:len(data)-1]
+
+		checksum := make([]byte, 2)
+		_, err = io.ReadFull(reader, checksum)
+		if err != nil {
+			return
+		}
+
+		//Ask gdb to send it again if it was garbled
+		if fmt.Sprintf("%02x", sum(data)) != string(toLower(checksum)) {
+			if acknowledge {
+				connection.Write([]byte("-"))
+			}
+			continue
+		}
+		if acknowledge {
+			connection.Write([]byte("+"))
+		}
+
+		packet := string(unescape(data))
+		if packet == "QStartNoAckMode" {
+			acknowledge = false
+		}
+		packets <- packet
+	}
+}
+
+//Function to write a packet to gdb
+func writePacket(writer io.Writer, data string) error {
+	_, err := fmt.Fprintf(writer, "$%s#%02x", data, sum([]byte(data)))
+	return err
+}
+
+//Function to add up the bytes of a packet, for its checksum
+func sum(data []byte) uint8 {
+	var total uint8
+	for _, value := range data {
+		total = total + value
+	}
+	return total
+}
+
+//Function to undo escaping in binary data, } followed by the byte xor 0x20
+func unescape(data []byte) []byte {
+	unescaped := make([]byte, 0, len(data))
+	for i := 0; i < len(data); i++ {
+		if data[i] == '}' && i+1 < len(data) {
+			i++
+			unescaped = append(unescaped, data[i]^0x20)
+		} else {
+			unescaped = append(unescaped, data[i])
+		}
+	}
+	return unescaped
+}
+
+func toLower(data []byte) []byte {
+	lower := make([]byte, len(data))
+	for i, value := range data {
+		if value >= 'A' && value <= 'Z' {
+			value = value + 'a' - 'A'
+		}
+		lower[i] = value
+	}
+	return lower
+}
@@ -0,0 +1,118 @@
+package gdbstub
+
+/*
+   GDB remote serial protocol stub for Chip-8, SCHIP, and XO-CHIP games
+
+   Lets gdb, or anything else that speaks the protocol, debug a running game over TCP
+   https://sourceware.org/gdb/onlinedocs/gdb/Remote-Protocol.html
+*/
+
+//This is helper class for the registers gdb can see, and the target description that names them
+//Registers are numbered v0 to vf, then i, sp, and pc
+//gdb reads registers in the target's byte order, and with no architecture to go on it picks little endian
+//So i and pc are sent little endian, even though Chip-8 memory is big endian
+//sp is how many return addresses are on the stack, it can be read but not written
+
+//Imports
+import (
+	cpu "github.com/torch2424/chipGo/cpu"
+	"encoding/hex"
+	"fmt"
+)
+
+//A register gdb can see
+type register struct {
+	name string
+
+	//Size in bytes
+	size int
+
+	//gdb type, e.g. uint8, code_ptr
+	kind string
+}
+
+//Our registers, in the order of their numbers
+var registers = func() []register {
+	list := make([]register, 0, 19)
+	for i := 0; i < 16; i++ {
+		list = append(list, register{name: fmt.Sprintf("v%x", i), size: 1, kind: "uint8"})
+	}
+	list = append(list, register{name: "i", size: 2, kind: "data_ptr"})
+	list = append(list, register{name: "sp", size: 1, kind: "uint8"})
+	list = append(list, register{name: "pc", size: 2, kind: "code_ptr"})
+	return list
+}()
+
+//Numbers of the registers after v0 to vf
+const (
+	registerIndex          = 16
+	registerStackPointer   = 17
+	registerProgramCounter = 18
+)
+
+//Function to write our target description, which tells gdb about our registers
+func targetXML() string {
+	xml := `<?xml version="1.0"?>
+<!DOCTYPE target SYSTEM "gdb-target.dtd">
+<target version="1.0">
+  <feature name="org.chipgo.chip8">
+`
+	for number, next := range registers {
+		xml = xml + fmt.Sprintf("    <reg name=\"%s\" bitsize=\"%d\" type=\"%s\" regnum=\"%d\"/>\n", next.name, next.size*8, next.kind, number)
+	}
+	return xml + "  </feature>\n</target>\n"
+}
+
+//Function to read a register as a number
+func readRegister(chipCpu cpu.Cpu, number int) int {
+	switch number {
+	case registerIndex:
+		return int(cpu.GetIndexRegister(chipCpu))
+	case registerStackPointer:
+		return len(cpu.GetStack(chipCpu))
+	case registerProgramCounter:
+		return int(cpu.GetProgramCounter(chipCpu))
+	}
+	return int(cpu.GetRegister(chipCpu, number))
+}
+
+//Function to write a register
+//sp can only be written with the value it already has, since gdb writes every register at once with G
+func writeRegister(chipCpu cpu.Cpu, number int, value int) (cpu.Cpu, error) {
+	switch number {
+	case registerIndex:
+		return cpu.SetIndexRegister(chipCpu, uint16(value)), nil
+	case registerStackPointer:
+		if value != len(cpu.GetStack(chipCpu)) {
+			return chipCpu, fmt.Errorf("sp can't be written")
+		}
+		return chipCpu, nil
+	case registerProgramCounter:
+		return cpu.SetProgramCounter(chipCpu, uint16(value)), nil
+	}
+	return cpu.SetRegister(chipCpu, number, uint8(value)), nil
+}
+
+//Function to encode a register as little endian hex
+func encodeRegister(chipCpu cpu.Cpu, number int) string {
+	value := readRegister(chipCpu, number)
+	data := make([]byte, registers[number].size)
+	for i := range data {
+		data[i] = uint8(value >> uint(i*8))
+	}
+	return hex.EncodeToString(data)
+}
+
+//Function to decode a register from little endian hex
+func decodeRegister(text string, number int) (int, error) {
+	data, err := hex.DecodeString(text)
+	if err != nil || len(data) != registers[number].size {
+		return 0, fmt.Errorf("bad value %s for %s", text, registers[number].name)
+	}
+
+	value := 0
+	for i, next := range data {
+		value = value | int(next)<<uint(i*8)
+	}
+	return value, nil
+}
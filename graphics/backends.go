@@ -0,0 +1,84 @@
+package graphics
+
+//This is helper class for finding a rendering backend by name, e.g. --renderer sfml
+//Each backend registers itself when it is built in, so backends that need cgo can be left out
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+//Options are how the user asked for the game to be drawn
+type Options struct {
+
+	//Our graphics scale for the window
+	Scale int
+
+	//How pixels are scaled up, for backends that draw a framebuffer
+	Filter Filter
+
+	//Random Color mode
+	RandomColor bool
+
+	//The terminal is being used for something else, e.g. the debugger, so we can't draw on it
+	TerminalBusy bool
+}
+
+//Function to open a rendering backend
+type OpenFunc func(options Options) (Renderer, error)
+
+//A backend we can draw with, backends with a higher priority are picked first for auto
+//Backends with a priority below zero are never picked for auto, they have to be asked for
+type backend struct {
+	open     OpenFunc
+	priority int
+}
+
+//Our backends, by name
+var backends = make(map[string]backend)
+
+//Function to add a rendering backend, call this from init
+func Register(name string, priority int, open OpenFunc) {
+	backends[name] = backend{open: open, priority: priority}
+}
+
+//Function to get the names of our backends, best first
+func Backends() []string {
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i int, j int) bool {
+		if backends[names[i]].priority != backends[names[j]].priority {
+			return backends[names[i]].priority > backends[names[j]].priority
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+//Function to open a rendering backend by name, auto opens the best one that works
+func Open(name string, options Options) (Renderer, error) {
+	if name == "auto" {
+		err := fmt.Errorf("chipGo was built without a window, try --renderer none")
+		for _, name := range Backends() {
+			if backends[name].priority < 0 {
+				continue
+			}
+
+			var renderer Renderer
+			renderer, err = backends[name].open(options)
+			if err == nil {
+				return renderer, nil
+			}
+		}
+		return nil, fmt.Errorf("Could not open a renderer: %s", err)
+	}
+
+	chosen, validBackend := backends[name]
+	if !validBackend {
+		return nil, fmt.Errorf("Unknown renderer: %s, try one of %s", name, strings.Join(Backends(), ", "))
+	}
+	return chosen.open(options)
+}
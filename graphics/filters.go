@@ -0,0 +1,125 @@
+package graphics
+
+//This is helper class for the filters a framebuffer draws through, see --filter
+//Scale2x and hq2x double the pixels before they are scaled up to the image, rounding off the stairs on diagonal lines
+//Scale2x is also known as EPX, see https://www.scale2x.it/algorithm
+
+import (
+	"fmt"
+	"image/color"
+)
+
+//Filter is how we scale pixels up
+type Filter int
+
+const (
+	//Every pixel is a square, like the original
+	FilterNearest Filter = iota
+
+	//Scale2x, or EPX, a pixel becomes four, taking the color of its neighbors along diagonal edges
+	FilterScale2x
+
+	//Like Scale2x, but the pixels along edges are blended with the pixel they came from, for smoother diagonals
+	//Real hq2x compares colors with a big table of patterns, this gets most of the look with two colors, or four on XO-CHIP
+	FilterHQ2x
+
+	//Every pixel is a square, with the last line of each row darkened like a CRT
+	FilterScanlines
+)
+
+//Names of our filters, for --filter
+var filterNames = map[string]Filter{
+	"nearest":   FilterNearest,
+	"scale2x":   FilterScale2x,
+	"hq2x":      FilterHQ2x,
+	"scanlines": FilterScanlines,
+}
+
+//Function to find a filter from it's command line name
+func ParseFilter(name string) (Filter, error) {
+	filter, validFilter := filterNames[name]
+	if !validFilter {
+		return FilterNearest, fmt.Errorf("Unknown filter: %s", name)
+	}
+	return filter, nil
+}
+
+//Function to get the name of a filter
+func (filter Filter) String() string {
+	for name, named := range filterNames {
+		if named == filter {
+			return name
+		}
+	}
+	return "unknown"
+}
+
+//Function to run a filter over the colors of a framebuffer
+func (filter Filter) apply(framebuffer *Framebuffer) {
+	switch filter {
+	case FilterScale2x:
+		scale2x(framebuffer, false)
+		break
+	case FilterHQ2x:
+		scale2x(framebuffer, true)
+		break
+	}
+}
+
+//Function to double the pixels of a framebuffer with Scale2x
+//Each pixel P becomes four, 1 2 on top and 3 4 below, from its neighbors A above, B right, C left, and D below
+//  1 = A if C == A, and C != D, and A != B, else P, and the same for the other corners
+//Blending mixes the neighbor's color half and half with P instead, for hq2x
+func scale2x(framebuffer *Framebuffer, blend bool) {
+	width, height := framebuffer.filteredWidth, framebuffer.filteredHeight
+
+	//Double into our spare pixels, then swap them, so we don't make new ones every frame
+	if cap(framebuffer.spare) < width*2*height*2 {
+		framebuffer.spare = make([]color.RGBA, width*2*height*2)
+	}
+	doubled := framebuffer.spare[:width*2*height*2]
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			p := framebuffer.at(x, y)
+			a := framebuffer.at(x, y-1)
+			b := framebuffer.at(x+1, y)
+			c := framebuffer.at(x-1, y)
+			d := framebuffer.at(x, y+1)
+
+			corners := [4]color.RGBA{p, p, p, p}
+			if c == a && c != d && a != b {
+				corners[0] = a
+			}
+			if a == b && a != c && b != d {
+				corners[1] = b
+			}
+			if d == c && d != b && c != a {
+				corners[2] = c
+			}
+			if b == d && b != a && d != c {
+				corners[3] = d
+			}
+
+			for corner, pixel := range corners {
+				if blend && pixel != p {
+					pixel = mixColors(p, pixel)
+				}
+				doubled[(y*2+corner/2)*width*2+x*2+corner%2] = pixel
+			}
+		}
+	}
+
+	framebuffer.filtered, framebuffer.spare = doubled, framebuffer.filtered
+	framebuffer.filteredWidth = width * 2
+	framebuffer.filteredHeight = height * 2
+}
+
+//Function to mix two colors half and half
+func mixColors(first color.RGBA, second color.RGBA) color.RGBA {
+	return color.RGBA{
+		uint8((int(first.R) + int(second.R)) / 2),
+		uint8((int(first.G) + int(second.G)) / 2),
+		uint8((int(first.B) + int(second.B)) / 2),
+		uint8((int(first.A) + int(second.A)) / 2),
+	}
+}
@@ -0,0 +1,155 @@
+package graphics
+
+//This is helper class for drawing frames into an image, in memory, without any graphics library
+//Any backend can draw the image, and screenshots and recordings can save it
+//The image is always the size of the low resolution screen times our scale, high resolution frames draw smaller pixels into the same size
+
+import (
+	"image"
+	"image/color"
+	"math/rand"
+)
+
+//Framebuffer is a frame drawn into an image, at a scale, through a filter
+type Framebuffer struct {
+	Filter Filter
+
+	//Random Color mode
+	RandomColor bool
+
+	//The image we draw into, reused for every frame
+	Image *image.RGBA
+
+	//The frame's pixels as colors, at the size the filter made them, before we scale them to the image
+	filtered       []color.RGBA
+	filteredWidth  int
+	filteredHeight int
+
+	//Spare pixels for filters to draw into, see filters.go
+	spare []color.RGBA
+}
+
+//Constructor for a framebuffer
+func NewFramebuffer(scale int, filter Filter) *Framebuffer {
+	if scale < 1 {
+		scale = 1
+	}
+
+	return &Framebuffer{
+		Filter: filter,
+		Image:  image.NewRGBA(image.Rect(0, 0, Width*scale, Height*scale)),
+	}
+}
+
+//Function to get our scale, how many pixels of the image each low resolution pixel takes
+func (framebuffer *Framebuffer) Scale() int {
+	return framebuffer.Image.Bounds().Dx() / Width
+}
+
+//Function to draw a frame into our image, and return the image
+//The image is reused, so copy it to keep it past the next frame
+func (framebuffer *Framebuffer) Render(frame Frame) *image.RGBA {
+	framebuffer.colorFrame(frame)
+	framebuffer.Filter.apply(framebuffer)
+	framebuffer.scaleToImage()
+	if framebuffer.Filter == FilterScanlines {
+		framebuffer.scanlines()
+	}
+
+	return framebuffer.Image
+}
+
+//Function to turn the pixels of a frame into colors, by which planes are on
+func (framebuffer *Framebuffer) colorFrame(frame Frame) {
+	displayWidth, displayHeight := frame.Size()
+	framebuffer.resize(displayWidth, displayHeight)
+
+	for y := 0; y < displayHeight; y++ {
+		for x := 0; x < displayWidth; x++ {
+			pixel := frame.Color(x, y)
+			if framebuffer.RandomColor && frame.Display[x][y] != 0 {
+				pixel = color.RGBA{uint8(rand.Intn(255)), uint8(rand.Intn(255)), uint8(rand.Intn(255)), 255}
+			}
+			framebuffer.filtered[y*displayWidth+x] = pixel
+		}
+	}
+}
+
+//Function to set the size of our filtered pixels, keeping the memory if it is big enough
+func (framebuffer *Framebuffer) resize(width int, height int) {
+	if cap(framebuffer.filtered) < width*height {
+		framebuffer.filtered = make([]color.RGBA, width*height)
+	}
+	framebuffer.filtered = framebuffer.filtered[:width*height]
+	framebuffer.filteredWidth = width
+	framebuffer.filteredHeight = height
+}
+
+//Function to find a filtered pixel, pixels off the edge are the closest pixel on it
+func (framebuffer *Framebuffer) at(x int, y int) color.RGBA {
+	if x < 0 {
+		x = 0
+	}
+	if x >= framebuffer.filteredWidth {
+		x = framebuffer.filteredWidth - 1
+	}
+	if y < 0 {
+		y = 0
+	}
+	if y >= framebuffer.filteredHeight {
+		y = framebuffer.filteredHeight - 1
+	}
+	return framebuffer.filtered[y*framebuffer.filteredWidth+x]
+}
+
+//Function to scale our filtered pixels up to the image, each image pixel takes the filtered pixel under it
+func (framebuffer *Framebuffer) scaleToImage() {
+	bounds := framebuffer.Image.Bounds()
+	imageWidth, imageHeight := bounds.Dx(), bounds.Dy()
+
+	previousY := -1
+	for y := 0; y < imageHeight; y++ {
+		row := framebuffer.Image.Pix[y*framebuffer.Image.Stride : y*framebuffer.Image.Stride+imageWidth*4]
+
+		//Lines from the same filtered row are the same, so copy the one above
+		filteredY := y * framebuffer.filteredHeight / imageHeight
+		if filteredY == previousY {
+			copy(row, framebuffer.Image.Pix[(y-1)*framebuffer.Image.Stride:])
+			continue
+		}
+		previousY = filteredY
+
+		filteredRow := framebuffer.filtered[filteredY*framebuffer.filteredWidth:]
+		for x := 0; x < imageWidth; x++ {
+			pixel := filteredRow[x*framebuffer.filteredWidth/imageWidth]
+			row[x*4] = pixel.R
+			row[x*4+1] = pixel.G
+			row[x*4+2] = pixel.B
+			row[x*4+3] = pixel.A
+		}
+	}
+}
+
+//Function to darken the last line of every row of pixels, like the gaps between the lines of a CRT
+//Pixels only one line tall are left alone, or there would be nothing left
+func (framebuffer *Framebuffer) scanlines() {
+	bounds := framebuffer.Image.Bounds()
+	imageHeight := bounds.Dy()
+	if imageHeight < framebuffer.filteredHeight*2 {
+		return
+	}
+
+	for y := 0; y < imageHeight; y++ {
+		//Only the last line before the next row of pixels
+		if y*framebuffer.filteredHeight/imageHeight == (y+1)*framebuffer.filteredHeight/imageHeight {
+			continue
+		}
+
+		row := framebuffer.Image.Pix[y*framebuffer.Image.Stride : y*framebuffer.Image.Stride+bounds.Dx()*4]
+		for i := 0; i < len(row); i = i + 4 {
+			row[i] = row[i] / 2
+			row[i+1] = row[i+1] / 2
+			row[i+2] = row[i+2] / 2
+		}
+	}
+}
@@ -1,156 +1,113 @@
 package graphics
 
-//Using SFML rewritten in Go
-//See here for dependencies needed to be installed
-//https://github.com/tedsta/gosfml
-
-//Also, for vagrant development, use X Forwarding:
-//http://computingforgeeks.com/how-to-enable-and-use-ssh-x11-forwarding-on-vagrant-instances/
-//Need to use "vagrant ssh -- -X chipGo" to test on host machine
+//Drawing is done by a rendering backend, see Renderer
+//The glfw and SFML window needs cgo, so chipGo can also be built without it, and without any window, see sfml.go
 
 import (
-	"github.com/go-gl/glfw3/v3.1/glfw"
-	"github.com/tedsta/gosfml"
+	cpu "github.com/torch2424/chipGo/cpu"
+	input "github.com/torch2424/chipGo/input"
+	"image/color"
 )
 
-//Our graphics scale for the window
-var scale int
-
 //Our colors for the display
-var ColorBg = sf.Color{20, 20, 20, 255}
-
-var ColorSprite = sf.Color{242, 242, 242, 255}
+var ColorBg = color.RGBA{20, 20, 20, 255}
 
-//Chip8 display size
-const Width int = 64
-const Height int = 32
+var ColorSprite = color.RGBA{242, 242, 242, 255}
 
-//Debug mode
-var debugMode bool
+//XO-CHIP has two bitplanes, so a pixel can be one of four colors
+//Index is the bitmask of the planes that are on. Background, first plane, second plane, and both planes
+var Palette = [4]color.RGBA{ColorBg, ColorSprite, color.RGBA{170, 68, 255, 255}, color.RGBA{255, 170, 0, 255}}
 
-//Random Color mode
-var ranColorMode bool
-
-type Video struct {
+//Chip8 display size, from our cpu
+const Width int = cpu.Width
+const Height int = cpu.Height
 
-	//Our window object
-	Window *glfw.Window
+//Super Chip-8 high resolution display size
+const HiResWidth int = cpu.HiResWidth
+const HiResHeight int = cpu.HiResHeight
 
-	//Our target object we render to
-	Target *sf.RenderTarget
-
-	//Our array of Sprites(Individual pixels) we ar erendering
-	pixels []*Pixel
+//Frame is a display to draw, as the cpu left it
+//The display is always the size of the high resolution screen, in low resolution only the top left 64x32 is used
+type Frame struct {
+	Display [HiResWidth][HiResHeight]uint8
+	HighRes bool
 }
 
-//Constructor for video
-func NewVideo(gameScale int, debug bool, ranColor bool) Video {
-
-	//Set our game scale
-	scale = gameScale
-
-	//Create our video
-	video := Video{Window: GetWindow(), Target: GetTarget()}
-
-	//Initialize our slice
-	video.pixels = make([]*Pixel, 0)
-
-	//Debug mode
-	debugMode = debug
-
-	//Random color mode
-	ranColorMode = ranColor
-
-	return video
+//Function to find the size of the screen a frame is drawn on
+func (frame Frame) Size() (int, int) {
+	if frame.HighRes {
+		return HiResWidth, HiResHeight
+	}
+	return Width, Height
 }
 
-//Function to poll events from our glfw library
-func PollEvents() {
-	glfw.PollEvents()
+//Function to find the color of a pixel, by which planes are on
+//Frames are big, so this takes a pointer to not copy the frame for every pixel
+func (frame *Frame) Color(x int, y int) color.RGBA {
+	return Palette[frame.Display[x][y]&0x03]
 }
 
-//Get a window for a struct
-func GetWindow() *glfw.Window {
+//Renderer is a rendering backend, something we can draw frames on and read keys from, e.g. a window
+type Renderer interface {
 
-	//Inform user of graphics initialization
-	print("\nOpening Window...\n")
+	//Draw a frame
+	Present(frame Frame)
 
-	//Initialize graphics library and window
-	glfw.Init()
-	window, err := glfw.CreateWindow(Width*scale, Height*scale, "Chip Go", nil, nil)
-	if err != nil {
-		panic(err)
-	}
-	window.MakeContextCurrent()
+	//Get the keys pressed and let go since we last checked, see input.HandleEvents
+	PollEvents() []input.Event
 
-	return window
-}
+	//Check if we can still draw, e.g. the window wasn't closed
+	IsOpen() bool
 
-//Get a target for a struct
-func GetTarget() *sf.RenderTarget {
+	//Close the window, or whatever we draw on
+	Close()
+}
 
-	//Save our target and exit
-	target := sf.NewRenderTarget(sf.Vector2{float32(Width * scale), float32(Height * scale)})
+//Video is a cpu.Display, so the emulator can draw on our rendering backend
+type Video struct {
+	Renderer Renderer
 
-	return target
+	//Debug mode, also displays how the game should look in the terminal
+	debugMode bool
 }
 
-//Return if the window is open
-func IsOpen(video Video) bool {
-	return !video.Window.ShouldClose()
+//Constructor for video
+func NewVideo(renderer Renderer, debug bool) Video {
+	return Video{Renderer: renderer, debugMode: debug}
 }
 
-//Function to draw sprites to the window
+//Function to draw the display on our rendering backend
 //Also, display how game should look in terminal
-func Render(video Video, display [Width][Height]uint8) {
+func (video Video) Render(display [HiResWidth][HiResHeight]uint8, highRes bool) {
+	frame := Frame{Display: display, HighRes: highRes}
 
 	//If debug mode, title video state
-	if debugMode {
+	if video.debugMode {
 		print("Display State:\n\n")
+		printFrame(frame)
 	}
 
-	//Loop through and create our sprites
-	for i := 0; i < Height; i++ {
-		//Y coordinate
-		for j := 0; j < Width; j++ {
-			//X Corrdinate
+	video.Renderer.Present(frame)
+}
 
-			if display[j][i] == 1 {
+//Function to clear the display
+func (video Video) Clear() {
+	video.Renderer.Present(Frame{})
+}
 
-				if debugMode {
-					print(1)
-				}
-				//Create a sprite at the location
-				video.pixels = append(video.pixels, NewPixel(float32(j*scale), float32(i*scale), float32(scale), float32(scale)))
-			} else if debugMode {
+//Function to print a frame to the terminal, the planes that are on for lit pixels
+func printFrame(frame Frame) {
+	displayWidth, displayHeight := frame.Size()
+	for i := 0; i < displayHeight; i++ {
+		//Y coordinate
+		for j := 0; j < displayWidth; j++ {
+			//X Corrdinate
+			if frame.Display[j][i] != 0 {
+				print(frame.Display[j][i])
+			} else {
 				print(" ")
 			}
-
-			if debugMode && j >= Width-1 {
-				print("\n")
-			}
 		}
+		print("\n")
 	}
-
-	//Render all of the pixels
-	//Clear the screen
-	video.Target.Clear(ColorBg)
-
-	//Render all of our pixels
-	for i := 0; i < len(video.pixels); i++ {
-		video.pixels[i].Render(video.Target, ranColorMode)
-	}
-
-	//Swap the buffers to show the new renders
-	video.Window.SwapBuffers()
-}
-
-//Function to clear the window
-func Clear(video Video) {
-
-	//Clear our pixels
-	video.pixels = nil
-
-	//video.Target.Clear(ColorBg)
-	video.Window.SwapBuffers()
 }
@@ -0,0 +1,29 @@
+package graphics
+
+//This is helper class for the none backend, which draws nothing
+//For running a game with no display at all, e.g. to debug it with --gdb or --dap on a server
+
+import (
+	input "github.com/torch2424/chipGo/input"
+)
+
+//Draw nothing, and never close
+type noneRenderer struct{}
+
+func init() {
+	Register("none", -1, func(options Options) (Renderer, error) {
+		return noneRenderer{}, nil
+	})
+}
+
+func (renderer noneRenderer) Present(frame Frame) {}
+
+func (renderer noneRenderer) PollEvents() []input.Event {
+	return nil
+}
+
+func (renderer noneRenderer) IsOpen() bool {
+	return true
+}
+
+func (renderer noneRenderer) Close() {}
@@ -0,0 +1,228 @@
+//go:build cgo
+// +build cgo
+
+package graphics
+
+//Using SFML rewritten in Go
+//See here for dependencies needed to be installed
+//https://github.com/tedsta/gosfml
+
+//Also, for vagrant development, use X Forwarding:
+//http://computingforgeeks.com/how-to-enable-and-use-ssh-x11-forwarding-on-vagrant-instances/
+//Need to use "vagrant ssh -- -X chipGo" to test on host machine
+
+//glfw and gosfml need cgo, so this backend is only built with it
+
+import (
+	"bytes"
+	"github.com/go-gl/glfw3/v3.1/glfw"
+	"github.com/tedsta/gosfml"
+	input "github.com/torch2424/chipGo/input"
+	"image/color"
+)
+
+//Our mapping of glfw keys to our keys
+var glfwKeys = map[glfw.Key]input.Key{
+	glfw.Key0: input.Key0,
+	glfw.Key1: input.Key1,
+	glfw.Key2: input.Key2,
+	glfw.Key3: input.Key3,
+	glfw.Key4: input.Key4,
+	glfw.Key5: input.Key5,
+	glfw.Key6: input.Key6,
+	glfw.Key7: input.Key7,
+	glfw.Key8: input.Key8,
+	glfw.Key9: input.Key9,
+
+	glfw.KeyA: input.KeyA,
+	glfw.KeyB: input.KeyB,
+	glfw.KeyC: input.KeyC,
+	glfw.KeyD: input.KeyD,
+	glfw.KeyE: input.KeyE,
+	glfw.KeyF: input.KeyF,
+	glfw.KeyG: input.KeyG,
+	glfw.KeyH: input.KeyH,
+	glfw.KeyI: input.KeyI,
+	glfw.KeyJ: input.KeyJ,
+	glfw.KeyK: input.KeyK,
+	glfw.KeyL: input.KeyL,
+	glfw.KeyM: input.KeyM,
+	glfw.KeyN: input.KeyN,
+	glfw.KeyO: input.KeyO,
+	glfw.KeyP: input.KeyP,
+	glfw.KeyQ: input.KeyQ,
+	glfw.KeyR: input.KeyR,
+	glfw.KeyS: input.KeyS,
+	glfw.KeyT: input.KeyT,
+	glfw.KeyU: input.KeyU,
+	glfw.KeyV: input.KeyV,
+	glfw.KeyW: input.KeyW,
+	glfw.KeyX: input.KeyX,
+	glfw.KeyY: input.KeyY,
+	glfw.KeyZ: input.KeyZ,
+
+	glfw.KeySpace:     input.KeySpace,
+	glfw.KeyEnter:     input.KeyEnter,
+	glfw.KeyBackspace: input.KeyBackspace,
+	glfw.KeyEscape:    input.KeyEscape,
+
+	glfw.KeyF1:  input.KeyF1,
+	glfw.KeyF2:  input.KeyF2,
+	glfw.KeyF3:  input.KeyF3,
+	glfw.KeyF4:  input.KeyF4,
+	glfw.KeyF5:  input.KeyF5,
+	glfw.KeyF6:  input.KeyF6,
+	glfw.KeyF7:  input.KeyF7,
+	glfw.KeyF8:  input.KeyF8,
+	glfw.KeyF9:  input.KeyF9,
+	glfw.KeyF10: input.KeyF10,
+	glfw.KeyF11: input.KeyF11,
+	glfw.KeyF12: input.KeyF12,
+}
+
+//Draws on a glfw window with SFML
+type sfmlRenderer struct {
+
+	//Our frames, drawn at the size of the window
+	framebuffer *Framebuffer
+
+	//Our window object
+	window *glfw.Window
+
+	//Our target object we render to
+	target *sf.RenderTarget
+
+	//Quads for the lines of color in the framebuffer, reused every frame
+	vertices []sf.Vertex
+
+	//Keys pressed in our window since we last polled
+	events []input.Event
+}
+
+func init() {
+	Register("sfml", 10, newSfmlRenderer)
+}
+
+//Constructor for our window
+func newSfmlRenderer(options Options) (Renderer, error) {
+
+	//Inform user of graphics initialization
+	print("\nOpening Window...\n")
+
+	//Initialize graphics library and window
+	renderer := &sfmlRenderer{framebuffer: NewFramebuffer(options.Scale, options.Filter)}
+	renderer.framebuffer.RandomColor = options.RandomColor
+	scale := renderer.framebuffer.Scale()
+	err := glfw.Init()
+	if err != nil {
+		return nil, err
+	}
+	window, err := glfw.CreateWindow(Width*scale, Height*scale, "Chip Go", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	window.MakeContextCurrent()
+	renderer.window = window
+
+	//Get a target to render to
+	renderer.target = sf.NewRenderTarget(sf.Vector2{float32(Width * scale), float32(Height * scale)})
+
+	//Set our input handler
+	window.SetKeyCallback(renderer.keyCallback)
+
+	return renderer, nil
+}
+
+//Function to turn glfw key events into ours
+func (renderer *sfmlRenderer) keyCallback(w *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+
+	//Key repeats don't press the key again
+	if action != glfw.Press && action != glfw.Release {
+		return
+	}
+
+	ourKey, validKey := glfwKeys[key]
+	if !validKey {
+		return
+	}
+
+	renderer.events = append(renderer.events, input.Event{Key: ourKey, Pressed: action == glfw.Press, Shift: mods&glfw.ModShift != 0})
+}
+
+//Function to poll events from our glfw library
+func (renderer *sfmlRenderer) PollEvents() []input.Event {
+	glfw.PollEvents()
+
+	events := renderer.events
+	renderer.events = nil
+	return events
+}
+
+//Return if the window is open
+func (renderer *sfmlRenderer) IsOpen() bool {
+	return !renderer.window.ShouldClose()
+}
+
+//Function to draw a frame to the window
+//The frame is drawn into our framebuffer, then each line of one color in it becomes a quad
+func (renderer *sfmlRenderer) Present(frame Frame) {
+	image := renderer.framebuffer.Render(frame)
+	bounds := image.Bounds()
+	background := sfColor(ColorBg)
+
+	renderer.vertices = renderer.vertices[:0]
+	previousRow := -1
+	previousQuads := 0
+	for y := 0; y < bounds.Dy(); y++ {
+		row := image.Pix[y*image.Stride : y*image.Stride+bounds.Dx()*4]
+
+		//Rows the same as the one above make its quads taller, instead of adding more
+		if previousRow >= 0 && bytes.Equal(row, image.Pix[previousRow*image.Stride:previousRow*image.Stride+bounds.Dx()*4]) {
+			for i := len(renderer.vertices) - previousQuads*4; i < len(renderer.vertices); i = i + 4 {
+				renderer.vertices[i+1].Position.Y++
+				renderer.vertices[i+2].Position.Y++
+			}
+			continue
+		}
+		previousRow = y
+		previousQuads = 0
+
+		//Find each line of one color, the background is already drawn when we clear
+		for start := 0; start < bounds.Dx(); {
+			end := start + 1
+			for end < bounds.Dx() && bytes.Equal(row[end*4:end*4+4], row[start*4:start*4+4]) {
+				end++
+			}
+
+			lineColor := sf.Color{row[start*4], row[start*4+1], row[start*4+2], row[start*4+3]}
+			if lineColor != background {
+				top, bottom := float32(y), float32(y+1)
+				left, right := float32(start), float32(end)
+				renderer.vertices = append(renderer.vertices,
+					sf.Vertex{sf.Vector2{left, top}, lineColor, sf.Vector2{}},
+					sf.Vertex{sf.Vector2{left, bottom}, lineColor, sf.Vector2{}},
+					sf.Vertex{sf.Vector2{right, bottom}, lineColor, sf.Vector2{}},
+					sf.Vertex{sf.Vector2{right, top}, lineColor, sf.Vector2{}})
+				previousQuads++
+			}
+			start = end
+		}
+	}
+
+	//Clear the screen, then draw all of our quads at once
+	renderer.target.Clear(background)
+	renderer.target.Render(renderer.vertices, sf.Quads, sf.RenderStates{sf.BlendAlpha, sf.IdentityTransform(), nil})
+
+	//Swap the buffers to show the new renders
+	renderer.window.SwapBuffers()
+}
+
+//Function to close the window
+func (renderer *sfmlRenderer) Close() {
+	renderer.window.Destroy()
+}
+
+//Function to turn one of our colors into an SFML color
+func sfColor(rgba color.RGBA) sf.Color {
+	return sf.Color{rgba.R, rgba.G, rgba.B, rgba.A}
+}
@@ -0,0 +1,217 @@
+package graphics
+
+//This is helper class for the terminal backends, which draw with text, e.g. over SSH where there is no window
+//terminal draws two pixels a character with the upper half block, colored with ANSI 24 bit colors
+//braille draws eight pixels a character with braille dots, so the high resolution screen fits in 64 columns
+//Only the characters that changed are drawn again, and the whole screen once a second, in case something else printed over it
+
+import (
+	"bytes"
+	"fmt"
+	input "github.com/torch2424/chipGo/input"
+	"golang.org/x/term"
+	"image/color"
+	"os"
+	"time"
+)
+
+//How often we draw the whole screen again
+const terminalRedrawTime = time.Second
+
+//A character on the terminal, and its colors
+type terminalCell struct {
+	char rune
+	fg   color.RGBA
+	bg   color.RGBA
+}
+
+//Function to find how many pixels go in a character, and the character for the pixels at a column and row
+type terminalStyle struct {
+	cellWidth  int
+	cellHeight int
+	cell       func(frame *Frame, column int, row int) terminalCell
+}
+
+//Draws on the terminal we were started in
+type terminalRenderer struct {
+	style terminalStyle
+
+	//Where we draw, and how the terminal was before we made it raw
+	out      *os.File
+	oldState *term.State
+
+	//The characters on the terminal, so we only draw the ones that changed
+	cells      [][]terminalCell
+	lastRedraw time.Time
+
+	//Keys read from the terminal, see termkeys.go
+	keys *terminalKeys
+
+	open bool
+}
+
+func init() {
+	Register("terminal", 5, func(options Options) (Renderer, error) {
+		return newTerminalRenderer(options, terminalStyle{cellWidth: 1, cellHeight: 2, cell: halfBlockCell})
+	})
+	Register("braille", 4, func(options Options) (Renderer, error) {
+		return newTerminalRenderer(options, terminalStyle{cellWidth: 2, cellHeight: 4, cell: brailleCell})
+	})
+}
+
+//Constructor for our terminal renderers
+func newTerminalRenderer(options Options, style terminalStyle) (Renderer, error) {
+	if options.TerminalBusy {
+		return nil, fmt.Errorf("The terminal is being used for something else, e.g. --debug")
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return nil, fmt.Errorf("Not running in a terminal")
+	}
+
+	return &terminalRenderer{style: style, out: os.Stdout, open: true}, nil
+}
+
+//Function to take over the terminal, the first time we draw or read keys
+//Waiting until then leaves the terminal alone if chipGo stops before the game starts, e.g. a game that won't load
+func (renderer *terminalRenderer) start() {
+	if renderer.keys != nil {
+		return
+	}
+
+	//Read keys as they are pressed, instead of a line at a time
+	oldState, err := term.MakeRaw(int(os.Stdin.Fd()))
+	if err != nil {
+		print("\nCould not read keys from the terminal...\n")
+		print(err.Error())
+		print("\nContinuing anyways...\n")
+	}
+	renderer.oldState = oldState
+	renderer.keys = newTerminalKeys(os.Stdin)
+
+	//Draw on the alternate screen without a cursor, so the terminal is left how it was when we close
+	fmt.Fprint(renderer.out, "\x1b[?1049h\x1b[?25l\x1b[2J")
+}
+
+//Function to get the keys pressed and let go on the terminal
+func (renderer *terminalRenderer) PollEvents() []input.Event {
+	renderer.start()
+	events, quit := renderer.keys.poll(time.Now())
+	if quit {
+		renderer.open = false
+	}
+	return events
+}
+
+//Return if we can still draw, Ctrl+C closes the terminal renderers
+func (renderer *terminalRenderer) IsOpen() bool {
+	return renderer.open
+}
+
+//Function to draw a frame, only the characters that changed
+func (renderer *terminalRenderer) Present(frame Frame) {
+	renderer.start()
+
+	displayWidth, displayHeight := frame.Size()
+	columns := displayWidth / renderer.style.cellWidth
+	rows := displayHeight / renderer.style.cellHeight
+
+	//Draw everything again if the resolution changed, or it has been a while
+	redraw := len(renderer.cells) != rows || len(renderer.cells[0]) != columns || time.Since(renderer.lastRedraw) >= terminalRedrawTime
+	var out bytes.Buffer
+	if redraw {
+		renderer.cells = make([][]terminalCell, rows)
+		for row := range renderer.cells {
+			renderer.cells[row] = make([]terminalCell, columns)
+		}
+		renderer.lastRedraw = time.Now()
+		out.WriteString("\x1b[0m\x1b[2J")
+	}
+
+	//Keep track of the colors and cursor we left the terminal with, so we only send what changed
+	var fg, bg color.RGBA
+	colorsSet := false
+	cursorRow, cursorColumn := -1, -1
+
+	for row := 0; row < rows; row++ {
+		for column := 0; column < columns; column++ {
+			cell := renderer.style.cell(&frame, column, row)
+			if !redraw && cell == renderer.cells[row][column] {
+				continue
+			}
+			renderer.cells[row][column] = cell
+
+			//Terminal rows and columns count from 1
+			if cursorRow != row || cursorColumn != column {
+				fmt.Fprintf(&out, "\x1b[%d;%dH", row+1, column+1)
+			}
+			if !colorsSet || cell.fg != fg {
+				fmt.Fprintf(&out, "\x1b[38;2;%d;%d;%dm", cell.fg.R, cell.fg.G, cell.fg.B)
+			}
+			if !colorsSet || cell.bg != bg {
+				fmt.Fprintf(&out, "\x1b[48;2;%d;%d;%dm", cell.bg.R, cell.bg.G, cell.bg.B)
+			}
+			fg, bg, colorsSet = cell.fg, cell.bg, true
+
+			out.WriteRune(cell.char)
+			cursorRow, cursorColumn = row, column+1
+		}
+	}
+
+	//Leave the cursor under the display, so anything else printed goes there
+	if out.Len() > 0 {
+		fmt.Fprintf(&out, "\x1b[0m\x1b[%d;1H", rows+1)
+		renderer.out.Write(out.Bytes())
+	}
+}
+
+//Function to put the terminal back how it was
+func (renderer *terminalRenderer) Close() {
+	renderer.open = false
+	if renderer.keys == nil {
+		return
+	}
+
+	fmt.Fprint(renderer.out, "\x1b[0m\x1b[?25h\x1b[?1049l")
+	if renderer.oldState != nil {
+		term.Restore(int(os.Stdin.Fd()), renderer.oldState)
+	}
+}
+
+//Function to draw two pixels, one above the other, with the upper half block
+//The top pixel is the color of the block, and the bottom pixel the color behind it
+func halfBlockCell(frame *Frame, column int, row int) terminalCell {
+	return terminalCell{char: '▀', fg: frame.Color(column, row*2), bg: frame.Color(column, row*2+1)}
+}
+
+//Bit of each braille dot, by the pixel's place in the character, see https://en.wikipedia.org/wiki/Braille_Patterns
+var brailleDots = [4][2]rune{
+	{0x01, 0x08},
+	{0x02, 0x10},
+	{0x04, 0x20},
+	{0x40, 0x80},
+}
+
+//Function to draw two by four pixels as braille dots, one for each lit pixel
+//A character can only have one color, so the dots take the color most of them have
+func brailleCell(frame *Frame, column int, row int) terminalCell {
+	var dots rune
+	var planes [4]int
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 2; x++ {
+			pixel := frame.Display[column*2+x][row*4+y] & 0x03
+			if pixel != 0 {
+				dots = dots | brailleDots[y][x]
+				planes[pixel]++
+			}
+		}
+	}
+
+	mostPlanes := 1
+	for plane := 2; plane < len(planes); plane++ {
+		if planes[plane] > planes[mostPlanes] {
+			mostPlanes = plane
+		}
+	}
+
+	return terminalCell{char: 0x2800 + dots, fg: Palette[mostPlanes], bg: ColorBg}
+}
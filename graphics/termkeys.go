@@ -0,0 +1,223 @@
+package graphics
+
+//This is helper class for reading keys from the terminal, for the terminal backends
+//Terminals only send the keys that are typed, not when they are let go, so we let go of a key when it hasn't been typed for a while
+//Holding a key down types it over and over, which keeps it held
+
+import (
+	input "github.com/torch2424/chipGo/input"
+	"os"
+	"time"
+)
+
+//How long a key stays held after it is first typed, longer than terminals wait before repeating a held key
+//e.g. X waits 660ms by default, and macOS 500ms
+const terminalKeyFirstHold = 700 * time.Millisecond
+
+//How long a key stays held once it is repeating, a little longer than the gap between repeats of a held key
+const terminalKeyHold = 250 * time.Millisecond
+
+//Ctrl+C, raw terminals send it to us instead of stopping the program
+const terminalQuit = 0x03
+
+//A key typed on the terminal
+//Quit is Ctrl+C, which isn't a key we pass on
+type terminalKey struct {
+	key   input.Key
+	shift bool
+	quit  bool
+}
+
+//Escape sequences terminals send for function keys
+//Shift adds ;2 to these, e.g. \x1b[15;2~ is Shift+F5, see parseTerminalSequence
+var terminalSequences = map[string]input.Key{
+	"OP": input.KeyF1,
+	"OQ": input.KeyF2,
+	"OR": input.KeyF3,
+	"OS": input.KeyF4,
+	"[P": input.KeyF1,
+	"[Q": input.KeyF2,
+	"[R": input.KeyF3,
+	"[S": input.KeyF4,
+
+	"[11~": input.KeyF1,
+	"[12~": input.KeyF2,
+	"[13~": input.KeyF3,
+	"[14~": input.KeyF4,
+	"[15~": input.KeyF5,
+	"[17~": input.KeyF6,
+	"[18~": input.KeyF7,
+	"[19~": input.KeyF8,
+	"[20~": input.KeyF9,
+	"[21~": input.KeyF10,
+	"[23~": input.KeyF11,
+	"[24~": input.KeyF12,
+}
+
+//Keys read from a terminal, and the keys we are holding
+type terminalKeys struct {
+	typed chan []byte
+	held  map[input.Key]heldKey
+}
+
+//A key we are holding, when it was last typed, and if the terminal is repeating it
+type heldKey struct {
+	typedAt  time.Time
+	repeated bool
+}
+
+//Function to start reading keys from a terminal
+func newTerminalKeys(in *os.File) *terminalKeys {
+	keys := &terminalKeys{typed: make(chan []byte, 64), held: make(map[input.Key]heldKey)}
+
+	//Reading blocks until a key is typed, so read in the background
+	go func() {
+		buffer := make([]byte, 64)
+		for {
+			count, err := in.Read(buffer)
+			if err != nil {
+				close(keys.typed)
+				return
+			}
+			data := make([]byte, count)
+			copy(data, buffer[:count])
+			keys.typed <- data
+		}
+	}()
+
+	return keys
+}
+
+//Function to get the keys pressed and let go since we last polled
+//Returns true if Ctrl+C was typed, or the terminal closed
+func (keys *terminalKeys) poll(now time.Time) ([]input.Event, bool) {
+	var events []input.Event
+	quit := false
+
+	//Press the keys typed since we last polled
+	reading := true
+	for reading {
+		select {
+		case data, open := <-keys.typed:
+			if !open {
+				quit = true
+				reading = false
+				break
+			}
+			for _, typed := range parseTerminalKeys(data) {
+				if typed.quit {
+					quit = true
+					continue
+				}
+				_, alreadyHeld := keys.held[typed.key]
+				if !alreadyHeld {
+					events = append(events, input.Event{Key: typed.key, Pressed: true, Shift: typed.shift})
+				}
+				keys.held[typed.key] = heldKey{typedAt: now, repeated: alreadyHeld}
+			}
+			break
+		default:
+			reading = false
+			break
+		}
+	}
+
+	//Let go of the keys that haven't been typed for a while
+	//Until a key repeats, we wait long enough for the terminal to start repeating it
+	for key, held := range keys.held {
+		hold := terminalKeyFirstHold
+		if held.repeated {
+			hold = terminalKeyHold
+		}
+		if now.Sub(held.typedAt) >= hold {
+			events = append(events, input.Event{Key: key, Pressed: false})
+			delete(keys.held, key)
+		}
+	}
+
+	return events, quit
+}
+
+//Function to find the keys in what the terminal sent us
+//Anything we don't use is left out
+func parseTerminalKeys(data []byte) []terminalKey {
+	var typed []terminalKey
+	for i := 0; i < len(data); i++ {
+		char := data[i]
+
+		//Escape sequences, or the escape key on its own
+		if char == 0x1b {
+			if i+1 >= len(data) {
+				typed = append(typed, terminalKey{key: input.KeyEscape})
+				continue
+			}
+			key, shift, length := parseTerminalSequence(data[i+1:])
+			if key != input.KeyUnknown {
+				typed = append(typed, terminalKey{key: key, shift: shift})
+			}
+			i = i + length
+			continue
+		}
+
+		switch {
+		case char == terminalQuit:
+			typed = append(typed, terminalKey{quit: true})
+			break
+		case char >= '0' && char <= '9':
+			typed = append(typed, terminalKey{key: input.Key0 + input.Key(char-'0')})
+			break
+		case char >= 'a' && char <= 'z':
+			typed = append(typed, terminalKey{key: input.KeyA + input.Key(char-'a')})
+			break
+		case char >= 'A' && char <= 'Z':
+			typed = append(typed, terminalKey{key: input.KeyA + input.Key(char-'A'), shift: true})
+			break
+		case char == ' ':
+			typed = append(typed, terminalKey{key: input.KeySpace})
+			break
+		case char == '\r' || char == '\n':
+			typed = append(typed, terminalKey{key: input.KeyEnter})
+			break
+		case char == 0x7f || char == 0x08:
+			typed = append(typed, terminalKey{key: input.KeyBackspace})
+			break
+		}
+	}
+	return typed
+}
+
+//Function to read the escape sequence after an escape, returns its key, if shift was held, and how long it was
+//Sequences end with a letter or ~, e.g. [15~, OP, or [1;2P for Shift+F1
+func parseTerminalSequence(data []byte) (input.Key, bool, int) {
+	if data[0] != '[' && data[0] != 'O' {
+		return input.KeyUnknown, false, 0
+	}
+
+	length := 1
+	for length < len(data) {
+		char := data[length]
+		length++
+		if char == '~' || (char >= 'A' && char <= 'Z') || (char >= 'a' && char <= 'z') {
+			break
+		}
+	}
+	sequence := string(data[:length])
+
+	//Take out the modifier, ;2 is shift
+	shift := false
+	end := sequence[len(sequence)-1:]
+	body := sequence[:len(sequence)-1]
+	for i := 0; i < len(body); i++ {
+		if body[i] == ';' {
+			shift = body[i+1:] == "2"
+			body = body[:i]
+			break
+		}
+	}
+	//Shift+F1 to F4 come as [1;2P, which is OP without shift
+	if body == "[1" {
+		body = "O"
+	}
+
+	return terminalSequences[body+end], shift, length
+}
@@ -0,0 +1,54 @@
+package main
+
+//This is helper class for running games without a window, e.g. for tracediff
+//Games run frame by frame as fast as they can, instead of on our clocks
+
+import (
+	cpu "github.com/torch2424/chipGo/cpu"
+	octo "github.com/torch2424/chipGo/octo"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+//Function to make a cpu from our --mode and --quirks flags, and load a game into it without starting our clocks
+//Octo source is compiled first, like when playing
+func newHeadlessCpu(gamePath string) cpu.Cpu {
+	mode, err := cpu.ParseMode(*cpuMode)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	chipCpu := cpu.NewCpu("chipCpu", *gameSpeed, mode)
+	chipCpu.Quirks, err = cpu.ParseQuirks(*quirks, mode)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	chipCpu.Random, _ = newRandom(true)
+
+	var game []byte
+	if strings.ToLower(filepath.Ext(gamePath)) == ".8o" {
+		var program *octo.Program
+		program, err = octo.CompileFile(gamePath)
+		if program != nil {
+			game = program.Binary
+		}
+	} else {
+		game, err = ioutil.ReadFile(gamePath)
+	}
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	chipCpu, err = cpu.LoadRom(chipCpu, game)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	return chipCpu
+}
@@ -7,13 +7,7 @@ package input
 */
 
 //This is helper class for handling Input in go
-//Using glfw to send key events to the window, which we can then record
-//https://github.com/tedsta/gosfml/blob/master/examples/pong/main.go
-
-//Imports
-import (
-	"github.com/go-gl/glfw3/v3.1/glfw"
-)
+//Our rendering backend sends us the keys pressed in its window, which we can then record, see graphics.Renderer
 
 //How we are mapping our keys to chip go keyboard
 //Key pad is counting in hex
@@ -30,45 +24,84 @@ import (
 
 //Our keys we will be watching
 //Left side is keypad, right side is keyboard
-//Our mapping to keys (keymap). Key is the glfwKey, and the value is the index of the key on hex chip 8 keyboard
-var keyMap = map[glfw.Key]int{
+//Our mapping to keys (keymap). Key is the keyboard key, and the value is the index of the key on hex chip 8 keyboard
+var keyMap = map[Key]int{
 	//Zero
-	glfw.KeyX: 0,
+	KeyX: 0,
 	//One
-	glfw.Key1: 1,
+	Key1: 1,
 	//Two
-	glfw.Key2: 2,
+	Key2: 2,
 	//Three
-	glfw.Key3: 3,
+	Key3: 3,
 	//Four
-	glfw.KeyQ: 4,
+	KeyQ: 4,
 	//Five
-	glfw.KeyW: 5,
+	KeyW: 5,
 	//Six
-	glfw.KeyE: 6,
+	KeyE: 6,
 	//Seven
-	glfw.KeyA: 7,
+	KeyA: 7,
 	//Eight
-	glfw.KeyS: 8,
+	KeyS: 8,
 	//Nine
-	glfw.KeyD: 9,
+	KeyD: 9,
 	//A (10)
-	glfw.KeyZ: 10,
+	KeyZ: 10,
 	//B (11)
-	glfw.KeyC: 11,
+	KeyC: 11,
 	//C (12)
-	glfw.Key4: 12,
+	Key4: 12,
 	//D (13)
-	glfw.KeyR: 13,
+	KeyR: 13,
 	//E (14)
-	glfw.KeyF: 14,
+	KeyF: 14,
 	//F (15)
-	glfw.KeyV: 15,
+	KeyV: 15,
 }
 
 //Array of boolean saying if key is pressed (0 - F on keypad)
 var pressedKeys [16]bool
 
+//Hotkey is a key pressed for the emulator instead of the game, e.g. F1 to load a save state
+type Hotkey struct {
+	Key   Key
+	Shift bool
+}
+
+//Keys we use as hotkeys, they are queued until the emulator asks for them
+var hotkeyKeys = map[Key]bool{
+	KeyF1:  true,
+	KeyF2:  true,
+	KeyF3:  true,
+	KeyF4:  true,
+	KeyF5:  true,
+	KeyF6:  true,
+	KeyF7:  true,
+	KeyF8:  true,
+	KeyF9:  true,
+	KeyF10: true,
+	KeyF11: true,
+	KeyF12: true,
+
+	//For the tool-assisted run editor, which also gets the keypad keys as hotkeys
+	KeySpace: true,
+	KeyEnter: true,
+}
+
+//Hotkeys pressed since we last checked
+var hotkeyQueue []Hotkey
+
+//Keys that are held down, for hotkeys that work while held, e.g. rewind
+var heldKeys = make(map[Key]bool)
+
+//Keyboard is a cpu.KeySource for the keys pressed in our window
+type Keyboard struct{}
+
+func (keyboard Keyboard) GetKeyArray() ([16]bool, bool) {
+	return GetKeyArray()
+}
+
 func GetKeyArray() ([16]bool, bool) {
 
 	//Declare if we found a key that was pressed
@@ -85,27 +118,54 @@ func GetKeyArray() ([16]bool, bool) {
 	return pressedKeys, keyPressed
 }
 
-func KeyCallback(w *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+//Function to get the hotkeys pressed since we last checked, in the order they were pressed
+func GetHotkeys() []Hotkey {
+	hotkeys := hotkeyQueue
+	hotkeyQueue = nil
+	return hotkeys
+}
+
+//Function to check if a key is held down
+func IsKeyHeld(key Key) bool {
+	return heldKeys[key]
+}
+
+//Function to find the keypad key for a keyboard key
+//Returns false if it is not one of our keypad keys
+func KeypadKey(key Key) (int, bool) {
+	keypad, validKey := keyMap[key]
+	return keypad, validKey
+}
+
+//Function to handle the key events from our rendering backend, see graphics.Renderer
+func HandleEvents(events []Event) {
+	for _, event := range events {
+		HandleEvent(event)
+	}
+}
+
+//Function to handle a key pressed or let go
+func HandleEvent(event Event) {
+
+	//Remember which keys are held
+	heldKeys[event.Key] = event.Pressed
 
 	//First use a two value assignment to check for key existance
 	//https://blog.golang.org/go-maps-in-action
-	_, validKey := keyMap[key]
+	_, validKey := keyMap[event.Key]
+
+	//Queue our hotkeys for the emulator, and keypad keys for the tool-assisted run editor
+	if (hotkeyKeys[event.Key] || validKey) && event.Pressed {
+		hotkeyQueue = append(hotkeyQueue, Hotkey{Key: event.Key, Shift: event.Shift})
+	}
 
 	//Get the key's value from our keymap
 	if validKey {
 
 		//Get the keys value
-		keyPressed := keyMap[key]
-
-		if action == glfw.Press {
+		keyPressed := keyMap[event.Key]
 
-			//Set pressed keys to true
-			pressedKeys[keyPressed] = true
-
-		} else if action == glfw.Release {
-
-			//Set pressed keys to false
-			pressedKeys[keyPressed] = false
-		}
+		//Set pressed keys
+		pressedKeys[keyPressed] = event.Pressed
 	}
 }
@@ -0,0 +1,82 @@
+package input
+
+/*
+   This project will be a CHIP-8 emulator in go, for a basic understanding of laearning how to code an emulator
+
+   http://www.multigesture.net/articles/how-to-write-an-emulator-chip-8-interpreter/
+*/
+
+//This is helper class for the keys of a keyboard
+//Each rendering backend reads keys its own way, e.g. from a glfw window, and turns them into our keys, see graphics.Renderer
+//So the emulator and its hotkeys don't depend on any one backend
+
+//Key is a key on the keyboard
+type Key int
+
+//The keys we use, for the keypad and hotkeys
+const (
+	KeyUnknown Key = iota
+
+	Key0
+	Key1
+	Key2
+	Key3
+	Key4
+	Key5
+	Key6
+	Key7
+	Key8
+	Key9
+
+	KeyA
+	KeyB
+	KeyC
+	KeyD
+	KeyE
+	KeyF
+	KeyG
+	KeyH
+	KeyI
+	KeyJ
+	KeyK
+	KeyL
+	KeyM
+	KeyN
+	KeyO
+	KeyP
+	KeyQ
+	KeyR
+	KeyS
+	KeyT
+	KeyU
+	KeyV
+	KeyW
+	KeyX
+	KeyY
+	KeyZ
+
+	KeySpace
+	KeyEnter
+	KeyBackspace
+	KeyEscape
+
+	KeyF1
+	KeyF2
+	KeyF3
+	KeyF4
+	KeyF5
+	KeyF6
+	KeyF7
+	KeyF8
+	KeyF9
+	KeyF10
+	KeyF11
+	KeyF12
+)
+
+//Event is a key pressed or let go, from a rendering backend
+type Event struct {
+	Key     Key
+	Pressed bool
+	Shift   bool
+}
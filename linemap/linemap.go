@@ -0,0 +1,252 @@
+package linemap
+
+/*
+   Line maps for Chip-8, SCHIP, and XO-CHIP games
+
+   Which source line each address of a game came from, so debuggers can show source instead of addresses
+*/
+
+//Line maps are text, one entry a line, and ; starts a comment
+//  line 0x202 12 pong.asm      the code at 0x202 came from line 12 of pong.asm
+//  label 0x20A main            the label main is at 0x20A
+//File names are relative to the line map, and can have spaces
+//A game's line map sits next to it, e.g. games/pong.ch8.map
+
+//Imports
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//Line is where the code at an address came from
+type Line struct {
+	Address int
+	File    string
+	Line    int
+}
+
+//Label is a name for an address, e.g. the start of a subroutine
+type Label struct {
+	Name    string
+	Address int
+}
+
+//Map is the lines and labels of a game, sorted by address
+type Map struct {
+	Lines  []Line
+	Labels []Label
+}
+
+//Function to find the line map of a game
+func PathFor(gamePath string) string {
+	return gamePath + ".map"
+}
+
+//Function to make a line map, sorting the lines and labels
+//If two lines have the same address, the last one wins, e.g. after :org writes over code
+func New(lines []Line, labels []Label) *Map {
+	lineMap := &Map{}
+
+	sorted := make([]Line, len(lines))
+	copy(sorted, lines)
+	sort.SliceStable(sorted, func(i int, j int) bool { return sorted[i].Address < sorted[j].Address })
+	for _, next := range sorted {
+		count := len(lineMap.Lines)
+		if count > 0 && lineMap.Lines[count-1].Address == next.Address {
+			lineMap.Lines[count-1] = next
+		} else {
+			lineMap.Lines = append(lineMap.Lines, next)
+		}
+	}
+
+	lineMap.Labels = make([]Label, len(labels))
+	copy(lineMap.Labels, labels)
+	sort.Slice(lineMap.Labels, func(i int, j int) bool {
+		if lineMap.Labels[i].Address != lineMap.Labels[j].Address {
+			return lineMap.Labels[i].Address < lineMap.Labels[j].Address
+		}
+		return lineMap.Labels[i].Name < lineMap.Labels[j].Name
+	})
+
+	return lineMap
+}
+
+//Function to find the line an address is in, the closest line at or before it
+func (lineMap *Map) Find(address int) (Line, bool) {
+	index := sort.Search(len(lineMap.Lines), func(i int) bool { return lineMap.Lines[i].Address > address })
+	if index == 0 {
+		return Line{}, false
+	}
+	return lineMap.Lines[index-1], true
+}
+
+//Function to check if a line starts at an address
+func (lineMap *Map) IsLineStart(address int) bool {
+	index := sort.Search(len(lineMap.Lines), func(i int) bool { return lineMap.Lines[i].Address >= address })
+	return index < len(lineMap.Lines) && lineMap.Lines[index].Address == address
+}
+
+//Function to find every address where code for a line starts
+func (lineMap *Map) Addresses(file string, line int) []int {
+	addresses := make([]int, 0)
+	for _, next := range lineMap.Lines {
+		if next.Line == line && next.File == file {
+			addresses = append(addresses, next.Address)
+		}
+	}
+	return addresses
+}
+
+//Function to find the first line with code in a file, at or after a line
+//Returns false if there is no code after the line
+func (lineMap *Map) NextLine(file string, line int) (int, bool) {
+	found := false
+	closest := 0
+	for _, next := range lineMap.Lines {
+		if next.File == file && next.Line >= line && (!found || next.Line < closest) {
+			found = true
+			closest = next.Line
+		}
+	}
+	return closest, found
+}
+
+//Function to find the label an address is under, the closest label at or before it
+func (lineMap *Map) Label(address int) (Label, bool) {
+	index := sort.Search(len(lineMap.Labels), func(i int) bool { return lineMap.Labels[i].Address > address })
+	if index == 0 {
+		return Label{}, false
+	}
+	return lineMap.Labels[index-1], true
+}
+
+//Function to write a line map
+//Files are written relative to directory, so the game and its sources can be moved together
+func (lineMap *Map) Write(writer io.Writer, directory string) error {
+	_, err := fmt.Fprintln(writer, "; chipGo line map")
+	if err != nil {
+		return err
+	}
+
+	for _, next := range lineMap.Lines {
+		file := next.File
+		absolute, err := filepath.Abs(file)
+		if err == nil {
+			relative, err := filepath.Rel(directory, absolute)
+			if err == nil {
+				file = filepath.ToSlash(relative)
+			}
+		}
+
+		_, err = fmt.Fprintf(writer, "line 0x%03X %d %s\n", next.Address, next.Line, file)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, next := range lineMap.Labels {
+		_, err = fmt.Fprintf(writer, "label 0x%03X %s\n", next.Address, next.Name)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//Function to write a line map to a file
+func (lineMap *Map) WriteFile(path string) error {
+	directory, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	err = lineMap.Write(file, directory)
+	closeErr := file.Close()
+	if err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+//Function to read a line map
+//Files are made absolute, relative to directory
+func Read(reader io.Reader, directory string) (*Map, error) {
+	lines := make([]Line, 0)
+	labels := make([]Label, 0)
+
+	scanner := bufio.NewScanner(reader)
+	number := 0
+	for scanner.Scan() {
+		number++
+
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, ";") {
+			continue
+		}
+
+		fields := strings.SplitN(text, " ", 4)
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("line map line %d: expected line or label, found %q", number, text)
+		}
+		address, err := strconv.ParseUint(fields[1], 0, 16)
+		if err != nil {
+			return nil, fmt.Errorf("line map line %d: bad address %s", number, fields[1])
+		}
+
+		switch fields[0] {
+		case "line":
+			if len(fields) != 4 {
+				return nil, fmt.Errorf("line map line %d: expected line ADDRESS LINE FILE", number)
+			}
+			line, err := strconv.Atoi(fields[2])
+			if err != nil {
+				return nil, fmt.Errorf("line map line %d: bad line number %s", number, fields[2])
+			}
+
+			file := filepath.FromSlash(fields[3])
+			if !filepath.IsAbs(file) {
+				file = filepath.Join(directory, file)
+			}
+			lines = append(lines, Line{Address: int(address), File: file, Line: line})
+			break
+		case "label":
+			labels = append(labels, Label{Name: strings.Join(fields[2:], " "), Address: int(address)})
+			break
+		default:
+			return nil, fmt.Errorf("line map line %d: expected line or label, found %s", number, fields[0])
+		}
+	}
+	if scanner.Err() != nil {
+		return nil, scanner.Err()
+	}
+
+	return New(lines, labels), nil
+}
+
+//Function to read a line map from a file
+func ReadFile(path string) (*Map, error) {
+	directory, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return Read(file, directory)
+}
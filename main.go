@@ -3,24 +3,26 @@ package main
 /*
    This project will be a CHIP-8 emulator in go, for a basic understanding of laearning how to code an emulator
 
-   Requires Go < 1.6, because graphics library breaks on Go 1.6
+   Requires Go 1.12 or newer, the window's graphics library was written for Go 1.5, see the README
 
    http://www.multigesture.net/articles/how-to-write-an-emulator-chip-8-interpreter/
 */
 
 import (
-	"bufio"
 	cpu "github.com/torch2424/chipGo/cpu"
 	graphics "github.com/torch2424/chipGo/graphics"
 	input "github.com/torch2424/chipGo/input"
+	linemap "github.com/torch2424/chipGo/linemap"
+	rewind "github.com/torch2424/chipGo/rewind"
 	audio "github.com/torch2424/chipGo/sound"
 	"fmt"
 	"gopkg.in/alecthomas/kingpin.v2"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"runtime"
-	"strconv"
 	"strings"
+	"syscall"
 )
 
 //Our CPU
@@ -32,17 +34,80 @@ var video graphics.Video
 //Our Sound
 var sound audio.AudioPlayer
 
-//Number of opcodes to pass in debug mode
-var skipDebug int
-
 //Command Line Parser (Kingpin) Setup
 var (
 	app       = kingpin.New("ChipGo", "A cjip 8 emulator written in Go")
-	gamePath  = kingpin.Arg("game", "Relative filepath to the game you would like to play. e.g: games/BRIX").Required().String()
-	debugMode = kingpin.Flag("debug", "Debug mode. Step through the emulator per opcode, and displays status of cpu, as well as a graphics mapping.").Short('d').Bool()
+	runCmd    = kingpin.Command("run", "Play a game. This is the default command, so chipgo games/BRIX works too").Default()
+	gamePath  = runCmd.Arg("game", "Relative filepath to the game you would like to play. e.g: games/BRIX. Octo source files ending in .8o are compiled and run").Required().String()
+	debugMode = kingpin.Flag("debug", "Debug mode. Starts the game paused in a debugger with breakpoints, watchpoints, and stepping, type help for commands. Also displays a graphics mapping.").Short('d').Bool()
 	gameSpeed = kingpin.Flag("speed", "Clock speed of the game. Increase this to make the game run faster, decrease to make the game run slower. Minimum is 1. Which will execute 1 opcode per second").Default("600").Int()
 	gameScale = kingpin.Flag("scale", "Increase in Scale of the game. Original Chip-8 had a 64x32 display. Scale=10 would make the display 640x320").Default("10").Int()
 	partyMode = kingpin.Flag("party", "Party Mode. Who knew Emulation could get so trippy mayne?").Short('p').Bool()
+	quirks    = kingpin.Flag("quirks", "Quirks preset for opCodes that interpreters disagree on. chipgo for what chipGo has always done, vip for the original Chip-8, chip48, schip, xochip, or auto to pick from --mode. auto picks chipgo for Chip-8 games").Default("auto").Enum("auto", "chipgo", "vip", "chip48", "schip", "xochip")
+	cpuMode   = kingpin.Flag("mode", "Instruction set to emulate. chip8 for the original Chip-8, schip for Super Chip-8 1.1 games with the 128x64 high resolution display, xochip for XO-CHIP games written in Octo").Default("chip8").Enum("chip8", "schip", "xochip")
+
+	//Rendering backend, see the graphics package
+	rendererName = kingpin.Flag("renderer", "Where to draw the game. auto for the best one that works, or one of "+strings.Join(graphics.Backends(), ", ")+". none draws nothing, e.g. for --gdb or --dap on a server").Default("auto").String()
+	filterName   = kingpin.Flag("filter", "How pixels are scaled up to the window. nearest for squares, scale2x and hq2x to smooth diagonal lines, or scanlines to look like a CRT").Default("nearest").Enum("nearest", "scale2x", "hq2x", "scanlines")
+
+	//Screenshots, see screenshot.go
+	screenshotFrame    = kingpin.Flag("screenshot-at-frame", "Save a screenshot next to the game once this many frames have run, e.g. games/BRIX.frame120.png. F12 saves one any time").PlaceHolder("N").Int()
+	screenshotMetadata = kingpin.Flag("screenshot-metadata", "Save the game's SHA-256, the frame, and the program counter in screenshots. Use --no-screenshot-metadata to leave them out").Default("true").Bool()
+
+	//Video recording, see recording.go
+	recordVideo  = kingpin.Flag("record-video", "Record the game from the start, as an animated GIF for .gif, raw video for .y4m, or a PNG for each frame for a pattern like frames/%05d.png. Shift+F12 starts and stops recording any time").PlaceHolder("FILE").String()
+	recordFormat = kingpin.Flag("record-format", "Format for recordings started with Shift+F12. gif, y4m, or png for a PNG for each frame").Default("gif").Enum("gif", "y4m", "png")
+
+	//Random numbers, see random.go
+	randomSeed      = kingpin.Flag("seed", "Seed for the random numbers of CXNN, so a game gets the same numbers every time. A new seed is picked every time you play without one").PlaceHolder("NUMBER").String()
+	randomAlgorithm = kingpin.Flag("random", "How random numbers are made. xorshift, go for the numbers of the PCG generator in Go's math/rand/v2, or vip for the routine of the COSMAC VIP's original Chip-8 interpreter").Default("xorshift").Enum("xorshift", "go", "vip")
+
+	//Debugging with gdb, see gdb.go
+	gdbAddress = kingpin.Flag("gdb", "Wait for gdb, or another GDB remote protocol client, to connect on this address before starting. e.g: --gdb 1234, then target remote localhost:1234 in gdb").PlaceHolder("ADDRESS").String()
+
+	//Debugging in an editor, see dap.go
+	dapAddress = kingpin.Flag("dap", "Debug in an editor with the Debug Adapter Protocol. stdio to talk over stdin and stdout, or wait for the editor to connect on this address. Shows the source from a line map next to the game, e.g. games/pong.ch8.map").PlaceHolder("stdio|ADDRESS").String()
+
+	//Tracing instructions, see trace.go
+	tracePath   = kingpin.Flag("trace", "Write every instruction the game runs to this file, or - for the terminal. Each line has the cycle, address, opCode, instruction, and the registers it changed").PlaceHolder("FILE").String()
+	traceFormat = kingpin.Flag("trace-format", "Format of the trace. text, or jsonl for a JSON object a line").Default("text").Enum("text", "jsonl")
+	traceRanges = kingpin.Flag("trace-range", "Only trace instructions in this range of addresses, e.g. 0x200-0x2FF. Can be given more than once").PlaceHolder("0x200-0x2FF").Strings()
+	traceRing   = kingpin.Flag("trace-ring", "Only keep the last this many instructions, and write them to the trace if the game hits an error").Default("0").Int()
+
+	//Input movies, see movie.go
+	recordMovie = kingpin.Flag("record-movie", "Record the keys of every frame to this movie file, saved when the game ends. Save states and rewinding are off while recording").PlaceHolder("FILE").String()
+	playMovie   = kingpin.Flag("play-movie", "Play back a movie file, exactly as it was recorded, with the seed, quirks, and speed it was recorded with").PlaceHolder("FILE").String()
+	tasPath     = kingpin.Flag("tas", "Edit a tool-assisted run one frame at a time, saved to this movie file. Carries on from the end of the movie if it exists").PlaceHolder("FILE").String()
+
+	//Rewinding, see rewind.go
+	rewindSecs = kingpin.Flag("rewind-seconds", "How many seconds of gameplay to keep for rewinding, hold Backspace to rewind. 0 turns rewinding off").Default("10").Float64()
+
+	//Disassembler, see disasm.go
+	disasmCmd     = kingpin.Command("disasm", "Disassemble a game into a listing of its instructions. e.g: chipgo disasm games/BRIX")
+	disasmPath    = disasmCmd.Arg("game", "Relative filepath to the game you would like to disassemble").Required().String()
+	disasmSyntax  = disasmCmd.Flag("syntax", "Assembly syntax to write. octo, or cowgod for Cowgod's Chip-8 technical reference mnemonics").Default("octo").Enum("octo", "cowgod")
+	disasmNoAddrs = disasmCmd.Flag("no-addresses", "Don't comment each line with its address and opCode").Bool()
+
+	//Assembler, see asm.go
+	asmCmd     = kingpin.Command("asm", "Assemble a source file into a game. e.g: chipgo asm pong.asm -o pong.ch8")
+	asmPath    = asmCmd.Arg("source", "Relative filepath to the source you would like to assemble").Required().String()
+	asmOutput  = asmCmd.Flag("output", "Where to write the game. Defaults to the source file with a .ch8 extension").Short('o').String()
+	asmListing = asmCmd.Flag("listing", "Also write a listing of each line's address and bytes to this file").String()
+	asmSymbols = asmCmd.Flag("symbols", "Also write every label and constant, and its value, to this file").String()
+	asmMap     = asmCmd.Flag("map", "Also write a line map next to the game, e.g. pong.ch8.map, so --dap can show the source while debugging").Bool()
+
+	//Trace comparison, see tracediff.go
+	diffCmd       = kingpin.Command("tracediff", "Run a game without a window, and report the first instruction that differs from a reference trace. Random numbers start the same as in a text trace chipGo wrote, other traces need --seed and --random. e.g: chipgo tracediff games/BRIX brix.trace --input brix.keys")
+	diffPath      = diffCmd.Arg("game", "Relative filepath to the game you would like to run").Required().String()
+	diffReference = diffCmd.Arg("reference", "Trace to compare against, in either --trace-format. Only the cycles in it are compared").Required().String()
+	diffInput     = diffCmd.Flag("input", "Input script of the keys to hold, one frame a line, e.g. 30 5 6 holds keys 5 and 6 from frame 30 on").PlaceHolder("SCRIPT").String()
+
+	//Conformance tests, see conformance.go
+	testCmd      = kingpin.Command("test", "Run the conformance tests without a window, and compare each display to the one we expect. e.g: chipgo test")
+	testManifest = testCmd.Arg("manifest", "Manifest of the tests to run, ROMs in it are relative to the manifest").Default("conformance/testdata/manifest").String()
+	testRun      = testCmd.Flag("run", "Only run tests with this in their name").String()
+	testUpdate   = testCmd.Flag("update", "Write the display of every test that runs into the manifest, as the one we expect").Bool()
+	testVerbose  = testCmd.Flag("verbose", "Draw the display of tests that fail").Short('v').Bool()
 )
 
 func main() {
@@ -51,12 +116,32 @@ func main() {
 	// See documentation for functions that are only allowed to be called from the main thread.
 	runtime.LockOSThread()
 
+	//Parse our input, and run the command
+	switch kingpin.Parse() {
+	case disasmCmd.FullCommand():
+		runDisasm()
+	case asmCmd.FullCommand():
+		runAsm()
+	case diffCmd.FullCommand():
+		runTraceDiff()
+	case testCmd.FullCommand():
+		runConformance()
+	default:
+		runGame()
+	}
+}
+
+//Function to play a game
+func runGame() {
+
+	//With --dap stdio, stdout belongs to the editor, so print to stderr instead, see dap.go
+	if *dapAddress == "stdio" {
+		os.Stdout = os.Stderr
+	}
+
 	//Print our banner
 	printBanner()
 
-	//Parse our input
-	kingpin.Parse()
-
 	//Check if our input file exists
 	_, err := os.Stat(*gamePath)
 	if err != nil {
@@ -69,86 +154,223 @@ func main() {
 	//Inform user we are starting!
 	print("Starting chipGo!\n")
 
-	//Test our graphics
-	video := graphics.NewVideo(*gameScale, *debugMode, *partyMode)
+	//Only one debugger can run the game, see debug.go
+	if countDebuggers() > 1 {
+		fmt.Println("Use only one of --debug, --gdb, or --dap")
+		os.Exit(1)
+	}
+	filter, err := graphics.ParseFilter(*filterName)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
 
 	//Start our sound
 	sound := audio.NewAudioPlayer(*debugMode)
 
-	//Set our input handler
-	video.Window.SetKeyCallback(input.KeyCallback)
+	//Find the instruction set we are emulating
+	mode, err := cpu.ParseMode(*cpuMode)
+	if err != nil {
+		panic(err)
+	}
+
+	//Initialize our CPU. Input is passed to the cpu by our emulator below
+	//The debugger shows the cpu state when asked, so the cpu doesn't print it every opCode
+	chipCpu := cpu.NewCpu("chipCpu", *gameSpeed, mode)
+
+	//Set the quirks for our opCodes
+	chipCpu.Quirks, err = cpu.ParseQuirks(*quirks, mode)
+	if err != nil {
+		panic(err)
+	}
 
-	//Initialize our CPU. Input is handled by opcode.go in cpu package
-	chipCpu := cpu.NewCpu("chipCpu", *gameSpeed, *debugMode)
+	//Seed our random numbers, see random.go
+	var seed uint64
+	chipCpu.Random, seed = newRandom(false)
 	print("Cpu initialized...\n")
 
 	//Load the game
+	//Octo source is compiled first, see octo.go
+	//Octo source has its own line map, other games can have one next to them, see dap.go
+	var lineMap *linemap.Map
 	loadGame, _ := filepath.Abs(*gamePath)
-	chipCpu = cpu.LoadGame(loadGame, chipCpu)
+	if strings.ToLower(filepath.Ext(loadGame)) == ".8o" {
+		chipCpu, lineMap, err = loadOcto(loadGame, chipCpu)
+	} else {
+		chipCpu, err = cpu.LoadGame(loadGame, chipCpu)
+		lineMap = loadLineMap(loadGame)
+	}
+	if err != nil {
+		print("Failed loading game...\n\n")
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	print("Game loaded!\n\n")
+
+	//Record or play a movie, playing sets up the cpu the way it was recorded, see movie.go
+	chipCpu, frameKeys, frameInstructions := startMovie(chipCpu, loadGame, seed, input.Keyboard{})
+	defer finishMovie(frameKeys)
+
+	//Wire our cpu to our keyboard, or movie, and speakers
+	//The window is opened last, as starting anything else can exit, see below
+	var keys cpu.KeySource = input.Keyboard{}
+	if frameKeys != nil {
+		keys = frameKeys
+	}
+	emulator := cpu.NewEmulator(chipCpu, keys, nil, sound)
+
+	//Record a video from the start, see recording.go
+	//From here on we return instead of exiting, so the movie and anything else we started is finished
+	if *recordVideo != "" {
+		startRecording(*recordVideo, emulator)
+		if currentRecording == nil {
+			return
+		}
+	}
+	defer stopRecording()
+
+	//Trace the instructions we run, see trace.go
+	finishTrace, err := startTrace(emulator)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer finishTrace()
+
+	//Keep our frames for rewinding, see rewind.go
+	var rewindBuffer *rewind.Buffer
+	if *rewindSecs > 0 && frameKeys == nil {
+		rewindBuffer = rewind.NewBuffer(rewind.FramesForSeconds(*rewindSecs))
+	}
 
-	//Set skip debug checks
-	skipDebug = 0
+	//Pause in our debugger, or wait for gdb or an editor, see debug.go, gdb.go, and dap.go
+	chipDebugger := newDebugger(emulator)
+	gdbServer, err := newGdbServer(emulator)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if gdbServer != nil {
+		defer gdbServer.Close()
+	}
+	dapServer, err := newDapServer(emulator, lineMap)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if dapServer != nil {
+		defer dapServer.Close()
+	}
+	runner := newInstructionRunner(emulator, chipDebugger, gdbServer, dapServer)
+
+	//Open our window, or whatever our rendering backend draws on
+	//Nothing exits from here on, so the window is always closed
+	renderer, err := graphics.Open(*rendererName, graphics.Options{Scale: *gameScale, Filter: filter, RandomColor: *partyMode, TerminalBusy: *debugMode || *dapAddress == "stdio"})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer renderer.Close()
+	emulator.Display = graphics.NewVideo(renderer, *debugMode)
+	if frameKeys != nil {
+		//Draw where the movie left off, e.g. after catching up to the end of a run we are editing
+		emulator.Redraw()
+	}
+
+	//Stop the game on Ctrl + C or kill, instead of exiting, so the movie and video we are recording are saved
+	//e.g. with --renderer none, there is no window to close
+	stopSignal := make(chan os.Signal, 1)
+	signal.Notify(stopSignal, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(stopSignal)
+
+	//Run the game while the video is open, and the game has not exited
+	for renderer.IsOpen() && !emulator.Cpu.Exit {
 
-	//Run the game while the video is open
-	for graphics.IsOpen(video) {
+		//Poll for events, and send the keys to our input handler
+		input.HandleEvents(renderer.PollEvents())
 
-		//Poll for events
-		graphics.PollEvents()
+		//Save and load states, see state.go, and take screenshots and videos, see screenshot.go and recording.go
+		//Then pause in the debugger, gdb, or the editor, see debug.go
+		//Or edit a tool-assisted run, see tas.go
+		hotkeys := input.GetHotkeys()
+		if frameKeys == nil {
+			handleStateHotkeys(emulator, hotkeys, loadGame, rewindBuffer)
+		}
+		handleTasHotkeys(emulator, frameKeys, hotkeys)
+		handleScreenshotHotkeys(emulator, hotkeys, loadGame)
+		handleRecordingHotkeys(emulator, hotkeys, loadGame)
+		handleDebugHotkeys(chipDebugger, gdbServer, dapServer, hotkeys)
+
+		//The game is paused while we rewind
+		rewinding := isRewinding(rewindBuffer)
 
 		//Use the Cpu Clock to see if we should run an instruction
 		//Check for if our cpu clock timer has ticked
+		//Our delay and sound timers have their own 60hz clock, so they don't change with the game speed
 		select {
-		case <-chipCpu.Clock.C:
-
-			//Timer ticked
-			//Run the instruction
-			chipCpu = cpu.EmulateCycle(chipCpu)
-
-			//Render our display
-			//using go function to call in other thread using goRoutines
-			if chipCpu.ShouldRender {
-				graphics.Render(video, chipCpu.GraphicsDisplay)
-			}
-			if chipCpu.ClearScreen {
-				graphics.Clear(video)
-				chipCpu = cpu.ClearGraphics(chipCpu)
+		case <-emulator.Cpu.Clock.C:
+			//Movies run a frame at a time on our timer clock instead
+			if rewinding || frameKeys != nil {
+				break
 			}
-			chipCpu.ShouldRender = false
-			chipCpu.ClearScreen = false
 
-			//Play any sounds
-			if cpu.ShouldPlaySound(chipCpu) {
-				audio.PlayBlip(sound)
+			//Timer ticked
+			//Run the instruction, the emulator will render our display
+			//Stop the game if the cpu hit an error, so we can report it instead of crashing
+			err = runner.Step()
+			if err != nil {
+				fmt.Println(err)
+				return
 			}
 
 			//Exit the case
 			break
-		}
+		case <-emulator.Cpu.TimerClock.C:
 
-		//If debug mode wait for user input to continue
-		if *debugMode {
-			if skipDebug < 1 {
-				reader := bufio.NewReader(os.Stdin)
-				fmt.Print("Debug Mode On. Enter a number of opcodes to execute before pausing. Or, Press enter to continue...\n")
-				text, _ := reader.ReadString('\n')
-
-				//Remove newline from text
-				text = strings.Replace(text, "\n", "", -1)
+			//Timer ticked
+			//While rewinding, go back a frame instead
+			if rewinding {
+				rewindFrame(emulator, rewindBuffer)
+				break
+			}
 
-				//Try to parse input to set debug check
-				parseResult, err := strconv.Atoi(text)
+			//Movies run the whole frame now, with the keys for it
+			//Once a movie finishes playing, the keyboard takes over
+			if frameKeys != nil {
+				if isTasPaused(frameKeys) {
+					break
+				}
+				var playing bool
+				playing, err = runMovieFrame(emulator, runner, frameKeys, frameInstructions)
 				if err != nil {
-					print("\n\nDid not find an int, continuing debug stepping...\n\n")
-				} else {
-					skipDebug = int(parseResult)
+					fmt.Println(err)
+					return
 				}
-
-				fmt.Print("\n")
-				print("\n\n\n")
-			} else {
-				skipDebug--
+				if !playing {
+					print("Movie finished, the keyboard has control again...\n")
+					emulator.Keys = input.Keyboard{}
+					frameKeys = nil
+				}
+				break
 			}
+
+			//Count down our delay and sound timers, and play any sounds
+			//Then save this frame, so we can rewind to it
+			emulator.TickTimers()
+			saveRewindFrame(emulator, rewindBuffer)
+
+			//Exit the case
+			break
+		case <-stopSignal:
+			print("Stopping chipGo...\n")
+			return
 		}
+
+		//Save a screenshot once we reach --screenshot-at-frame, see screenshot.go
+		checkScreenshotFrame(emulator, loadGame)
+
+		//Record the frames we ran, see recording.go
+		recordFrames(emulator)
 	}
 }
 
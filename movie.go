@@ -0,0 +1,119 @@
+package main
+
+//This is helper class for input movies, see the movie package
+//With --record-movie the keys of every frame are saved when the game ends, and --play-movie plays them back
+//Movies run a whole frame at a time, locked to our timers, so they play back exactly the same way
+//Save states and rewinding would change what happened, so they are off while recording or playing
+//Editing a tool-assisted run with --tas records a movie too, see tas.go
+
+import (
+	cpu "github.com/torch2424/chipGo/cpu"
+	movie "github.com/torch2424/chipGo/movie"
+	tas "github.com/torch2424/chipGo/tas"
+	"fmt"
+	"io/ioutil"
+	"os"
+)
+
+//The keys for each frame of a movie, a movie.Recorder, movie.Player, or tas.Editor
+type movieKeys interface {
+	cpu.KeySource
+	NextFrame() bool
+}
+
+//Function to record, play, or edit a movie, if asked to
+//Playing sets up the cpu the way the movie was recorded
+//Returns the cpu, the keys for each frame or nil without a movie, and how many instructions to run each frame
+func startMovie(chipCpu cpu.Cpu, gamePath string, seed uint64, keyboard cpu.KeySource) (cpu.Cpu, movieKeys, int) {
+	movieFlags := 0
+	for _, path := range []string{*recordMovie, *playMovie, *tasPath} {
+		if path != "" {
+			movieFlags++
+		}
+	}
+	if movieFlags == 0 {
+		return chipCpu, nil, cpu.InstructionsPerFrame(*gameSpeed)
+	}
+	if movieFlags > 1 {
+		fmt.Println("Use only one of --record-movie, --play-movie, or --tas")
+		os.Exit(1)
+	}
+
+	game, err := ioutil.ReadFile(gamePath)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	//Record with the settings we are running with
+	settings := movie.Settings{
+		RomHash: movie.HashRom(game),
+		Seed:    seed,
+		Random:  uint8(chipCpu.Random.Algorithm),
+		Mode:    uint8(cpu.GetMode(chipCpu)),
+		Quirks:  cpu.PackQuirks(chipCpu.Quirks),
+		Speed:   uint32(*gameSpeed),
+	}
+	if *tasPath != "" {
+		chipCpu, editor := startTas(chipCpu, game, settings)
+		return chipCpu, editor, cpu.InstructionsPerFrame(int(editor.Movie().Settings.Speed))
+	}
+	if *recordMovie != "" {
+		print("Recording movie to ", *recordMovie, ", it is saved when the game ends...\n")
+		return chipCpu, movie.NewRecorder(movie.New(settings), keyboard), cpu.InstructionsPerFrame(*gameSpeed)
+	}
+
+	//Play with the settings the movie was recorded with
+	played, err := movie.ReadFile(*playMovie)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	err = played.Settings.Check(game, cpu.GetMode(chipCpu))
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	print("Playing movie ", *playMovie, ", ", len(played.Frames), " frames...\n")
+
+	return played.Settings.Apply(chipCpu), movie.NewPlayer(played), cpu.InstructionsPerFrame(int(played.Settings.Speed))
+}
+
+//Function to run one frame of a movie, with the keys held for it
+//Returns false once the movie has finished playing
+func runMovieFrame(emulator *cpu.Emulator, runner instructionRunner, keys movieKeys, instructions int) (bool, error) {
+	if !keys.NextFrame() {
+		return false, nil
+	}
+
+	for i := 0; i < instructions && !emulator.Cpu.Exit; i++ {
+		err := runner.Step()
+		if err != nil {
+			return true, err
+		}
+	}
+	emulator.TickTimers()
+
+	return true, nil
+}
+
+//Function to save the movie we recorded or edited, once the game ends
+func finishMovie(keys movieKeys) {
+	editor, isEditing := keys.(*tas.Editor)
+	if isEditing {
+		saveTas(editor)
+		return
+	}
+
+	recorder, isRecording := keys.(*movie.Recorder)
+	if !isRecording {
+		return
+	}
+
+	err := recorder.Movie().WriteFile(*recordMovie)
+	if err != nil {
+		fmt.Println("Failed saving movie:", err)
+		return
+	}
+	print("Saved ", len(recorder.Movie().Frames), " frames of movie to ", *recordMovie, "\n")
+}
@@ -0,0 +1,80 @@
+package movie
+
+/*
+   Input movies for Chip-8, SCHIP, and XO-CHIP games
+
+   The keys held on every frame of a game, so it can be played back exactly the same way later
+*/
+
+//This is helper class for recording and playing movies, as the keys an emulator reads
+//Call NextFrame at the start of every frame, the keys then stay the same for the whole frame
+
+//Imports
+import (
+	cpu "github.com/torch2424/chipGo/cpu"
+)
+
+//Recorder records the keys of another key source, e.g. the keyboard, into a movie
+type Recorder struct {
+	movie   *Movie
+	source  cpu.KeySource
+	current [16]bool
+}
+
+//Function to make a recorder, adding frames to the end of a movie
+func NewRecorder(movie *Movie, source cpu.KeySource) *Recorder {
+	return &Recorder{movie: movie, source: source}
+}
+
+//Function to start the next frame, reading and recording the keys for it
+//Always returns true, recording doesn't end until we stop it
+func (recorder *Recorder) NextFrame() bool {
+	recorder.current, _ = recorder.source.GetKeyArray()
+	recorder.movie.Frames = append(recorder.movie.Frames, PackKeys(recorder.current))
+	return true
+}
+
+//Function to get the keys of this frame, see cpu.KeySource
+func (recorder *Recorder) GetKeyArray() ([16]bool, bool) {
+	return recorder.current, PackKeys(recorder.current) != 0
+}
+
+//Function to get the movie we are recording
+func (recorder *Recorder) Movie() *Movie {
+	return recorder.movie
+}
+
+//Player plays back the keys of a movie
+type Player struct {
+	movie   *Movie
+	frame   int
+	current [16]bool
+}
+
+//Function to make a player, starting at the first frame of a movie
+func NewPlayer(movie *Movie) *Player {
+	return &Player{movie: movie}
+}
+
+//Function to start the next frame, with its keys from the movie
+//Returns false once the movie has ended, and lets go of every key
+func (player *Player) NextFrame() bool {
+	if player.frame >= len(player.movie.Frames) {
+		player.current = [16]bool{}
+		return false
+	}
+
+	player.current = UnpackKeys(player.movie.Frames[player.frame])
+	player.frame++
+	return true
+}
+
+//Function to get the keys of this frame, see cpu.KeySource
+func (player *Player) GetKeyArray() ([16]bool, bool) {
+	return player.current, PackKeys(player.current) != 0
+}
+
+//Function to find how many frames have played
+func (player *Player) Frame() int {
+	return player.frame
+}
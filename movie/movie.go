@@ -0,0 +1,190 @@
+package movie
+
+/*
+   Input movies for Chip-8, SCHIP, and XO-CHIP games
+
+   The keys held on every frame of a game, so it can be played back exactly the same way later
+*/
+
+//Movies are a small header, then the settings the game was recorded with, then the keys of each frame
+//Each frame is 16 bits, bit 0 for key 0, and games run locked to frames while recording and playing
+//Everything is big endian, written with encoding/binary, like save states
+
+//Imports
+import (
+	cpu "github.com/torch2424/chipGo/cpu"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+//Every movie starts with this, so we don't play some other file
+var movieMagic = [4]byte{'C', 'G', 'M', 'V'}
+
+//Version of our movie layout, increase this when the layout changes
+const Version uint16 = 1
+
+//Header at the start of every movie
+type movieHeader struct {
+	Magic   [4]byte
+	Version uint16
+}
+
+//Settings are how the game was running when the movie was recorded
+//A movie only plays back the same way with the same settings
+type Settings struct {
+
+	//SHA-256 of the game file
+	RomHash [32]byte
+
+	//Seed and algorithm for our random numbers, see cpu/random.go
+	Seed   uint64
+	Random uint8
+
+	//Instruction set, and quirks packed with cpu.PackQuirks
+	Mode   uint8
+	Quirks uint8
+
+	//Instructions a second, see --speed
+	Speed uint32
+}
+
+//Movie is the settings of a game, and the keys held on each of its frames
+type Movie struct {
+	Settings Settings
+	Frames   []uint16
+}
+
+//Function to make a new, empty movie
+func New(settings Settings) *Movie {
+	return &Movie{Settings: settings, Frames: make([]uint16, 0)}
+}
+
+//Function to hash a game, for the settings of a movie
+func HashRom(game []byte) [32]byte {
+	return sha256.Sum256(game)
+}
+
+//Function to pack the keys of a frame into 16 bits
+func PackKeys(keys [16]bool) uint16 {
+	var packed uint16
+	for key, pressed := range keys {
+		if pressed {
+			packed = packed | 1<<uint(key)
+		}
+	}
+	return packed
+}
+
+//Function to unpack the keys of a frame
+func UnpackKeys(packed uint16) [16]bool {
+	var keys [16]bool
+	for key := range keys {
+		keys[key] = packed&(1<<uint(key)) != 0
+	}
+	return keys
+}
+
+//Function to write a movie
+func (movie *Movie) Write(writer io.Writer) error {
+	err := binary.Write(writer, binary.BigEndian, movieHeader{Magic: movieMagic, Version: Version})
+	if err != nil {
+		return err
+	}
+	err = binary.Write(writer, binary.BigEndian, &movie.Settings)
+	if err != nil {
+		return err
+	}
+	err = binary.Write(writer, binary.BigEndian, uint32(len(movie.Frames)))
+	if err != nil {
+		return err
+	}
+
+	return binary.Write(writer, binary.BigEndian, movie.Frames)
+}
+
+//Function to write a movie to a file
+func (movie *Movie) WriteFile(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	err = movie.Write(file)
+	closeErr := file.Close()
+	if err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+//Function to read a movie
+func Read(reader io.Reader) (*Movie, error) {
+	var header movieHeader
+	err := binary.Read(reader, binary.BigEndian, &header)
+	if err != nil || header.Magic != movieMagic {
+		return nil, fmt.Errorf("Not a chipGo movie")
+	}
+	if header.Version != Version {
+		return nil, fmt.Errorf("Movie is version %d, and we can only play version %d", header.Version, Version)
+	}
+
+	movie := &Movie{}
+	err = binary.Read(reader, binary.BigEndian, &movie.Settings)
+	if err != nil {
+		return nil, fmt.Errorf("Movie is too short")
+	}
+
+	var count uint32
+	err = binary.Read(reader, binary.BigEndian, &count)
+	if err != nil {
+		return nil, fmt.Errorf("Movie is too short")
+	}
+
+	//Check the frames are all there before making room for them, a broken count could ask for gigabytes
+	frames, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(frames)) < uint64(count)*2 {
+		return nil, fmt.Errorf("Movie is too short, expected %d frames", count)
+	}
+	movie.Frames = make([]uint16, count)
+	for i := range movie.Frames {
+		movie.Frames[i] = binary.BigEndian.Uint16(frames[i*2:])
+	}
+
+	return movie, nil
+}
+
+//Function to read a movie from a file
+func ReadFile(path string) (*Movie, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return Read(file)
+}
+
+//Function to check a game can play a movie, returns why not if it can't
+func (settings Settings) Check(game []byte, mode cpu.Mode) error {
+	if HashRom(game) != settings.RomHash {
+		return fmt.Errorf("Movie was recorded with a different game")
+	}
+	if cpu.Mode(settings.Mode) != mode {
+		return fmt.Errorf("Movie was recorded with --mode %s", cpu.Mode(settings.Mode))
+	}
+	return nil
+}
+
+//Function to set up a cpu the way the movie was recorded, with its quirks and random numbers
+func (settings Settings) Apply(chipCpu cpu.Cpu) cpu.Cpu {
+	chipCpu.Quirks = cpu.UnpackQuirks(settings.Quirks)
+	chipCpu.Random = cpu.NewRandom(cpu.RandomAlgorithm(settings.Random), settings.Seed)
+	return chipCpu
+}
@@ -0,0 +1,24 @@
+package main
+
+//This is helper class for running Octo source, see the octo package
+
+import (
+	cpu "github.com/torch2424/chipGo/cpu"
+	linemap "github.com/torch2424/chipGo/linemap"
+	octo "github.com/torch2424/chipGo/octo"
+)
+
+//Function to compile an Octo source file, and start it like any other game
+//Also returns the line map of the source, for debugging in an editor
+func loadOcto(path string, chipCpu cpu.Cpu) (cpu.Cpu, *linemap.Map, error) {
+
+	program, err := octo.CompileFile(path)
+	if err != nil {
+		print("Failed compiling game...\n\n")
+		return chipCpu, nil, err
+	}
+	print("Compiled ", len(program.Binary), " bytes of Octo...\n")
+
+	chipCpu, err = cpu.StartGame(chipCpu, program.Binary)
+	return chipCpu, program.LineMap, err
+}
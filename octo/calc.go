@@ -0,0 +1,176 @@
+package octo
+
+/*
+   Compiler for Octo (https://github.com/JohnEarnest/Octo), the high level assembler
+   most modern Chip-8, SCHIP, and XO-CHIP games are written in
+*/
+
+//This is helper class for { } calc expressions, used by :calc, :byte, :org, and :assert
+//Like Octo, there is no operator precedence, expressions are worked out right to left
+//e.g. { 2 * 3 + 1 } is 2 * ( 3 + 1 )
+
+//Imports
+import (
+	"fmt"
+	"math"
+)
+
+//Operators with two sides
+var binaryOperators = map[string]func(left float64, right float64) float64{
+	"-":   func(left float64, right float64) float64 { return left - right },
+	"+":   func(left float64, right float64) float64 { return left + right },
+	"*":   func(left float64, right float64) float64 { return left * right },
+	"/":   func(left float64, right float64) float64 { return left / right },
+	"%":   func(left float64, right float64) float64 { return float64(int(left) % nonZero(int(right))) },
+	"&":   func(left float64, right float64) float64 { return float64(int(left) & int(right)) },
+	"|":   func(left float64, right float64) float64 { return float64(int(left) | int(right)) },
+	"^":   func(left float64, right float64) float64 { return float64(int(left) ^ int(right)) },
+	"<<":  func(left float64, right float64) float64 { return float64(int(left) << uint(int(right)&31)) },
+	">>":  func(left float64, right float64) float64 { return float64(int(left) >> uint(int(right)&31)) },
+	"pow": math.Pow,
+	"min": math.Min,
+	"max": math.Max,
+	"<":   func(left float64, right float64) float64 { return truth(left < right) },
+	"<=":  func(left float64, right float64) float64 { return truth(left <= right) },
+	">":   func(left float64, right float64) float64 { return truth(left > right) },
+	">=":  func(left float64, right float64) float64 { return truth(left >= right) },
+	"==":  func(left float64, right float64) float64 { return truth(left == right) },
+	"!=":  func(left float64, right float64) float64 { return truth(left != right) },
+}
+
+//Operators with one side
+var unaryOperators = map[string]func(value float64) float64{
+	"-":     func(value float64) float64 { return -value },
+	"~":     func(value float64) float64 { return float64(^int(value)) },
+	"!":     func(value float64) float64 { return truth(value == 0) },
+	"sin":   math.Sin,
+	"cos":   math.Cos,
+	"tan":   math.Tan,
+	"exp":   math.Exp,
+	"log":   math.Log,
+	"abs":   math.Abs,
+	"sqrt":  math.Sqrt,
+	"sign":  sign,
+	"ceil":  math.Ceil,
+	"floor": math.Floor,
+}
+
+//Function to work out a calc expression, after the {
+func (compiler *compiler) calc(open token) float64 {
+	value := compiler.calcExpression()
+	if compiler.err == nil && compiler.peek() != "}" {
+		compiler.fail(compiler.next(), "expected }")
+	}
+	compiler.next()
+	if compiler.err != nil {
+		return 0
+	}
+
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		compiler.fail(open, "calc is not a number")
+	}
+	return value
+}
+
+//Function to read a value, and any operators after it
+func (compiler *compiler) calcExpression() float64 {
+	left := compiler.calcTerm()
+
+	operator, isBinary := binaryOperators[compiler.peek()]
+	if !isBinary || compiler.err != nil {
+		return left
+	}
+	compiler.next()
+
+	return operator(left, compiler.calcExpression())
+}
+
+//Function to read a single value, with any unary operators in front of it
+func (compiler *compiler) calcTerm() float64 {
+	next := compiler.next()
+	if compiler.err != nil {
+		return 0
+	}
+
+	if next.quoted {
+		compiler.fail(next, fmt.Sprintf("unexpected string %q", next.text))
+		return 0
+	}
+	if next.text == "}" || next.text == ")" {
+		compiler.fail(next, "missing value")
+		return 0
+	}
+
+	operator, isUnary := unaryOperators[next.text]
+	if isUnary {
+		return operator(compiler.calcTerm())
+	}
+
+	switch next.text {
+	case "(":
+		value := compiler.calcExpression()
+		compiler.expect(")")
+		return value
+	case "@":
+		//The byte we wrote at an address
+		return float64(compiler.readAt(int(compiler.calcTerm())))
+	case "strlen":
+		text := compiler.next()
+		return float64(len(text.text))
+	case "HERE":
+		return float64(compiler.here)
+	case "PI":
+		return math.Pi
+	case "E":
+		return math.E
+	}
+
+	number, isNumber := parseNumber(next.text)
+	if isNumber {
+		return float64(number)
+	}
+	constant, isConstant := compiler.constants[next.text]
+	if isConstant {
+		return constant
+	}
+	label, isLabel := compiler.labels[next.text]
+	if isLabel {
+		return float64(label)
+	}
+	register, isRegister := compiler.register(next)
+	if isRegister {
+		return float64(register)
+	}
+
+	compiler.fail(next, fmt.Sprintf("undefined name %s, calc can only use names defined before it", next.text))
+	return 0
+}
+
+func truth(value bool) float64 {
+	if value {
+		return 1
+	}
+	return 0
+}
+
+func sign(value float64) float64 {
+	if value < 0 {
+		return -1
+	} else if value > 0 {
+		return 1
+	}
+	return 0
+}
+
+//Function to avoid dividing by zero, % by zero is zero
+func nonZero(value int) int {
+	if value == 0 {
+		return 1
+	}
+	return value
+}
+
+//Function to round a calc value down to a whole number
+func floor(value float64) float64 {
+	return math.Floor(value)
+}
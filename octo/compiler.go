@@ -0,0 +1,651 @@
+package octo
+
+/*
+   Compiler for Octo (https://github.com/JohnEarnest/Octo), the high level assembler
+   most modern Chip-8, SCHIP, and XO-CHIP games are written in
+*/
+
+//This is helper class for our compiler state, labels, and the : directives
+
+//Imports
+import (
+	linemap "github.com/torch2424/chipGo/linemap"
+	"fmt"
+	"strconv"
+)
+
+//The last address an XO-CHIP game can use
+const maxAddress = 0xFFFF
+
+//Stop expanding macros after this many, so a macro using itself doesn't run forever
+const maxExpansions = 100000
+
+//Kinds of places we write an address before we know it
+type patchKind int
+
+const (
+	//The low 12 bits of an opCode, e.g. jump or i :=
+	patchAddress patchKind = iota
+	//A 16 bit address after i := long
+	patchLong
+	//The two v0 and v1 loads of :unpack, with the high nibble to add
+	patchUnpack
+	patchUnpackLong
+)
+
+//A place to write the address of a label, once it is defined
+type patch struct {
+	kind    patchKind
+	address int
+	nibble  int
+	token   token
+}
+
+//A macro, and the tokens it expands to
+type macro struct {
+	parameters []token
+	body       []token
+	calls      int
+}
+
+//A string mode, a macro run for each character of a string
+type stringMode struct {
+	alphabet string
+	body     []token
+	calls    int
+}
+
+//A loop, where it starts, and the whiles to jump out of it
+type loopFrame struct {
+	address int
+	whiles  []int
+	token   token
+}
+
+//Our compiler state
+type compiler struct {
+	fileName string
+
+	//Tokens we still have to read, macros add their bodies here
+	tokens []token
+	index  int
+	last   token
+
+	//Our game, starting at 0x200, and where we are writing
+	memory []byte
+	here   int
+
+	//True while the jump to main at 0x200 is still reserved
+	jumpToMain bool
+
+	labels      map[string]int
+	constants   map[string]float64
+	aliases     map[string]int
+	macros      map[string]*macro
+	stringModes map[string][]*stringMode
+	breakpoints map[int]string
+
+	//Addresses waiting on labels that are not defined yet
+	patches map[string][]patch
+
+	//Open loops, and open if ... begin blocks, innermost last
+	loops    []loopFrame
+	branches []token
+	jumps    []int
+
+	expansions int
+
+	//Which line each run of bytes came from, and the address after the last one
+	lines   []linemap.Line
+	lineEnd int
+
+	//The first error we hit
+	err *Error
+}
+
+func newCompiler(fileName string, tokens []token) *compiler {
+	return &compiler{
+		fileName:    fileName,
+		tokens:      tokens,
+		memory:      make([]byte, 0),
+		here:        romStart,
+		labels:      make(map[string]int),
+		constants:   make(map[string]float64),
+		aliases:     make(map[string]int),
+		macros:      make(map[string]*macro),
+		stringModes: make(map[string][]*stringMode),
+		breakpoints: make(map[int]string),
+		patches:     make(map[string][]patch),
+	}
+}
+
+//Function to compile every token
+func (compiler *compiler) compile() {
+
+	//Games start at 0x200, so we save room to jump to main
+	compiler.jumpToMain = true
+	compiler.instruction(0x1000)
+
+	for compiler.err == nil && compiler.index < len(compiler.tokens) {
+		compiler.statement()
+	}
+	if compiler.err != nil {
+		return
+	}
+
+	//Check everything we started was finished
+	if len(compiler.loops) > 0 {
+		compiler.fail(compiler.loops[len(compiler.loops)-1].token, "loop without again")
+		return
+	}
+	if len(compiler.branches) > 0 {
+		compiler.fail(compiler.branches[len(compiler.branches)-1], "begin without end")
+		return
+	}
+	for name, waiting := range compiler.patches {
+		compiler.fail(waiting[0].token, fmt.Sprintf("undefined name %s", name))
+		return
+	}
+
+	main, hasMain := compiler.labels["main"]
+	if !hasMain {
+		compiler.err = &Error{File: compiler.fileName, Line: 1, Column: 1, Message: "this program is missing a main label"}
+		return
+	}
+	if compiler.jumpToMain {
+		compiler.writeAt(romStart, byte(0x10|main>>8), byte(main))
+	}
+}
+
+//Function to record an error, only the first one is kept
+func (compiler *compiler) fail(at token, message string) {
+	if compiler.err == nil {
+		compiler.err = &Error{File: compiler.fileName, Line: at.line, Column: at.column, Message: message}
+	}
+}
+
+//Function to read the next token
+func (compiler *compiler) next() token {
+	if compiler.index >= len(compiler.tokens) {
+		last := token{line: 1, column: 1}
+		if len(compiler.tokens) > 0 {
+			last = compiler.tokens[len(compiler.tokens)-1]
+		}
+		compiler.fail(last, "unexpected end of file")
+		return token{line: last.line, column: last.column}
+	}
+
+	next := compiler.tokens[compiler.index]
+	compiler.index++
+	compiler.last = next
+	return next
+}
+
+//Function to look at the next token, without reading it
+func (compiler *compiler) peek() string {
+	if compiler.index >= len(compiler.tokens) {
+		return ""
+	}
+	return compiler.tokens[compiler.index].text
+}
+
+//Function to read a token we expect, e.g. then
+func (compiler *compiler) expect(text string) token {
+	next := compiler.next()
+	if next.text != text || next.quoted {
+		compiler.fail(next, fmt.Sprintf("expected %s, found %q", text, next.text))
+	}
+	return next
+}
+
+//Function to read a name we can define, that isn't a number or register
+func (compiler *compiler) name() token {
+	next := compiler.next()
+	_, isNumber := parseNumber(next.text)
+	_, isRegister := parseRegister(next.text)
+	if next.quoted || isNumber || isRegister || next.text == "" {
+		compiler.fail(next, fmt.Sprintf("%q can't be used as a name", next.text))
+	}
+	return next
+}
+
+//Function to write bytes at the current address
+func (compiler *compiler) emit(values ...byte) {
+	for _, value := range values {
+		if compiler.here > maxAddress {
+			compiler.fail(compiler.last, "program is too big, it runs past 0xFFFF")
+			return
+		}
+		compiler.addLine(compiler.here, compiler.last.line)
+		compiler.writeAt(compiler.here, value)
+		compiler.here++
+	}
+}
+
+//Function to remember the line a byte came from, for our line map
+//Bytes written one after another from the same line share an entry
+func (compiler *compiler) addLine(address int, line int) {
+	if line < 1 {
+		return
+	}
+
+	count := len(compiler.lines)
+	if count == 0 || compiler.lines[count-1].Line != line || compiler.lineEnd != address {
+		compiler.lines = append(compiler.lines, linemap.Line{Address: address, File: compiler.fileName, Line: line})
+	}
+	compiler.lineEnd = address + 1
+}
+
+//Function to write an opCode at the current address
+func (compiler *compiler) instruction(opCode int) {
+	compiler.emit(byte(opCode>>8), byte(opCode))
+}
+
+//Function to write bytes at an address, growing our game if we need to
+func (compiler *compiler) writeAt(address int, values ...byte) {
+	for i, value := range values {
+		offset := address + i - romStart
+		for len(compiler.memory) <= offset {
+			compiler.memory = append(compiler.memory, 0)
+		}
+		compiler.memory[offset] = value
+	}
+}
+
+//Function to read a byte we already wrote
+func (compiler *compiler) readAt(address int) byte {
+	offset := address - romStart
+	if offset < 0 || offset >= len(compiler.memory) {
+		return 0
+	}
+	return compiler.memory[offset]
+}
+
+//Function to define a label at an address, and fill in anything that was waiting for it
+func (compiler *compiler) defineLabel(name token, address int) {
+	if compiler.isDefined(name.text) {
+		compiler.fail(name, fmt.Sprintf("%s is already defined", name.text))
+		return
+	}
+
+	//If main is the first thing in our game, we don't need to jump to it
+	if name.text == "main" && compiler.jumpToMain && len(compiler.labels) == 0 && compiler.here == romStart+2 && address == compiler.here {
+		compiler.jumpToMain = false
+		compiler.memory = compiler.memory[:0]
+		compiler.here = romStart
+		address = romStart
+	}
+
+	compiler.labels[name.text] = address
+
+	for _, waiting := range compiler.patches[name.text] {
+		compiler.applyPatch(waiting, address)
+	}
+	delete(compiler.patches, name.text)
+}
+
+//Function to check if a name is already used
+func (compiler *compiler) isDefined(name string) bool {
+	_, isLabel := compiler.labels[name]
+	_, isConstant := compiler.constants[name]
+	_, isAlias := compiler.aliases[name]
+	_, isMacro := compiler.macros[name]
+	return isLabel || isConstant || isAlias || isMacro
+}
+
+//Function to write the address of a label, now that we know it
+func (compiler *compiler) applyPatch(waiting patch, address int) {
+	switch waiting.kind {
+	case patchAddress:
+		if address > 0xFFF {
+			compiler.fail(waiting.token, fmt.Sprintf("%s is at 0x%X, past the 12 bit address range", waiting.token.text, address))
+			return
+		}
+		opCode := int(compiler.readAt(waiting.address))&0xF0<<8 | address
+		compiler.writeAt(waiting.address, byte(opCode>>8), byte(opCode))
+	case patchLong:
+		compiler.writeAt(waiting.address, byte(address>>8), byte(address))
+	case patchUnpack:
+		//v0 := nibble and high bits, v1 := low byte
+		compiler.writeAt(waiting.address+1, byte(waiting.nibble<<4|(address>>8&0x0F)))
+		compiler.writeAt(waiting.address+3, byte(address))
+	case patchUnpackLong:
+		compiler.writeAt(waiting.address+1, byte(address>>8))
+		compiler.writeAt(waiting.address+3, byte(address))
+	}
+}
+
+//Function to read an address, a label can be defined later and will be filled in then
+func (compiler *compiler) address(kind patchKind, nibble int) int {
+	next := compiler.next()
+
+	label, isLabel := compiler.labels[next.text]
+	if isLabel {
+		return label
+	}
+
+	//Anything that isn't a number, constant, or calc is a label we haven't seen yet
+	_, isNumber := parseNumber(next.text)
+	_, isConstant := compiler.constants[next.text]
+	if !isNumber && !isConstant && next.text != "{" && !next.quoted {
+		compiler.patches[next.text] = append(compiler.patches[next.text], patch{kind: kind, address: compiler.here, nibble: nibble, token: next})
+		return 0
+	}
+
+	compiler.index--
+	if kind == patchLong || kind == patchUnpackLong {
+		return compiler.value(0, maxAddress)
+	}
+	return compiler.value(0, 0xFFF)
+}
+
+//Function to read a value, a number, constant, or { calc }, and check it is in range
+func (compiler *compiler) value(lowest int, highest int) int {
+	next := compiler.next()
+
+	var value int
+	if next.text == "{" && !next.quoted {
+		value = int(floor(compiler.calc(next)))
+	} else if number, isNumber := parseNumber(next.text); isNumber {
+		value = number
+	} else if constant, isConstant := compiler.constants[next.text]; isConstant {
+		value = int(floor(constant))
+	} else if label, isLabel := compiler.labels[next.text]; isLabel {
+		value = label
+	} else {
+		compiler.fail(next, fmt.Sprintf("expected a number, found %q", next.text))
+		return 0
+	}
+
+	if value < lowest || value > highest {
+		compiler.fail(next, fmt.Sprintf("value %d is out of range, must be from %d to %d", value, lowest, highest))
+		return 0
+	}
+	return value
+}
+
+//Function to read a register, v0 to vF, or an alias for one
+func (compiler *compiler) register(next token) (int, bool) {
+	if next.quoted {
+		return 0, false
+	}
+
+	register, isRegister := parseRegister(next.text)
+	if isRegister {
+		return register, true
+	}
+	register, isAlias := compiler.aliases[next.text]
+	return register, isAlias
+}
+
+//Function to read a token that must be a register
+func (compiler *compiler) expectRegister() int {
+	next := compiler.next()
+	register, isRegister := compiler.register(next)
+	if !isRegister {
+		compiler.fail(next, fmt.Sprintf("expected a register, found %q", next.text))
+	}
+	return register
+}
+
+//Function to read a { } block of tokens, after the {
+func (compiler *compiler) block(open token) []token {
+	body := make([]token, 0)
+	depth := 1
+	for {
+		if compiler.index >= len(compiler.tokens) {
+			compiler.fail(open, "missing }")
+			return body
+		}
+		next := compiler.next()
+
+		if !next.quoted && next.text == "{" {
+			depth++
+		} else if !next.quoted && next.text == "}" {
+			depth--
+			if depth == 0 {
+				return body
+			}
+		}
+		body = append(body, next)
+	}
+}
+
+//Function to add tokens to be read next, for macros
+func (compiler *compiler) insert(at token, tokens []token) {
+	compiler.expansions++
+	if compiler.expansions > maxExpansions {
+		compiler.fail(at, "too many macro expansions, is a macro using itself?")
+		return
+	}
+
+	rest := compiler.tokens[compiler.index:]
+	expanded := make([]token, 0, len(tokens)+len(rest))
+	expanded = append(expanded, tokens...)
+	expanded = append(expanded, rest...)
+	compiler.tokens = expanded
+	compiler.index = 0
+}
+
+//Function to handle the : directives, e.g. :const
+func (compiler *compiler) directive(directive token) {
+	switch directive.text {
+	case ":":
+		compiler.defineLabel(compiler.name(), compiler.here)
+	case ":next":
+		//A label for the second byte of the next instruction, for self modifying code
+		compiler.defineLabel(compiler.name(), compiler.here+1)
+	case ":alias":
+		name := compiler.name()
+		next := compiler.next()
+		register, isRegister := compiler.register(next)
+		if !isRegister && next.text == "{" {
+			register = int(floor(compiler.calc(next)))
+			isRegister = register >= 0 && register <= 15
+		}
+		if !isRegister {
+			compiler.fail(next, fmt.Sprintf("expected a register, found %q", next.text))
+			return
+		}
+		if compiler.isDefined(name.text) && !compiler.isAlias(name.text) {
+			compiler.fail(name, fmt.Sprintf("%s is already defined", name.text))
+			return
+		}
+		compiler.aliases[name.text] = register
+	case ":const":
+		name := compiler.name()
+		value := compiler.value(-maxAddress, maxAddress)
+		compiler.defineConstant(name, float64(value))
+	case ":calc":
+		name := compiler.name()
+		open := compiler.expect("{")
+		compiler.defineConstant(name, compiler.calc(open))
+	case ":byte":
+		compiler.emit(byte(compiler.value(-128, 255)))
+	case ":pointer":
+		address := compiler.address(patchLong, 0)
+		compiler.emit(byte(address>>8), byte(address))
+	case ":org":
+		compiler.here = compiler.value(romStart, maxAddress)
+	case ":call":
+		compiler.instruction(0x2000 | compiler.address(patchAddress, 0))
+	case ":unpack":
+		//Load an address into v0 and v1, the high nibble of v0 is given
+		next := compiler.next()
+		kind := patchUnpackLong
+		nibble := 0
+		if next.text != "long" {
+			compiler.index--
+			kind = patchUnpack
+			nibble = compiler.value(0, 15)
+		}
+		address := compiler.address(kind, nibble)
+		if kind == patchUnpackLong {
+			compiler.instruction(0x6000 | address>>8)
+		} else {
+			compiler.instruction(0x6000 | nibble<<4 | address>>8&0x0F)
+		}
+		compiler.instruction(0x6100 | address&0xFF)
+	case ":breakpoint":
+		compiler.breakpoints[compiler.here] = compiler.next().text
+	case ":monitor":
+		//Monitors are for Octo's debugger, we skip the address and size
+		compiler.next()
+		compiler.next()
+	case ":proto":
+		//Old Octo needed labels declared before use, we don't
+		compiler.next()
+	case ":assert":
+		message := "assertion failed"
+		next := compiler.next()
+		if next.quoted {
+			message = "assertion failed: " + next.text
+			next = compiler.next()
+		}
+		if next.text != "{" {
+			compiler.fail(next, "expected {")
+			return
+		}
+		if compiler.calc(next) == 0 {
+			compiler.fail(directive, message)
+		}
+	case ":macro":
+		compiler.defineMacro()
+	case ":stringmode":
+		compiler.defineStringMode()
+	default:
+		compiler.fail(directive, fmt.Sprintf("unknown directive %s", directive.text))
+	}
+}
+
+//Function to check if a name is an alias
+func (compiler *compiler) isAlias(name string) bool {
+	_, isAlias := compiler.aliases[name]
+	return isAlias
+}
+
+//Function to define a constant, constants can't change once they are defined
+func (compiler *compiler) defineConstant(name token, value float64) {
+	if compiler.isDefined(name.text) {
+		compiler.fail(name, fmt.Sprintf("%s is already defined", name.text))
+		return
+	}
+	compiler.constants[name.text] = value
+}
+
+//Function to define a macro, :macro name parameters { body }
+func (compiler *compiler) defineMacro() {
+	name := compiler.name()
+
+	defined := &macro{}
+	for compiler.err == nil {
+		next := compiler.next()
+		if next.text == "{" && !next.quoted {
+			defined.body = compiler.block(next)
+			break
+		}
+		defined.parameters = append(defined.parameters, next)
+	}
+
+	if compiler.isDefined(name.text) {
+		compiler.fail(name, fmt.Sprintf("%s is already defined", name.text))
+		return
+	}
+	compiler.macros[name.text] = defined
+}
+
+//Function to expand a macro, replacing its parameters with the tokens after it
+func (compiler *compiler) expandMacro(name token, expanding *macro) {
+
+	replacements := make(map[string]token)
+	for _, parameter := range expanding.parameters {
+		replacements[parameter.text] = compiler.next()
+	}
+
+	//CALLS is how many times this macro was used before
+	replacements["CALLS"] = token{text: strconv.Itoa(expanding.calls)}
+	expanding.calls++
+
+	compiler.insert(name, replaceTokens(expanding.body, replacements))
+}
+
+//Function to define a string mode, :stringmode name "alphabet" { body }
+//Using it runs the body once for each character of a string
+func (compiler *compiler) defineStringMode() {
+	name := compiler.name()
+	alphabet := compiler.next()
+	if !alphabet.quoted {
+		compiler.fail(alphabet, "expected an alphabet in quotes")
+		return
+	}
+	open := compiler.expect("{")
+	body := compiler.block(open)
+
+	if compiler.isDefined(name.text) {
+		compiler.fail(name, fmt.Sprintf("%s is already defined", name.text))
+		return
+	}
+	compiler.stringModes[name.text] = append(compiler.stringModes[name.text], &stringMode{alphabet: alphabet.text, body: body})
+}
+
+//Function to expand a string mode for each character of a string
+func (compiler *compiler) expandStringMode(name token, modes []*stringMode) {
+	text := compiler.next()
+	if !text.quoted {
+		compiler.fail(text, fmt.Sprintf("%s needs a string in quotes", name.text))
+		return
+	}
+
+	expanded := make([]token, 0)
+	for index := 0; index < len(text.text); index++ {
+		character := text.text[index]
+
+		found := false
+		for _, mode := range modes {
+			value := -1
+			for i := 0; i < len(mode.alphabet); i++ {
+				if mode.alphabet[i] == character {
+					value = i
+					break
+				}
+			}
+			if value < 0 {
+				continue
+			}
+
+			//VALUE is where the character is in the alphabet, CHAR is the character, and INDEX is where it is in the string
+			replacements := map[string]token{
+				"VALUE": {text: strconv.Itoa(value)},
+				"CHAR":  {text: strconv.Itoa(int(character))},
+				"INDEX": {text: strconv.Itoa(index)},
+				"CALLS": {text: strconv.Itoa(mode.calls)},
+			}
+			mode.calls++
+			expanded = append(expanded, replaceTokens(mode.body, replacements)...)
+			found = true
+			break
+		}
+		if !found {
+			compiler.fail(text, fmt.Sprintf("string mode %s can't write %q", name.text, character))
+			return
+		}
+	}
+
+	compiler.insert(name, expanded)
+}
+
+//Function to copy tokens, replacing some of them
+//Replaced tokens keep the position of the token they replace, so errors point at the macro
+func replaceTokens(tokens []token, replacements map[string]token) []token {
+	replaced := make([]token, len(tokens))
+	for i, next := range tokens {
+		replacement, isReplaced := replacements[next.text]
+		if isReplaced && !next.quoted {
+			replacement.line = next.line
+			replacement.column = next.column
+			next = replacement
+		}
+		replaced[i] = next
+	}
+	return replaced
+}
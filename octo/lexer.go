@@ -0,0 +1,104 @@
+package octo
+
+/*
+   Compiler for Octo (https://github.com/JohnEarnest/Octo), the high level assembler
+   most modern Chip-8, SCHIP, and XO-CHIP games are written in
+*/
+
+//This is helper class for splitting source into tokens
+
+//Imports
+import (
+	"strconv"
+	"strings"
+)
+
+//A single token, and where it is in our source
+type token struct {
+	text string
+
+	//True if this token was written in quotes
+	quoted bool
+
+	line   int
+	column int
+}
+
+//Function to split source into tokens
+//Tokens are separated by whitespace, strings are in quotes, and comments start with a # and run to the end of the line
+func tokenize(fileName string, source string) ([]token, error) {
+
+	tokens := make([]token, 0)
+
+	lines := strings.Split(strings.Replace(source, "\r\n", "\n", -1), "\n")
+	for number, line := range lines {
+		for i := 0; i < len(line); {
+			character := line[i]
+
+			switch {
+			case character == ' ' || character == '\t' || character == '\r':
+				i++
+			case character == '#':
+				//Comment, skip the rest of the line
+				i = len(line)
+			case character == '"':
+				end := strings.IndexByte(line[i+1:], '"')
+				if end < 0 {
+					return nil, &Error{File: fileName, Line: number + 1, Column: i + 1, Message: "unterminated string"}
+				}
+				tokens = append(tokens, token{text: line[i+1 : i+1+end], quoted: true, line: number + 1, column: i + 1})
+				i += end + 2
+			default:
+				end := i
+				for end < len(line) && line[end] != ' ' && line[end] != '\t' && line[end] != '\r' {
+					end++
+				}
+				tokens = append(tokens, token{text: line[i:end], line: number + 1, column: i + 1})
+				i = end
+			}
+		}
+	}
+
+	return tokens, nil
+}
+
+//Function to read a number, decimal, 0x hex, or 0b binary, with an optional -
+func parseNumber(text string) (int, bool) {
+
+	negative := strings.HasPrefix(text, "-")
+	digits := strings.TrimPrefix(text, "-")
+
+	base := 10
+	lower := strings.ToLower(digits)
+	if strings.HasPrefix(lower, "0x") {
+		base = 16
+		digits = digits[2:]
+	} else if strings.HasPrefix(lower, "0b") {
+		base = 2
+		digits = digits[2:]
+	}
+
+	//Anything bigger than 24 bits is too big to be a value in a Chip-8 game
+	value, err := strconv.ParseUint(digits, base, 24)
+	if err != nil || digits == "" || strings.HasPrefix(digits, "+") {
+		return 0, false
+	}
+
+	if negative {
+		return -int(value), true
+	}
+	return int(value), true
+}
+
+//Function to read a register name, v0 to vF
+func parseRegister(text string) (int, bool) {
+	if len(text) != 2 || (text[0] != 'v' && text[0] != 'V') {
+		return 0, false
+	}
+
+	register, err := strconv.ParseUint(text[1:], 16, 4)
+	if err != nil {
+		return 0, false
+	}
+	return int(register), true
+}
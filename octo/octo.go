@@ -0,0 +1,121 @@
+package octo
+
+/*
+   Compiler for Octo (https://github.com/JohnEarnest/Octo), the high level assembler
+   most modern Chip-8, SCHIP, and XO-CHIP games are written in
+
+   Octo source is a list of whitespace separated tokens, comments start with a #
+
+	   :const SPEED 2
+	   :alias x v0
+	   :macro move reg amount { reg += amount }
+	   :calc half { SPEED / 2 }
+	   : main
+	       x := 0
+	       loop
+	           i := ball
+	           sprite x v1 1
+	           move x SPEED
+	           if x == 60 then x := 0
+	           while x != 62
+	       again
+	   : ball
+	       0b11000000
+*/
+
+//Imports
+import (
+	linemap "github.com/torch2424/chipGo/linemap"
+	"fmt"
+	"io/ioutil"
+	"sort"
+)
+
+//Where games are loaded into memory
+const romStart = 0x200
+
+//Error in our source, with where it happened
+type Error struct {
+	File    string
+	Line    int
+	Column  int
+	Message string
+}
+
+func (err *Error) Error() string {
+	return fmt.Sprintf("%s:%d:%d: %s", err.File, err.Line, err.Column, err.Message)
+}
+
+//Symbol is a label or constant, and its value
+type Symbol struct {
+	Name  string
+	Value int
+	Label bool
+}
+
+//Program is a compiled game
+type Program struct {
+	Binary []byte
+
+	//Every label and constant, sorted by value
+	Symbols []Symbol
+
+	//Addresses marked with :breakpoint, and their names
+	Breakpoints map[int]string
+
+	//Which line each address came from, for debuggers
+	LineMap *linemap.Map
+}
+
+//Function to compile a source file
+func CompileFile(path string) (*Program, error) {
+
+	source, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return Compile(path, source)
+}
+
+//Function to compile source, fileName is used for errors
+//Like Octo, we stop at the first error
+func Compile(fileName string, source []byte) (*Program, error) {
+
+	tokens, err := tokenize(fileName, string(source))
+	if err != nil {
+		return nil, err
+	}
+
+	compiler := newCompiler(fileName, tokens)
+	compiler.compile()
+	if compiler.err != nil {
+		return nil, compiler.err
+	}
+
+	return compiler.program(), nil
+}
+
+//Function to build our program, once everything is compiled
+func (compiler *compiler) program() *Program {
+
+	program := &Program{Binary: compiler.memory, Breakpoints: compiler.breakpoints}
+
+	labels := make([]linemap.Label, 0, len(compiler.labels))
+	for name, address := range compiler.labels {
+		program.Symbols = append(program.Symbols, Symbol{Name: name, Value: address, Label: true})
+		labels = append(labels, linemap.Label{Name: name, Address: address})
+	}
+	program.LineMap = linemap.New(compiler.lines, labels)
+	for name, value := range compiler.constants {
+		program.Symbols = append(program.Symbols, Symbol{Name: name, Value: int(value)})
+	}
+	sort.Slice(program.Symbols, func(i, j int) bool {
+		if program.Symbols[i].Value != program.Symbols[j].Value {
+			return program.Symbols[i].Value < program.Symbols[j].Value
+		}
+		return program.Symbols[i].Name < program.Symbols[j].Name
+	})
+
+	return program
+}
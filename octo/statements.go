@@ -0,0 +1,418 @@
+package octo
+
+/*
+   Compiler for Octo (https://github.com/JohnEarnest/Octo), the high level assembler
+   most modern Chip-8, SCHIP, and XO-CHIP games are written in
+*/
+
+//This is helper class for turning statements into opCodes, including if, loop, and the register operators
+
+//Imports
+import (
+	"fmt"
+	"strings"
+)
+
+//Conditions, and the condition that is true when they are false
+var negatedConditions = map[string]string{
+	"==":   "!=",
+	"!=":   "==",
+	"<":    ">=",
+	">=":   "<",
+	">":    "<=",
+	"<=":   ">",
+	"key":  "-key",
+	"-key": "key",
+}
+
+//Function to compile a single statement
+func (compiler *compiler) statement() {
+	next := compiler.next()
+
+	if next.quoted {
+		compiler.fail(next, fmt.Sprintf("unexpected string %q", next.text))
+		return
+	}
+
+	//Directives, and labels
+	if strings.HasPrefix(next.text, ":") && next.text != ":=" {
+		compiler.directive(next)
+		return
+	}
+
+	//Register operators, e.g. v0 += 1
+	register, isRegister := compiler.register(next)
+	if isRegister {
+		compiler.registerStatement(register)
+		return
+	}
+
+	switch next.text {
+	case "return", ";":
+		compiler.instruction(0x00EE)
+	case "clear":
+		compiler.instruction(0x00E0)
+	case "hires":
+		compiler.instruction(0x00FF)
+	case "lores":
+		compiler.instruction(0x00FE)
+	case "exit":
+		compiler.instruction(0x00FD)
+	case "scroll-right":
+		compiler.instruction(0x00FB)
+	case "scroll-left":
+		compiler.instruction(0x00FC)
+	case "scroll-down":
+		compiler.instruction(0x00C0 | compiler.value(0, 15))
+	case "scroll-up":
+		compiler.instruction(0x00D0 | compiler.value(0, 15))
+	case "audio":
+		compiler.instruction(0xF002)
+	case "plane":
+		compiler.instruction(0xF001 | compiler.value(0, 15)<<8)
+	case "native":
+		compiler.instruction(0x0000 | compiler.address(patchAddress, 0))
+	case "jump":
+		compiler.instruction(0x1000 | compiler.address(patchAddress, 0))
+	case "jump0":
+		compiler.instruction(0xB000 | compiler.address(patchAddress, 0))
+	case "bcd":
+		compiler.instruction(0xF033 | compiler.expectRegister()<<8)
+	case "saveflags":
+		compiler.instruction(0xF075 | compiler.expectRegister()<<8)
+	case "loadflags":
+		compiler.instruction(0xF085 | compiler.expectRegister()<<8)
+	case "save", "load":
+		compiler.saveOrLoad(next.text)
+	case "sprite":
+		x := compiler.expectRegister()
+		y := compiler.expectRegister()
+		compiler.instruction(0xD000 | x<<8 | y<<4 | compiler.value(0, 15))
+	case "delay":
+		compiler.expect(":=")
+		compiler.instruction(0xF015 | compiler.expectRegister()<<8)
+	case "buzzer":
+		compiler.expect(":=")
+		compiler.instruction(0xF018 | compiler.expectRegister()<<8)
+	case "pitch":
+		compiler.expect(":=")
+		compiler.instruction(0xF03A | compiler.expectRegister()<<8)
+	case "i":
+		compiler.indexStatement()
+	case "if":
+		compiler.ifStatement(next)
+	case "else":
+		compiler.elseStatement(next)
+	case "end":
+		compiler.endStatement(next)
+	case "loop":
+		compiler.loops = append(compiler.loops, loopFrame{address: compiler.here, token: next})
+	case "while":
+		compiler.whileStatement(next)
+	case "again":
+		compiler.againStatement(next)
+	default:
+		compiler.otherStatement(next)
+	}
+}
+
+//Function to compile a name that isn't a keyword, a macro, string mode, data, or a call
+func (compiler *compiler) otherStatement(next token) {
+
+	expanding, isMacro := compiler.macros[next.text]
+	if isMacro {
+		compiler.expandMacro(next, expanding)
+		return
+	}
+	modes, isStringMode := compiler.stringModes[next.text]
+	if isStringMode {
+		compiler.expandStringMode(next, modes)
+		return
+	}
+
+	//Numbers and constants on their own are bytes of data
+	_, isNumber := parseNumber(next.text)
+	_, isConstant := compiler.constants[next.text]
+	if isNumber || isConstant || next.text == "{" {
+		compiler.index--
+		compiler.emit(byte(compiler.value(-128, 255)))
+		return
+	}
+
+	//Anything else is a subroutine call, to a label that can be defined later
+	compiler.index--
+	compiler.instruction(0x2000 | compiler.address(patchAddress, 0))
+}
+
+//Function to compile save and load, of v0 to vX, or XO-CHIP's range vX - vY
+func (compiler *compiler) saveOrLoad(keyword string) {
+	x := compiler.expectRegister()
+
+	if compiler.peek() == "-" {
+		compiler.next()
+		y := compiler.expectRegister()
+		if keyword == "save" {
+			compiler.instruction(0x5002 | x<<8 | y<<4)
+		} else {
+			compiler.instruction(0x5003 | x<<8 | y<<4)
+		}
+		return
+	}
+
+	if keyword == "save" {
+		compiler.instruction(0xF055 | x<<8)
+	} else {
+		compiler.instruction(0xF065 | x<<8)
+	}
+}
+
+//Function to compile the index register operators
+func (compiler *compiler) indexStatement() {
+	operator := compiler.next()
+
+	switch operator.text {
+	case ":=":
+		switch compiler.peek() {
+		case "hex":
+			compiler.next()
+			compiler.instruction(0xF029 | compiler.expectRegister()<<8)
+		case "bighex":
+			compiler.next()
+			compiler.instruction(0xF030 | compiler.expectRegister()<<8)
+		case "long":
+			compiler.next()
+			compiler.instruction(0xF000)
+			address := compiler.address(patchLong, 0)
+			compiler.emit(byte(address>>8), byte(address))
+		default:
+			compiler.instruction(0xA000 | compiler.address(patchAddress, 0))
+		}
+	case "+=":
+		compiler.instruction(0xF01E | compiler.expectRegister()<<8)
+	default:
+		compiler.fail(operator, fmt.Sprintf("unknown operator i %s", operator.text))
+	}
+}
+
+//Function to compile the register operators, e.g. v0 := random 0xFF
+func (compiler *compiler) registerStatement(x int) {
+	operator := compiler.next()
+	operand := compiler.next()
+	y, isRegister := compiler.register(operand)
+
+	//Operators between two registers are all 8XYN
+	registerOpcodes := map[string]int{":=": 0x0, "|=": 0x1, "&=": 0x2, "^=": 0x3, "+=": 0x4, "-=": 0x5, ">>=": 0x6, "=-": 0x7, "<<=": 0xE}
+	if isRegister {
+		last, known := registerOpcodes[operator.text]
+		if !known {
+			compiler.fail(operator, fmt.Sprintf("unknown operator %s", operator.text))
+			return
+		}
+		compiler.instruction(0x8000 | x<<8 | y<<4 | last)
+		return
+	}
+
+	switch operator.text {
+	case ":=":
+		switch operand.text {
+		case "random":
+			compiler.instruction(0xC000 | x<<8 | compiler.value(0, 255))
+		case "key":
+			compiler.instruction(0xF00A | x<<8)
+		case "delay":
+			compiler.instruction(0xF007 | x<<8)
+		default:
+			compiler.index--
+			compiler.instruction(0x6000 | x<<8 | compiler.value(-128, 255)&0xFF)
+		}
+	case "+=":
+		compiler.index--
+		compiler.instruction(0x7000 | x<<8 | compiler.value(-128, 255)&0xFF)
+	case "-=":
+		//There is no subtract a number opCode, so we add its negative
+		compiler.index--
+		compiler.instruction(0x7000 | x<<8 | -compiler.value(-255, 128)&0xFF)
+	default:
+		compiler.fail(operand, fmt.Sprintf("%s needs a register, found %q", operator.text, operand.text))
+	}
+}
+
+//Function to compile a condition, followed by a skip
+//The instruction after the skip only runs if the condition is true
+func (compiler *compiler) condition(negated bool) {
+	x := compiler.expectRegister()
+	comparison := compiler.next()
+	if compiler.err != nil {
+		return
+	}
+
+	condition := comparison.text
+	_, known := negatedConditions[condition]
+	if !known || comparison.quoted {
+		compiler.fail(comparison, fmt.Sprintf("unknown condition %q", condition))
+		return
+	}
+	if negated {
+		condition = negatedConditions[condition]
+	}
+
+	switch condition {
+	case "key":
+		compiler.instruction(0xE0A1 | x<<8)
+		return
+	case "-key":
+		compiler.instruction(0xE09E | x<<8)
+		return
+	}
+
+	operand := compiler.next()
+	y, isRegister := compiler.register(operand)
+	compiler.index--
+
+	switch condition {
+	case "==":
+		if isRegister {
+			compiler.next()
+			compiler.instruction(0x9000 | x<<8 | y<<4)
+		} else {
+			compiler.instruction(0x4000 | x<<8 | compiler.value(-128, 255)&0xFF)
+		}
+	case "!=":
+		if isRegister {
+			compiler.next()
+			compiler.instruction(0x5000 | x<<8 | y<<4)
+		} else {
+			compiler.instruction(0x3000 | x<<8 | compiler.value(-128, 255)&0xFF)
+		}
+	default:
+		//There are no opCodes for < and >, so we subtract in vF and check its no borrow flag
+		if isRegister {
+			compiler.next()
+			compiler.instruction(0x8F00 | y<<4)
+		} else {
+			compiler.instruction(0x6F00 | compiler.value(-128, 255)&0xFF)
+		}
+
+		if condition == "<" || condition == ">=" {
+			//vF =- vX, vF is 1 when vX >= the right side
+			compiler.instruction(0x8F07 | x<<4)
+		} else {
+			//vF -= vX, vF is 1 when the right side >= vX
+			compiler.instruction(0x8F05 | x<<4)
+		}
+
+		//For < and >, vF is 1 when the condition is false
+		if condition == "<" || condition == ">" {
+			compiler.instruction(0x3F01)
+		} else {
+			compiler.instruction(0x4F01)
+		}
+	}
+}
+
+//Function to compile if ... then, or if ... begin
+func (compiler *compiler) ifStatement(start token) {
+
+	//Look ahead for then or begin, the condition is two or three tokens, and a { calc } counts as one
+	mode := ""
+	depth := 0
+	for i, count := compiler.index, 0; i < len(compiler.tokens) && count <= 3; i++ {
+		text := compiler.tokens[i].text
+		if text == "{" {
+			depth++
+		} else if text == "}" {
+			depth--
+		} else if depth == 0 && (text == "then" || text == "begin") {
+			mode = text
+			break
+		}
+		if depth == 0 {
+			count++
+		}
+	}
+	if mode == "" {
+		compiler.fail(start, "if needs a then or begin")
+		return
+	}
+
+	if mode == "then" {
+		compiler.condition(false)
+		compiler.expect("then")
+		return
+	}
+
+	//Skip the jump past our block when the condition is true
+	compiler.condition(true)
+	compiler.expect("begin")
+	compiler.branches = append(compiler.branches, start)
+	compiler.jumps = append(compiler.jumps, compiler.here)
+	compiler.instruction(0x1000)
+}
+
+//Function to compile else, jumping from the end of the if block past the else block
+func (compiler *compiler) elseStatement(start token) {
+	if len(compiler.jumps) == 0 {
+		compiler.fail(start, "else without if ... begin")
+		return
+	}
+
+	jump := compiler.jumps[len(compiler.jumps)-1]
+	compiler.jumps[len(compiler.jumps)-1] = compiler.here
+	compiler.instruction(0x1000)
+	compiler.patchJump(start, jump, compiler.here)
+}
+
+//Function to compile end, the end of an if block
+func (compiler *compiler) endStatement(start token) {
+	if len(compiler.jumps) == 0 {
+		compiler.fail(start, "end without if ... begin")
+		return
+	}
+
+	jump := compiler.jumps[len(compiler.jumps)-1]
+	compiler.jumps = compiler.jumps[:len(compiler.jumps)-1]
+	compiler.branches = compiler.branches[:len(compiler.branches)-1]
+	compiler.patchJump(start, jump, compiler.here)
+}
+
+//Function to compile while, jumping out of the loop if the condition is false
+func (compiler *compiler) whileStatement(start token) {
+	if len(compiler.loops) == 0 {
+		compiler.fail(start, "while without loop")
+		return
+	}
+
+	compiler.condition(true)
+	frame := &compiler.loops[len(compiler.loops)-1]
+	frame.whiles = append(frame.whiles, compiler.here)
+	compiler.instruction(0x1000)
+}
+
+//Function to compile again, jumping back to the start of the loop
+func (compiler *compiler) againStatement(start token) {
+	if len(compiler.loops) == 0 {
+		compiler.fail(start, "again without loop")
+		return
+	}
+
+	frame := compiler.loops[len(compiler.loops)-1]
+	compiler.loops = compiler.loops[:len(compiler.loops)-1]
+	if frame.address > 0xFFF {
+		compiler.fail(frame.token, "loop is past the 12 bit address range")
+		return
+	}
+	compiler.instruction(0x1000 | frame.address)
+
+	for _, while := range frame.whiles {
+		compiler.patchJump(start, while, compiler.here)
+	}
+}
+
+//Function to fill in a jump we wrote before we knew where it went
+func (compiler *compiler) patchJump(at token, address int, target int) {
+	if target > 0xFFF {
+		compiler.fail(at, "jump is past the 12 bit address range")
+		return
+	}
+	compiler.writeAt(address, byte(0x10|target>>8), byte(target))
+}
@@ -0,0 +1,37 @@
+package main
+
+//This is helper class for our random number generator, see cpu/random.go
+//--seed makes CXNN give the same numbers every run, and --random picks how they are made
+
+import (
+	cpu "github.com/torch2424/chipGo/cpu"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+//Function to make our random number generator from the command line
+//Without --seed, games get a new seed every time they are played, and headless runs use 0 so they repeat
+//Also returns the seed, e.g. for recording movies
+func newRandom(headless bool) (cpu.Random, uint64) {
+	algorithm, err := cpu.ParseRandomAlgorithm(*randomAlgorithm)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	var seed uint64
+	if *randomSeed != "" {
+		seed, err = strconv.ParseUint(*randomSeed, 0, 64)
+		if err != nil {
+			fmt.Println("Bad seed, expected a number:", *randomSeed)
+			os.Exit(1)
+		}
+	} else if !headless {
+		seed = uint64(time.Now().UnixNano())
+		print("Random seed is ", seed, ", use --seed ", seed, " to play with the same numbers again\n")
+	}
+
+	return cpu.NewRandom(algorithm, seed), seed
+}
@@ -0,0 +1,248 @@
+package record
+
+/*
+   Video recording of Chip-8, SCHIP, and XO-CHIP games
+
+   Saves every frame of a game, as an animated GIF, raw Y4M video, or a PNG for each frame
+*/
+
+//This is helper class for animated GIFs
+//image/gif needs every frame in memory before it writes any, so we write the GIF ourselves, a frame at a time
+//See https://www.w3.org/Graphics/GIF/spec-gif89a.txt
+
+//Frames that are the same as the one before only make it last longer, so a still screen is one frame
+//GIF delays are in hundredths of a second, so frames are timed from the start of the recording, to not drift from 60hz
+//Browsers slow down frames shorter than 2 hundredths, so frames that short are dropped for the next one
+
+//Imports
+import (
+	"bufio"
+	"bytes"
+	"compress/lzw"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"os"
+)
+
+//Shortest delay we write, in hundredths of a second
+const gifMinDelay = 2
+
+//Colors in a GIF's color table, and the bits for each
+const gifColors = 256
+const gifColorBits = 8
+
+//Writes an animated GIF
+type gifWriter struct {
+	file *os.File
+	out  *bufio.Writer
+
+	//Size of the first frame, written in the header
+	bounds  image.Rectangle
+	started bool
+
+	//The frame we are waiting to write, until we know how long it lasts
+	pending *image.RGBA
+
+	//How many frames we were given, and how many hundredths of a second we have written
+	frames  int
+	written int
+
+	//Our last frame's colors, reused for every frame
+	paletted *image.Paletted
+}
+
+//Function to start an animated GIF
+func newGifWriter(path string) (Writer, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return &gifWriter{file: file, out: bufio.NewWriter(file)}, nil
+}
+
+//Function to find how many hundredths of a second a number of frames take, rounded
+func gifTime(frames int) int {
+	return (frames*100 + FrameRate/2) / FrameRate
+}
+
+//Function to add a frame, the frame before it is written once we know how long it lasts
+func (writer *gifWriter) WriteFrame(picture *image.RGBA) error {
+	if !writer.started {
+		writer.bounds = picture.Bounds()
+		writer.pending = image.NewRGBA(writer.bounds)
+		writer.started = true
+		writer.writeHeader()
+	} else {
+		err := checkSize(writer.bounds, picture)
+		if err != nil {
+			return err
+		}
+
+		//The same frame again lasts longer
+		if bytes.Equal(writer.pending.Pix, picture.Pix) {
+			writer.frames++
+			return nil
+		}
+
+		//Write the frame we were waiting on, unless it was too short to show
+		delay := gifTime(writer.frames) - writer.written
+		if delay >= gifMinDelay {
+			writer.writeImage(writer.pending, delay)
+			writer.written = writer.written + delay
+		}
+	}
+
+	copy(writer.pending.Pix, picture.Pix)
+	writer.frames++
+	return nil
+}
+
+//Function to write the last frame, and finish the GIF
+func (writer *gifWriter) Close() error {
+	if writer.started {
+		delay := gifTime(writer.frames) - writer.written
+		if delay < gifMinDelay {
+			delay = gifMinDelay
+		}
+		writer.writeImage(writer.pending, delay)
+
+		//Trailer
+		writer.out.WriteByte(0x3B)
+	}
+
+	err := writer.out.Flush()
+	closeErr := writer.file.Close()
+	if err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+//Function to write the header, the size of the GIF, and that it loops forever
+func (writer *gifWriter) writeHeader() {
+	writer.out.WriteString("GIF89a")
+	binary.Write(writer.out, binary.LittleEndian, uint16(writer.bounds.Dx()))
+	binary.Write(writer.out, binary.LittleEndian, uint16(writer.bounds.Dy()))
+	//No global color table, every frame has its own, then the background color and aspect ratio
+	writer.out.Write([]byte{0x70, 0x00, 0x00})
+
+	//Netscape's extension to loop, 0 times is forever
+	writer.out.Write([]byte{0x21, 0xFF, 0x0B})
+	writer.out.WriteString("NETSCAPE2.0")
+	writer.out.Write([]byte{0x03, 0x01, 0x00, 0x00, 0x00})
+}
+
+//Function to write a frame, lasting a delay in hundredths of a second
+func (writer *gifWriter) writeImage(picture *image.RGBA, delay int) {
+	paletted := writer.palettize(picture)
+
+	//Graphic control extension, for the delay, each frame replaces the last one
+	writer.out.Write([]byte{0x21, 0xF9, 0x04, 0x04})
+	binary.Write(writer.out, binary.LittleEndian, uint16(delay))
+	writer.out.Write([]byte{0x00, 0x00})
+
+	//Image descriptor, the whole GIF, with a color table of 256 colors
+	writer.out.WriteByte(0x2C)
+	binary.Write(writer.out, binary.LittleEndian, [4]uint16{0, 0, uint16(writer.bounds.Dx()), uint16(writer.bounds.Dy())})
+	writer.out.WriteByte(0x80 | (gifColorBits - 1))
+	for i := 0; i < gifColors; i++ {
+		var entry color.RGBA
+		if i < len(paletted.Palette) {
+			entry = paletted.Palette[i].(color.RGBA)
+		}
+		writer.out.Write([]byte{entry.R, entry.G, entry.B})
+	}
+
+	//The pixels, compressed with LZW, in blocks of up to 255 bytes
+	writer.out.WriteByte(gifColorBits)
+	blocks := &gifBlocks{out: writer.out}
+	compressor := lzw.NewWriter(blocks, lzw.LSB, gifColorBits)
+	compressor.Write(paletted.Pix)
+	compressor.Close()
+	blocks.close()
+}
+
+//Function to turn a frame into colors from a palette
+//Our frames only have a few colors, so each frame's palette is the colors in it
+//If there are too many, e.g. in party mode, we use the closest colors from the Plan 9 palette instead
+func (writer *gifWriter) palettize(picture *image.RGBA) *image.Paletted {
+	if writer.paletted == nil {
+		writer.paletted = image.NewPaletted(writer.bounds, nil)
+	}
+	paletted := writer.paletted
+	paletted.Palette = paletted.Palette[:0]
+
+	indexes := make(map[color.RGBA]uint8)
+	var last color.RGBA
+	var lastIndex uint8
+	for i := 0; i < len(picture.Pix); i = i + 4 {
+		pixel := color.RGBA{picture.Pix[i], picture.Pix[i+1], picture.Pix[i+2], picture.Pix[i+3]}
+		if i > 0 && pixel == last {
+			paletted.Pix[i/4] = lastIndex
+			continue
+		}
+
+		index, found := indexes[pixel]
+		if !found {
+			if len(paletted.Palette) >= gifColors {
+				paletted.Palette = gifPlan9Palette()
+				draw.Draw(paletted, paletted.Bounds(), picture, picture.Bounds().Min, draw.Src)
+				return paletted
+			}
+			index = uint8(len(paletted.Palette))
+			indexes[pixel] = index
+			paletted.Palette = append(paletted.Palette, pixel)
+		}
+
+		paletted.Pix[i/4] = index
+		last, lastIndex = pixel, index
+	}
+
+	return paletted
+}
+
+//Function to get the Plan 9 palette as RGBA colors, for frames with too many colors
+func gifPlan9Palette() color.Palette {
+	colors := make(color.Palette, len(palette.Plan9))
+	for i, entry := range palette.Plan9 {
+		colors[i] = color.RGBAModel.Convert(entry)
+	}
+	return colors
+}
+
+//Writes GIF data blocks, a byte for the size, then up to 255 bytes
+type gifBlocks struct {
+	out    *bufio.Writer
+	buffer [255]byte
+	size   int
+}
+
+func (blocks *gifBlocks) Write(data []byte) (int, error) {
+	for _, value := range data {
+		blocks.buffer[blocks.size] = value
+		blocks.size++
+		if blocks.size == len(blocks.buffer) {
+			blocks.flush()
+		}
+	}
+	return len(data), nil
+}
+
+//Function to write the block we have so far
+func (blocks *gifBlocks) flush() {
+	if blocks.size == 0 {
+		return
+	}
+	blocks.out.WriteByte(uint8(blocks.size))
+	blocks.out.Write(blocks.buffer[:blocks.size])
+	blocks.size = 0
+}
+
+//Function to write the last block, and the empty block that ends them
+func (blocks *gifBlocks) close() {
+	blocks.flush()
+	blocks.out.WriteByte(0x00)
+}
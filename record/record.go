@@ -0,0 +1,58 @@
+package record
+
+/*
+   Video recording of Chip-8, SCHIP, and XO-CHIP games
+
+   Saves every frame of a game, as an animated GIF, raw Y4M video, or a PNG for each frame
+*/
+
+//Frames are written as they come, 60 a second, so long recordings don't need to fit in memory
+//Y4M and PNG sequences are for other programs, e.g. ffmpeg -i brix.y4m brix.mp4, or ffmpeg -framerate 60 -i frames/%05d.png brix.mp4
+
+//Imports
+import (
+	"fmt"
+	"image"
+	"path/filepath"
+	"strings"
+)
+
+//Frames a second we record at, the same as our timers
+const FrameRate = 60
+
+//Writer saves the frames of a recording
+type Writer interface {
+
+	//Save the next frame, every frame is 1/60th of a second, and must be the same size
+	WriteFrame(picture *image.RGBA) error
+
+	//Finish the recording
+	Close() error
+}
+
+//Function to start a recording, the format is picked from the path
+//  brix.gif            animated GIF
+//  brix.y4m            raw video, YUV 4:4:4
+//  frames/%05d.png     a PNG for each frame, numbered from 0
+func Open(path string) (Writer, error) {
+	if strings.Contains(path, "%") {
+		return newSequenceWriter(path)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".gif":
+		return newGifWriter(path)
+	case ".y4m":
+		return newY4mWriter(path)
+	}
+
+	return nil, fmt.Errorf("Can't record to %s, use .gif, .y4m, or a pattern like frames/%%05d.png", path)
+}
+
+//Function to check every frame of a recording is the same size as the first
+func checkSize(first image.Rectangle, picture *image.RGBA) error {
+	if picture.Bounds().Size() != first.Size() {
+		return fmt.Errorf("Frames must all be %dx%d, this one is %dx%d", first.Dx(), first.Dy(), picture.Bounds().Dx(), picture.Bounds().Dy())
+	}
+	return nil
+}
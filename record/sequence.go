@@ -0,0 +1,67 @@
+package record
+
+/*
+   Video recording of Chip-8, SCHIP, and XO-CHIP games
+
+   Saves every frame of a game, as an animated GIF, raw Y4M video, or a PNG for each frame
+*/
+
+//This is helper class for image sequences, a PNG for every frame
+//The path is a pattern for fmt, e.g. frames/%05d.png is frames/00000.png, frames/00001.png, and so on
+
+//Imports
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"strings"
+)
+
+//Writes a PNG for every frame
+type sequenceWriter struct {
+	pattern string
+	frames  int
+
+	//Size of the first frame, every frame must match
+	bounds image.Rectangle
+}
+
+//Function to start an image sequence
+func newSequenceWriter(pattern string) (Writer, error) {
+	//Check the pattern numbers each file, e.g. not %s or %%
+	name := fmt.Sprintf(pattern, 0)
+	if strings.Contains(name, "%!") || name == fmt.Sprintf(pattern, 1) {
+		return nil, fmt.Errorf("Pattern %s doesn't number each frame, try frames/%%05d.png", pattern)
+	}
+	return &sequenceWriter{pattern: pattern}, nil
+}
+
+//Function to write a frame, to the next file of the sequence
+func (writer *sequenceWriter) WriteFrame(picture *image.RGBA) error {
+	if writer.frames == 0 {
+		writer.bounds = picture.Bounds()
+	}
+	err := checkSize(writer.bounds, picture)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(fmt.Sprintf(writer.pattern, writer.frames))
+	if err != nil {
+		return err
+	}
+	err = png.Encode(file, picture)
+	closeErr := file.Close()
+	if err == nil {
+		err = closeErr
+	}
+
+	writer.frames++
+	return err
+}
+
+//Function to finish the sequence, every frame is already saved
+func (writer *sequenceWriter) Close() error {
+	return nil
+}
@@ -0,0 +1,80 @@
+package record
+
+/*
+   Video recording of Chip-8, SCHIP, and XO-CHIP games
+
+   Saves every frame of a game, as an animated GIF, raw Y4M video, or a PNG for each frame
+*/
+
+//This is helper class for Y4M, raw video that encoders like ffmpeg and x264 read
+//A line of text for the header, then each frame is FRAME and the Y, Cb, and Cr planes, one byte a pixel each
+//We use 4:4:4, so no colors are lost to our sharp pixels, and full range, like image/color's YCbCr
+//See https://wiki.multimedia.cx/index.php/YUV4MPEG2
+
+//Imports
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"os"
+)
+
+//Writes Y4M video
+type y4mWriter struct {
+	file *os.File
+	out  *bufio.Writer
+
+	//Size of the first frame, written in the header
+	bounds  image.Rectangle
+	started bool
+
+	//Our planes, reused for every frame
+	planes []byte
+}
+
+//Function to start a Y4M video
+func newY4mWriter(path string) (Writer, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return &y4mWriter{file: file, out: bufio.NewWriter(file)}, nil
+}
+
+//Function to write a frame
+func (writer *y4mWriter) WriteFrame(picture *image.RGBA) error {
+	if !writer.started {
+		writer.bounds = picture.Bounds()
+		writer.started = true
+		writer.planes = make([]byte, writer.bounds.Dx()*writer.bounds.Dy()*3)
+		fmt.Fprintf(writer.out, "YUV4MPEG2 W%d H%d F%d:1 Ip A1:1 C444 XCOLORRANGE=FULL\n", writer.bounds.Dx(), writer.bounds.Dy(), FrameRate)
+	}
+	err := checkSize(writer.bounds, picture)
+	if err != nil {
+		return err
+	}
+
+	//Each plane is a whole frame, Y first, then Cb, then Cr
+	size := writer.bounds.Dx() * writer.bounds.Dy()
+	for i := 0; i < size; i++ {
+		y, cb, cr := color.RGBToYCbCr(picture.Pix[i*4], picture.Pix[i*4+1], picture.Pix[i*4+2])
+		writer.planes[i] = y
+		writer.planes[size+i] = cb
+		writer.planes[size*2+i] = cr
+	}
+
+	writer.out.WriteString("FRAME\n")
+	_, err = writer.out.Write(writer.planes)
+	return err
+}
+
+//Function to finish the video
+func (writer *y4mWriter) Close() error {
+	err := writer.out.Flush()
+	closeErr := writer.file.Close()
+	if err == nil {
+		err = closeErr
+	}
+	return err
+}
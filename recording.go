@@ -0,0 +1,111 @@
+package main
+
+//This is helper class for video recording, see the record package
+//Shift+F12 starts and stops recording next to the game, e.g. games/BRIX.frame120.gif, and --record-video records from the start
+//Every frame the timers tick is recorded, at our --scale and through our --filter, like screenshots
+
+import (
+	cpu "github.com/torch2424/chipGo/cpu"
+	graphics "github.com/torch2424/chipGo/graphics"
+	input "github.com/torch2424/chipGo/input"
+	record "github.com/torch2424/chipGo/record"
+	"fmt"
+)
+
+//Key to press with shift to start and stop recording
+const recordingKey = input.KeyF12
+
+//A video we are recording
+type recording struct {
+	writer      record.Writer
+	path        string
+	framebuffer *graphics.Framebuffer
+
+	//The emulator frame we recorded last
+	lastFrame int
+}
+
+//The video we are recording, nil when we aren't
+var currentRecording *recording
+
+//Function to find the file for a recording started with the hotkey, in our --record-format
+func recordingPath(gamePath string, frame int) string {
+	switch *recordFormat {
+	case "y4m":
+		return fmt.Sprintf("%s.frame%d.y4m", gamePath, frame)
+	case "png":
+		return fmt.Sprintf("%s.frame%d.%%05d.png", gamePath, frame)
+	}
+	return fmt.Sprintf("%s.frame%d.gif", gamePath, frame)
+}
+
+//Function to start or stop recording if the recording hotkey was pressed
+func handleRecordingHotkeys(emulator *cpu.Emulator, hotkeys []input.Hotkey, gamePath string) {
+	for _, hotkey := range hotkeys {
+		if hotkey.Key != recordingKey || !hotkey.Shift {
+			continue
+		}
+
+		if currentRecording != nil {
+			stopRecording()
+		} else {
+			startRecording(recordingPath(gamePath, emulator.Frames), emulator)
+		}
+	}
+}
+
+//Function to start recording, from the frame on the screen now
+func startRecording(path string, emulator *cpu.Emulator) {
+	writer, err := record.Open(path)
+	if err != nil {
+		fmt.Println("Failed starting recording:", err)
+		return
+	}
+
+	filter, _ := graphics.ParseFilter(*filterName)
+	currentRecording = &recording{
+		writer:      writer,
+		path:        path,
+		framebuffer: graphics.NewFramebuffer(*gameScale, filter),
+		lastFrame:   emulator.Frames - 1,
+	}
+	print("Recording video to ", path, ", Shift+F12 stops...\n")
+
+	recordFrames(emulator)
+}
+
+//Function to record the frames the emulator has run since we last recorded
+//The display is only recorded once a frame, what the game drew in between is part of that frame
+func recordFrames(emulator *cpu.Emulator) {
+	if currentRecording == nil {
+		return
+	}
+
+	//Frames only count up when the timers tick, so nothing is recorded while paused or rewinding
+	//Rewinding doesn't count the frames back down, so the video carries on from where the game is rewound to
+	for currentRecording.lastFrame < emulator.Frames {
+		picture := currentRecording.framebuffer.Render(graphics.Frame{Display: emulator.Cpu.GraphicsDisplay, HighRes: emulator.Cpu.HighRes})
+		err := currentRecording.writer.WriteFrame(picture)
+		if err != nil {
+			fmt.Println("Failed recording video:", err)
+			stopRecording()
+			return
+		}
+		currentRecording.lastFrame++
+	}
+}
+
+//Function to finish the video we are recording, if we are
+func stopRecording() {
+	if currentRecording == nil {
+		return
+	}
+
+	err := currentRecording.writer.Close()
+	if err != nil {
+		fmt.Println("Failed saving recording:", err)
+	} else {
+		print("Saved video ", currentRecording.path, "\n")
+	}
+	currentRecording = nil
+}
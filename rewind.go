@@ -0,0 +1,47 @@
+package main
+
+//This is helper class for rewinding, see the rewind package
+//Every frame is saved, and holding Backspace plays them back in reverse
+
+import (
+	cpu "github.com/torch2424/chipGo/cpu"
+	input "github.com/torch2424/chipGo/input"
+	rewind "github.com/torch2424/chipGo/rewind"
+	"fmt"
+)
+
+//Key to hold for rewinding
+const rewindKey = input.KeyBackspace
+
+//Function to check if we should be rewinding
+func isRewinding(buffer *rewind.Buffer) bool {
+	return buffer != nil && input.IsKeyHeld(rewindKey)
+}
+
+//Function to save the current frame for rewinding
+func saveRewindFrame(emulator *cpu.Emulator, buffer *rewind.Buffer) {
+	if buffer == nil {
+		return
+	}
+
+	err := buffer.Push(emulator.Cpu)
+	if err != nil {
+		fmt.Println("Failed saving rewind frame:", err)
+	}
+}
+
+//Function to go back one frame, and draw it
+//Once we run out of frames, the game stays on the oldest one until the key is let go
+func rewindFrame(emulator *cpu.Emulator, buffer *rewind.Buffer) {
+	previous, rewound, err := buffer.Pop(emulator.Cpu)
+	if err != nil {
+		fmt.Println("Failed rewinding:", err)
+		return
+	}
+	if !rewound {
+		return
+	}
+
+	emulator.Cpu = previous
+	emulator.Redraw()
+}
@@ -0,0 +1,117 @@
+package rewind
+
+/*
+   Rewind buffer for Chip-8, SCHIP, and XO-CHIP games
+
+   Keeps a save state of every frame, so a game can be played backwards
+*/
+
+//Save states are mostly memory that doesn't change, so each one is compressed with flate
+//The buffer is a ring, once it is full the oldest frame is replaced
+
+//Imports
+import (
+	cpu "github.com/torch2424/chipGo/cpu"
+	"bytes"
+	"compress/flate"
+	"io/ioutil"
+)
+
+//Buffer is a ring of compressed save states, one per frame
+type Buffer struct {
+	frames [][]byte
+
+	//Where the oldest frame is, and how many frames we have
+	start int
+	count int
+
+	//Reused between frames, so we don't make a new compressor each time
+	compressed bytes.Buffer
+	writer     *flate.Writer
+}
+
+//Function to make a buffer that holds a number of frames
+func NewBuffer(frames int) *Buffer {
+	if frames < 1 {
+		frames = 1
+	}
+
+	return &Buffer{frames: make([][]byte, frames)}
+}
+
+//Function to find how many frames we keep, for a number of seconds
+func FramesForSeconds(seconds float64) int {
+	return int(seconds * cpu.TimerSpeed)
+}
+
+//Function to save a frame, replacing the oldest frame if we are full
+func (buffer *Buffer) Push(chipCpu cpu.Cpu) error {
+
+	state, err := cpu.SaveState(chipCpu)
+	if err != nil {
+		return err
+	}
+
+	buffer.compressed.Reset()
+	if buffer.writer == nil {
+		buffer.writer, err = flate.NewWriter(&buffer.compressed, flate.BestSpeed)
+		if err != nil {
+			return err
+		}
+	} else {
+		buffer.writer.Reset(&buffer.compressed)
+	}
+
+	_, err = buffer.writer.Write(state)
+	if err == nil {
+		err = buffer.writer.Close()
+	}
+	if err != nil {
+		return err
+	}
+
+	//Copy out of our compressor's buffer, reusing the slice of the frame we replace
+	index := (buffer.start + buffer.count) % len(buffer.frames)
+	buffer.frames[index] = append(buffer.frames[index][:0], buffer.compressed.Bytes()...)
+	if buffer.count < len(buffer.frames) {
+		buffer.count++
+	} else {
+		buffer.start = (buffer.start + 1) % len(buffer.frames)
+	}
+
+	return nil
+}
+
+//Function to take the newest frame off the buffer, and load it into the cpu
+//Returns false if there are no frames left
+func (buffer *Buffer) Pop(chipCpu cpu.Cpu) (cpu.Cpu, bool, error) {
+	if buffer.count == 0 {
+		return chipCpu, false, nil
+	}
+
+	index := (buffer.start + buffer.count - 1) % len(buffer.frames)
+	buffer.count--
+
+	state, err := ioutil.ReadAll(flate.NewReader(bytes.NewReader(buffer.frames[index])))
+	if err != nil {
+		return chipCpu, false, err
+	}
+
+	chipCpu, err = cpu.LoadState(chipCpu, state)
+	if err != nil {
+		return chipCpu, false, err
+	}
+
+	return chipCpu, true, nil
+}
+
+//Function to find how many frames we can rewind
+func (buffer *Buffer) Len() int {
+	return buffer.count
+}
+
+//Function to forget every frame, e.g. after loading a save state
+func (buffer *Buffer) Clear() {
+	buffer.start = 0
+	buffer.count = 0
+}
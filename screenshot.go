@@ -0,0 +1,86 @@
+package main
+
+//This is helper class for screenshots, see the screenshot package
+//F12 saves what is on the screen next to the game, e.g. games/BRIX.frame120.png, and --screenshot-at-frame saves one at a frame
+//Screenshots are drawn at our --scale and through our --filter, like the window
+
+import (
+	cpu "github.com/torch2424/chipGo/cpu"
+	graphics "github.com/torch2424/chipGo/graphics"
+	input "github.com/torch2424/chipGo/input"
+	movie "github.com/torch2424/chipGo/movie"
+	screenshot "github.com/torch2424/chipGo/screenshot"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+)
+
+//Key to press to save a screenshot
+const screenshotKey = input.KeyF12
+
+//If we saved the screenshot for --screenshot-at-frame yet
+var screenshotTaken bool
+
+//Function to find the file for a screenshot
+func screenshotPath(gamePath string, frame int) string {
+	return fmt.Sprintf("%s.frame%d.png", gamePath, frame)
+}
+
+//Function to save a screenshot if the screenshot hotkey was pressed
+func handleScreenshotHotkeys(emulator *cpu.Emulator, hotkeys []input.Hotkey, gamePath string) {
+	for _, hotkey := range hotkeys {
+		if hotkey.Key == screenshotKey && !hotkey.Shift {
+			saveScreenshot(emulator, gamePath)
+		}
+	}
+}
+
+//Function to save a screenshot once we reach --screenshot-at-frame
+func checkScreenshotFrame(emulator *cpu.Emulator, gamePath string) {
+	if *screenshotFrame <= 0 || screenshotTaken || emulator.Frames < *screenshotFrame {
+		return
+	}
+
+	screenshotTaken = true
+	saveScreenshot(emulator, gamePath)
+}
+
+//Function to save what is on the screen as a PNG
+func saveScreenshot(emulator *cpu.Emulator, gamePath string) {
+	filter, _ := graphics.ParseFilter(*filterName)
+	framebuffer := graphics.NewFramebuffer(*gameScale, filter)
+	picture := framebuffer.Render(graphics.Frame{Display: emulator.Cpu.GraphicsDisplay, HighRes: emulator.Cpu.HighRes})
+
+	var text []screenshot.Text
+	if *screenshotMetadata {
+		text = screenshotText(emulator, gamePath)
+	}
+
+	path := screenshotPath(gamePath, emulator.Frames)
+	err := screenshot.Save(path, picture, text)
+	if err != nil {
+		fmt.Println("Failed saving screenshot:", err)
+		return
+	}
+
+	print("Saved screenshot ", path, "\n")
+}
+
+//Function to describe where a screenshot came from, the game, its hash, the frame, and the program counter
+func screenshotText(emulator *cpu.Emulator, gamePath string) []screenshot.Text {
+	text := []screenshot.Text{
+		{Keyword: "Software", Value: "chipGo"},
+		{Keyword: "Title", Value: filepath.Base(gamePath)},
+	}
+
+	//The hash is of the game file, like movies, see movie.HashRom
+	game, err := ioutil.ReadFile(gamePath)
+	if err == nil {
+		text = append(text, screenshot.Text{Keyword: "ROM SHA-256", Value: fmt.Sprintf("%x", movie.HashRom(game))})
+	}
+
+	return append(text,
+		screenshot.Text{Keyword: "Frame", Value: fmt.Sprint(emulator.Frames)},
+		screenshot.Text{Keyword: "Program Counter", Value: fmt.Sprintf("0x%04X", cpu.GetProgramCounter(emulator.Cpu))},
+	)
+}
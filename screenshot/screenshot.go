@@ -0,0 +1,88 @@
+package screenshot
+
+/*
+   Screenshots of Chip-8, SCHIP, and XO-CHIP games
+
+   Saves what is on the screen as a PNG, with text about where it came from, e.g. the game's hash and frame
+*/
+
+//image/png can't write text chunks, so we encode the image, then add tEXt chunks before the end
+//See https://www.w3.org/TR/png/#11tEXt
+
+//Imports
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"io"
+	"io/ioutil"
+)
+
+//Text is a keyword and its value, saved in the PNG
+//Keywords are 1 to 79 letters, e.g. Software or Frame
+type Text struct {
+	Keyword string
+	Value   string
+}
+
+//Size of the IEND chunk every PNG ends with, its length, type, and CRC
+const iendSize = 12
+
+//Function to write an image as a PNG, with text
+func Encode(writer io.Writer, picture image.Image, text []Text) error {
+	var encoded bytes.Buffer
+	err := png.Encode(&encoded, picture)
+	if err != nil {
+		return err
+	}
+
+	data := encoded.Bytes()
+	end := len(data) - iendSize
+	if end < 0 || string(data[end+4:end+8]) != "IEND" {
+		return fmt.Errorf("PNG didn't end with IEND")
+	}
+
+	//Everything up to the end, then our text, then the end
+	var out bytes.Buffer
+	out.Write(data[:end])
+	for _, entry := range text {
+		err = writeTextChunk(&out, entry)
+		if err != nil {
+			return err
+		}
+	}
+	out.Write(data[end:])
+
+	_, err = writer.Write(out.Bytes())
+	return err
+}
+
+//Function to save an image as a PNG file, with text
+func Save(path string, picture image.Image, text []Text) error {
+	var out bytes.Buffer
+	err := Encode(&out, picture, text)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, out.Bytes(), 0644)
+}
+
+//Function to write a tEXt chunk, its length, type, keyword, a zero, the value, and a CRC of the type and data
+func writeTextChunk(out *bytes.Buffer, entry Text) error {
+	if len(entry.Keyword) < 1 || len(entry.Keyword) > 79 {
+		return fmt.Errorf("PNG text keywords are 1 to 79 letters, %q isn't", entry.Keyword)
+	}
+
+	chunk := []byte("tEXt")
+	chunk = append(chunk, entry.Keyword...)
+	chunk = append(chunk, 0)
+	chunk = append(chunk, entry.Value...)
+
+	binary.Write(out, binary.BigEndian, uint32(len(chunk)-4))
+	out.Write(chunk)
+	binary.Write(out, binary.BigEndian, crc32.ChecksumIEEE(chunk))
+	return nil
+}
@@ -0,0 +1,20 @@
+//go:build !cgo
+// +build !cgo
+
+package sound
+
+//Without cgo we can't open OpenAL to play sounds, so the game runs silently, see sound.go
+
+//Our AudioPlayer struct for accessing the class in a state
+type AudioPlayer struct{}
+
+//Function to intialize our audioplayer
+func NewAudioPlayer(debug bool) AudioPlayer {
+	print("\nchipGo was built without cgo, so there is no sound...\n")
+	return AudioPlayer{}
+}
+
+//AudioPlayer is a cpu.PatternBeeper, but plays nothing
+func (audioPlayer AudioPlayer) Beep() {}
+
+func (audioPlayer AudioPlayer) BeepPattern(pattern [16]byte, pitch uint8) {}
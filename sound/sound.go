@@ -1,9 +1,15 @@
+//go:build cgo
+// +build cgo
+
 //Using this lib: https://godoc.org/golang.org/x/mobile/exp/audio#Player.Play
 //And this example https://github.com/golang/mobile/blob/master/example/audio/main.go
 
 package sound
 
+//golang.org/x/mobile/exp/audio plays sounds with OpenAL, which needs cgo, see nosound.go for builds without it
+
 import (
+	"bytes"
 	"golang.org/x/mobile/exp/audio"
 	"os"
 )
@@ -17,6 +23,12 @@ var audioBroken bool
 //Debug mode
 var debugMode bool
 
+//Player for the last XO-CHIP audio pattern we synthesized, and the pattern it was made from
+//So we only rebuild the sound when the game changes the pattern or pitch
+var patternPlayer *audio.Player
+var lastPattern [16]byte
+var lastPitch uint8
+
 //Our AudioPlayer struct for accessing the class in a state
 type AudioPlayer struct {
 	//Our player object
@@ -67,3 +79,47 @@ func PlayBlip(audioPlayer AudioPlayer) {
 		audioPlayer.sound.Play()
 	}
 }
+
+//Function to play an XO-CHIP audio pattern
+//The pattern is 128 1-bit samples, played back at 4000*2^((pitch-64)/48) samples per second
+func PlayPattern(audioPlayer AudioPlayer, pattern [16]byte, pitch uint8) {
+
+	//Build a new player if the game changed the sound
+	if patternPlayer == nil || pattern != lastPattern || pitch != lastPitch {
+		if patternPlayer != nil {
+			patternPlayer.Close()
+		}
+
+		player, err := audio.NewPlayer(wavReader{bytes.NewReader(patternWav(pattern, pitch))}, 0, 0)
+		if err != nil {
+			soundError(err)
+			return
+		}
+
+		patternPlayer = player
+		lastPattern = pattern
+		lastPitch = pitch
+	}
+
+	if patternPlayer.State() != audio.Playing {
+		patternPlayer.Play()
+	}
+}
+
+//Our synthesized wavs are in memory, so wrap the reader with a Close for the audio player
+type wavReader struct {
+	*bytes.Reader
+}
+
+func (reader wavReader) Close() error {
+	return nil
+}
+
+//AudioPlayer is a cpu.PatternBeeper, so the emulator can play our sounds
+func (audioPlayer AudioPlayer) Beep() {
+	PlayBlip(audioPlayer)
+}
+
+func (audioPlayer AudioPlayer) BeepPattern(pattern [16]byte, pitch uint8) {
+	PlayPattern(audioPlayer, pattern, pitch)
+}
@@ -0,0 +1,58 @@
+package sound
+
+//This is helper class for synthesizing XO-CHIP audio patterns into wav files
+//Synthesizing doesn't need cgo, only playing the sound does, see sound.go
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+)
+
+//Sample rate we synthesize XO-CHIP audio patterns at
+const patternSampleRate = 44100
+
+//How long one XO-CHIP pattern blip lasts, in seconds
+const patternLength = 0.1
+
+//Function to synthesize an XO-CHIP audio pattern into an 8 bit mono wav file
+func patternWav(pattern [16]byte, pitch uint8) []byte {
+
+	//Find how fast we step through the pattern's bits
+	patternRate := 4000 * math.Pow(2, (float64(pitch)-64)/48)
+	step := patternRate / patternSampleRate
+
+	//Create our samples, looping over the pattern
+	samples := make([]byte, int(patternSampleRate*patternLength))
+	position := 0.0
+	for i := 0; i < len(samples); i++ {
+		bit := int(position) % 128
+		if pattern[bit/8]&(0x80>>uint(bit%8)) != 0 {
+			samples[i] = 0xC0
+		} else {
+			samples[i] = 0x40
+		}
+		position = position + step
+	}
+
+	//Write the wav header, see: http://soundfile.sapp.org/doc/WaveFormat/
+	var wav bytes.Buffer
+	wav.WriteString("RIFF")
+	binary.Write(&wav, binary.LittleEndian, uint32(36+len(samples)))
+	wav.WriteString("WAVEfmt ")
+	binary.Write(&wav, binary.LittleEndian, uint32(16))
+	//PCM, mono
+	binary.Write(&wav, binary.LittleEndian, uint16(1))
+	binary.Write(&wav, binary.LittleEndian, uint16(1))
+	//Sample rate and byte rate are the same at 8 bits mono
+	binary.Write(&wav, binary.LittleEndian, uint32(patternSampleRate))
+	binary.Write(&wav, binary.LittleEndian, uint32(patternSampleRate))
+	//Block align and bits per sample
+	binary.Write(&wav, binary.LittleEndian, uint16(1))
+	binary.Write(&wav, binary.LittleEndian, uint16(8))
+	wav.WriteString("data")
+	binary.Write(&wav, binary.LittleEndian, uint32(len(samples)))
+	wav.Write(samples)
+
+	return wav.Bytes()
+}
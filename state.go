@@ -0,0 +1,79 @@
+package main
+
+//This is helper class for save state slots, see cpu/state.go
+//F1 to F9 load a slot, and Shift + F1 to F9 save to it
+//Slots are saved next to the game, e.g. games/BRIX.state1
+//Loading a slot forgets the frames we could rewind, so rewinding never goes back past it
+
+import (
+	cpu "github.com/torch2424/chipGo/cpu"
+	input "github.com/torch2424/chipGo/input"
+	rewind "github.com/torch2424/chipGo/rewind"
+	"fmt"
+	"io/ioutil"
+)
+
+//Our save slot hotkeys, and the slot they use
+var stateSlots = map[input.Key]int{
+	input.KeyF1: 1,
+	input.KeyF2: 2,
+	input.KeyF3: 3,
+	input.KeyF4: 4,
+	input.KeyF5: 5,
+	input.KeyF6: 6,
+	input.KeyF7: 7,
+	input.KeyF8: 8,
+	input.KeyF9: 9,
+}
+
+//Function to find the file for a save slot
+func stateSlotPath(gamePath string, slot int) string {
+	return fmt.Sprintf("%s.state%d", gamePath, slot)
+}
+
+//Function to save and load states for any hotkeys that were pressed
+func handleStateHotkeys(emulator *cpu.Emulator, hotkeys []input.Hotkey, gamePath string, rewindBuffer *rewind.Buffer) {
+	for _, hotkey := range hotkeys {
+		slot, isSlot := stateSlots[hotkey.Key]
+		if !isSlot {
+			continue
+		}
+
+		if hotkey.Shift {
+			saveStateSlot(emulator, gamePath, slot)
+		} else {
+			loadStateSlot(emulator, gamePath, slot, rewindBuffer)
+		}
+	}
+}
+
+//Function to save the game to a slot
+func saveStateSlot(emulator *cpu.Emulator, gamePath string, slot int) {
+	state, err := emulator.SaveState()
+	if err == nil {
+		err = ioutil.WriteFile(stateSlotPath(gamePath, slot), state, 0644)
+	}
+	if err != nil {
+		fmt.Println("Failed saving state", slot, ":", err)
+		return
+	}
+
+	print("Saved state ", slot, "\n")
+}
+
+//Function to load the game from a slot
+func loadStateSlot(emulator *cpu.Emulator, gamePath string, slot int, rewindBuffer *rewind.Buffer) {
+	state, err := ioutil.ReadFile(stateSlotPath(gamePath, slot))
+	if err == nil {
+		err = emulator.LoadState(state)
+	}
+	if err != nil {
+		fmt.Println("Failed loading state", slot, ":", err)
+		return
+	}
+	if rewindBuffer != nil {
+		rewindBuffer.Clear()
+	}
+
+	print("Loaded state ", slot, "\n")
+}
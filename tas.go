@@ -0,0 +1,124 @@
+package main
+
+//This is helper class for the tool-assisted run editor, see the tas package
+//With --tas the game starts paused, Space pauses and unpauses, Enter runs one frame, and the keypad keys toggle keys on and off
+//Shift + F1 to Shift + F9 save a branch, F1 to F9 go back to it, and F11 saves the run as a movie, which also happens when the game ends
+//If the movie already exists, the run carries on from the end of it
+
+import (
+	cpu "github.com/torch2424/chipGo/cpu"
+	input "github.com/torch2424/chipGo/input"
+	movie "github.com/torch2424/chipGo/movie"
+	tas "github.com/torch2424/chipGo/tas"
+	"fmt"
+	"os"
+)
+
+//Our tool-assisted run editor hotkeys
+const (
+	tasPauseKey   = input.KeySpace
+	tasAdvanceKey = input.KeyEnter
+	tasExportKey  = input.KeyF11
+)
+
+//Function to start editing a run, carrying on from the movie if it exists
+//The frames of the movie are run straight away, without drawing
+func startTas(chipCpu cpu.Cpu, game []byte, settings movie.Settings) (cpu.Cpu, *tas.Editor) {
+	run := movie.New(settings)
+	_, err := os.Stat(*tasPath)
+	if err == nil {
+		run, err = movie.ReadFile(*tasPath)
+		if err == nil {
+			err = run.Settings.Check(game, cpu.GetMode(chipCpu))
+		}
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		chipCpu = run.Settings.Apply(chipCpu)
+	}
+
+	//Catch up to the end of the movie
+	emulator := cpu.NewEmulator(chipCpu, nil, nil, nil)
+	player := movie.NewPlayer(run)
+	emulator.Keys = player
+	instructions := cpu.InstructionsPerFrame(int(run.Settings.Speed))
+	for !emulator.Cpu.Exit {
+		playing, err := runMovieFrame(emulator, emulator, player, instructions)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if !playing {
+			break
+		}
+	}
+
+	editor := tas.New(run)
+	print("Editing run ", *tasPath, ". Space pauses, Enter runs a frame, and the keypad keys toggle keys\n")
+	print("Shift + F1 to F9 saves a branch, F1 to F9 goes back to it, and F11 saves the run\n")
+	print(editor.Status(), "\n")
+
+	return emulator.Cpu, editor
+}
+
+//Function to handle our tool-assisted run editor hotkeys, if we are editing a run
+func handleTasHotkeys(emulator *cpu.Emulator, keys movieKeys, hotkeys []input.Hotkey) {
+	editor, isEditing := keys.(*tas.Editor)
+	if !isEditing {
+		return
+	}
+
+	for _, hotkey := range hotkeys {
+		keypad, isKeypad := input.KeypadKey(hotkey.Key)
+		slot, isSlot := stateSlots[hotkey.Key]
+		switch {
+		case isKeypad:
+			editor.Toggle(keypad)
+			break
+		case hotkey.Key == tasPauseKey:
+			editor.TogglePause()
+			break
+		case hotkey.Key == tasAdvanceKey:
+			editor.Advance()
+			continue
+		case hotkey.Key == tasExportKey:
+			saveTas(editor)
+			continue
+		case isSlot && hotkey.Shift:
+			editor.SaveBranch(slot, emulator.Cpu)
+			print("Saved branch ", slot, "\n")
+			break
+		case isSlot:
+			chipCpu, err := editor.LoadBranch(slot)
+			if err != nil {
+				fmt.Println(err)
+				continue
+			}
+			emulator.Cpu = chipCpu
+			emulator.Redraw()
+			print("Back to branch ", slot, "\n")
+			break
+		default:
+			continue
+		}
+
+		print(editor.Status(), "\n")
+	}
+}
+
+//Function to check if the run we are editing should run a frame now
+func isTasPaused(keys movieKeys) bool {
+	editor, isEditing := keys.(*tas.Editor)
+	return isEditing && !editor.ShouldRun()
+}
+
+//Function to save the run we are editing as a movie
+func saveTas(editor *tas.Editor) {
+	err := editor.Movie().WriteFile(*tasPath)
+	if err != nil {
+		fmt.Println("Failed saving run:", err)
+		return
+	}
+	print("Saved ", len(editor.Movie().Frames), " frames of run to ", *tasPath, "\n")
+}
@@ -0,0 +1,147 @@
+package tas
+
+/*
+   Tool-assisted run editor for Chip-8, SCHIP, and XO-CHIP games
+
+   Plays a game one frame at a time, choosing the keys for every frame, and saves the keys as a movie
+*/
+
+//The Editor is the keys for each frame, like a movie.Recorder, except keys are toggled on and off instead of held
+//Toggled keys stay held on every frame after, until they are toggled off
+//Branches are snapshots of the cpu and the keys so far, kept in memory, to try something and go back
+
+//Imports
+import (
+	cpu "github.com/torch2424/chipGo/cpu"
+	movie "github.com/torch2424/chipGo/movie"
+	"fmt"
+	"strings"
+)
+
+//How many branches we keep
+const Branches = 9
+
+//A branch, the cpu and the frames that led to it
+type branch struct {
+	chipCpu cpu.Cpu
+	frames  []uint16
+	keys    [16]bool
+}
+
+//Editor is a tool-assisted run being edited
+type Editor struct {
+	movie *movie.Movie
+
+	//The keys for the next frame, and the keys of the frame running now
+	keys    [16]bool
+	current [16]bool
+
+	//Paused games only run frames we advance to
+	paused   bool
+	advances int
+
+	branches [Branches]*branch
+}
+
+//Function to make an editor, continuing from the frames of a movie
+//The editor starts paused
+func New(run *movie.Movie) *Editor {
+	editor := &Editor{movie: run, paused: true}
+	if len(run.Frames) > 0 {
+		editor.keys = movie.UnpackKeys(run.Frames[len(run.Frames)-1])
+	}
+	return editor
+}
+
+//Function to toggle a key on or off, from the next frame on
+func (editor *Editor) Toggle(key int) {
+	editor.keys[key] = !editor.keys[key]
+}
+
+//Function to pause or unpause, unpaused games run a frame every tick of our timers
+func (editor *Editor) TogglePause() {
+	editor.paused = !editor.paused
+	editor.advances = 0
+}
+
+//Function to run one more frame while paused
+func (editor *Editor) Advance() {
+	editor.advances++
+}
+
+//Function to check if we should run a frame now
+func (editor *Editor) ShouldRun() bool {
+	return !editor.paused || editor.advances > 0
+}
+
+//Function to start the next frame, recording its keys, see movie.Recorder
+//Always returns true, the run doesn't end until we stop it
+func (editor *Editor) NextFrame() bool {
+	if editor.advances > 0 {
+		editor.advances--
+	}
+
+	editor.current = editor.keys
+	editor.movie.Frames = append(editor.movie.Frames, movie.PackKeys(editor.current))
+	return true
+}
+
+//Function to get the keys of this frame, see cpu.KeySource
+func (editor *Editor) GetKeyArray() ([16]bool, bool) {
+	return editor.current, movie.PackKeys(editor.current) != 0
+}
+
+//Function to save a branch, a snapshot of the cpu and the frames so far
+func (editor *Editor) SaveBranch(slot int, chipCpu cpu.Cpu) error {
+	if slot < 1 || slot > Branches {
+		return fmt.Errorf("Branches are 1 to %d", Branches)
+	}
+
+	frames := make([]uint16, len(editor.movie.Frames))
+	copy(frames, editor.movie.Frames)
+	editor.branches[slot-1] = &branch{chipCpu: chipCpu, frames: frames, keys: editor.keys}
+	return nil
+}
+
+//Function to go back to a branch, returns the cpu to carry on from
+//The frames after the branch are thrown away, save another branch first to keep them
+func (editor *Editor) LoadBranch(slot int) (cpu.Cpu, error) {
+	if slot < 1 || slot > Branches || editor.branches[slot-1] == nil {
+		return cpu.Cpu{}, fmt.Errorf("There is no branch %d", slot)
+	}
+
+	saved := editor.branches[slot-1]
+	editor.movie.Frames = make([]uint16, len(saved.frames))
+	copy(editor.movie.Frames, saved.frames)
+	editor.keys = saved.keys
+	editor.paused = true
+	editor.advances = 0
+
+	return saved.chipCpu, nil
+}
+
+//Function to get the run so far, as a movie
+func (editor *Editor) Movie() *movie.Movie {
+	return editor.movie
+}
+
+//Function to describe where we are, e.g. Frame 120, paused, holding keys 5 6
+func (editor *Editor) Status() string {
+	state := "running"
+	if editor.paused {
+		state = "paused"
+	}
+
+	held := make([]string, 0)
+	for key, pressed := range editor.keys {
+		if pressed {
+			held = append(held, fmt.Sprintf("%X", key))
+		}
+	}
+	keys := "no keys"
+	if len(held) > 0 {
+		keys = "keys " + strings.Join(held, " ")
+	}
+
+	return fmt.Sprintf("Frame %d, %s, holding %s", len(editor.movie.Frames), state, keys)
+}
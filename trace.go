@@ -0,0 +1,72 @@
+package main
+
+//This is helper class for tracing instructions, see the trace package
+//With --trace every instruction is written to a file, or - for the terminal
+//With --trace-ring only the last instructions are kept, and written if the game hits an error
+
+import (
+	cpu "github.com/torch2424/chipGo/cpu"
+	trace "github.com/torch2424/chipGo/trace"
+	"fmt"
+	"os"
+)
+
+//Function to find our trace options from the command line
+func traceOptions() (trace.Options, error) {
+	format, err := trace.ParseFormat(*traceFormat)
+	if err != nil {
+		return trace.Options{}, err
+	}
+
+	options := trace.Options{Format: format, RingSize: *traceRing}
+	for _, text := range *traceRanges {
+		addresses, err := trace.ParseRange(text)
+		if err != nil {
+			return trace.Options{}, err
+		}
+		options.Ranges = append(options.Ranges, addresses)
+	}
+
+	return options, nil
+}
+
+//Function to start tracing the emulator, if asked to
+//Returns a function to finish writing the trace, call it once the game ends
+func startTrace(emulator *cpu.Emulator) (func(), error) {
+	if *tracePath == "" {
+		return func() {}, nil
+	}
+
+	//Text traces start with our random number generator, so tracediff makes the same numbers
+	options, err := traceOptions()
+	if err != nil {
+		return nil, err
+	}
+	random := emulator.Cpu.Random
+	options.Random = &random
+	file := os.Stdout
+	if *tracePath != "-" {
+		file, err = os.Create(*tracePath)
+		if err != nil {
+			return nil, fmt.Errorf("Failed creating trace: %s", err)
+		}
+	}
+
+	tracer := trace.New(file, options)
+	emulator.Tracer = tracer
+	if options.RingSize > 0 {
+		print("Tracing the last ", options.RingSize, " instructions to ", *tracePath, " if the game hits an error...\n")
+	} else {
+		print("Tracing instructions to ", *tracePath, "...\n")
+	}
+
+	return func() {
+		err := tracer.Flush()
+		if err != nil {
+			fmt.Println("Failed writing trace:", err)
+		}
+		if file != os.Stdout {
+			file.Close()
+		}
+	}, nil
+}
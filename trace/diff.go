@@ -0,0 +1,320 @@
+package trace
+
+/*
+   Instruction traces for Chip-8, SCHIP, and XO-CHIP games
+
+   A record of every instruction the cpu runs, as text or JSON lines, for finding bugs and comparing emulators
+*/
+
+//This is helper class for comparing a game as it runs against a reference trace, e.g. from another emulator
+//The Differ plugs into an Emulator like the Tracer, and stops at the first instruction that doesn't match
+//Reference traces can skip instructions, e.g. when traced with --trace-range, only the cycles they have are compared
+//The text of each instruction is not compared, so traces from other disassemblers still match
+//Our text traces say which random numbers they were made with, see Random
+
+//Imports
+import (
+	cpu "github.com/torch2424/chipGo/cpu"
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+//How many of our instructions before a divergence to show
+const historySize = 16
+
+//Divergence is the first instruction that didn't match the reference
+type Divergence struct {
+
+	//The instruction we expected, and the one we ran
+	//Actual is nil if the game stopped before the reference did
+	Expected Record
+	Actual   *Record
+
+	//Our last instructions, oldest first, not including Actual
+	History []Record
+
+	//The cpu before and after the instruction that didn't match
+	Before cpu.Cpu
+	After  cpu.Cpu
+}
+
+//Differ compares instructions against a reference trace, see cpu.Tracer
+type Differ struct {
+	reference *bufio.Scanner
+	line      int
+
+	//The next reference record to compare, nil once the reference has ended
+	next *Record
+
+	//How many instructions have run, and how many were compared
+	cycle    uint64
+	compared int
+
+	//Our last instructions, for showing what led up to a divergence
+	history []Record
+
+	//The random number generator from the reference's comments, nil if it didn't have one
+	random *cpu.Random
+
+	divergence *Divergence
+	err        error
+}
+
+//Function to construct a new Differ, reading the reference trace from reader
+func NewDiffer(reference io.Reader) *Differ {
+	differ := &Differ{reference: bufio.NewScanner(reference)}
+	differ.reference.Buffer(make([]byte, 64*1024), 1024*1024)
+	differ.readNext()
+
+	return differ
+}
+
+//Function to get the random number generator the reference trace started with
+//Returns nil if it doesn't say, e.g. JSON traces and traces from other emulators
+//The first record has been read by NewDiffer, so this can be called straight away
+func (differ *Differ) Random() *cpu.Random {
+	return differ.random
+}
+
+//Function to read the next reference record, skipping blank lines and comments
+func (differ *Differ) readNext() {
+	differ.next = nil
+	for differ.err == nil && differ.reference.Scan() {
+		differ.line++
+
+		text := strings.TrimSpace(differ.reference.Text())
+		random, isRandom := parseRandom(text)
+		if isRandom && differ.random == nil {
+			differ.random = &random
+		}
+		if text == "" || strings.HasPrefix(text, ";") || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		record, err := ParseRecord(text)
+		if err != nil {
+			differ.err = fmt.Errorf("reference trace line %d: %s", differ.line, err)
+			return
+		}
+		if record.Cycle < differ.cycle {
+			differ.err = fmt.Errorf("reference trace line %d: cycle %d is out of order", differ.line, record.Cycle)
+			return
+		}
+		differ.next = &record
+		return
+	}
+
+	if differ.err == nil && differ.reference.Err() != nil {
+		differ.err = differ.reference.Err()
+	}
+}
+
+//Function to compare an instruction against the reference, see cpu.Tracer
+func (differ *Differ) Trace(before cpu.Cpu, after cpu.Cpu, err error) {
+	cycle := differ.cycle
+	differ.cycle++
+	if differ.Done() {
+		return
+	}
+
+	//Instructions the reference skipped are only kept for context
+	actual := NewRecord(cycle, before, after, err)
+	if differ.next.Cycle != cycle {
+		differ.remember(actual)
+		return
+	}
+
+	expected := *differ.next
+	differ.compared++
+	if !matches(expected, actual) {
+		differ.divergence = &Divergence{Expected: expected, Actual: &actual, History: differ.history, Before: before, After: after}
+		return
+	}
+
+	differ.remember(actual)
+	differ.readNext()
+}
+
+//Function to add one of our instructions to the history
+func (differ *Differ) remember(record Record) {
+	if len(differ.history) == historySize {
+		differ.history = append(differ.history[:0], differ.history[1:]...)
+	}
+	differ.history = append(differ.history, record)
+}
+
+//Function to check if an instruction matches the reference
+func matches(expected Record, actual Record) bool {
+	if expected.ProgramCounter != actual.ProgramCounter || expected.OpCode != actual.OpCode {
+		return false
+	}
+	if (expected.Error == "") != (actual.Error == "") {
+		return false
+	}
+	if len(expected.Changes) != len(actual.Changes) {
+		return false
+	}
+	for name, value := range expected.Changes {
+		actualValue, changed := actual.Changes[name]
+		if !changed || actualValue != value {
+			return false
+		}
+	}
+	return true
+}
+
+//Function to check if we are done comparing
+//We are done once an instruction didn't match, the reference ended, or it couldn't be read
+func (differ *Differ) Done() bool {
+	return differ.divergence != nil || differ.next == nil || differ.err != nil
+}
+
+//Function to finish comparing, once the game has stopped
+//If the reference has instructions we never ran, that is a divergence
+func (differ *Differ) Finish(chipCpu cpu.Cpu) {
+	if differ.Done() {
+		return
+	}
+
+	differ.divergence = &Divergence{Expected: *differ.next, History: differ.history, Before: chipCpu, After: chipCpu}
+}
+
+//Function to find the first instruction that didn't match, nil if everything matched
+func (differ *Differ) Divergence() *Divergence {
+	return differ.divergence
+}
+
+//Function to find any error reading the reference trace
+func (differ *Differ) Err() error {
+	return differ.err
+}
+
+//Function to find how many instructions were compared
+func (differ *Differ) Compared() int {
+	return differ.compared
+}
+
+//Function to write a report of a divergence, with the instructions leading up to it, the registers, and memory
+func (divergence *Divergence) Report(writer io.Writer) {
+	fmt.Fprintf(writer, "Traces differ at cycle %d\n\n", divergence.Expected.Cycle)
+	fmt.Fprintf(writer, "Expected: %s\n", divergence.Expected.Format(FormatText))
+	if divergence.Actual != nil {
+		fmt.Fprintf(writer, "Actual:   %s\n", divergence.Actual.Format(FormatText))
+		for _, difference := range differences(divergence.Expected, *divergence.Actual) {
+			fmt.Fprintf(writer, "          %s\n", difference)
+		}
+	} else {
+		fmt.Fprintf(writer, "Actual:   the game stopped before this instruction\n")
+	}
+
+	if len(divergence.History) > 0 {
+		fmt.Fprintf(writer, "\nLast %d instructions:\n", len(divergence.History))
+		for _, record := range divergence.History {
+			fmt.Fprintf(writer, "%s\n", record.Format(FormatText))
+		}
+	}
+
+	fmt.Fprintf(writer, "\nRegisters before:\n")
+	writeRegisters(writer, divergence.Before)
+	if divergence.Actual != nil {
+		fmt.Fprintf(writer, "\nRegisters after:\n")
+		writeRegisters(writer, divergence.After)
+	}
+
+	fmt.Fprintf(writer, "\nMemory at pc:\n")
+	writeMemory(writer, divergence.Before, int(cpu.GetProgramCounter(divergence.Before)))
+	fmt.Fprintf(writer, "\nMemory at i:\n")
+	writeMemory(writer, divergence.Before, int(cpu.GetIndexRegister(divergence.Before)))
+
+	//Show anything the instruction wrote to memory
+	writes := cpu.GetWrites(divergence.Before)
+	if divergence.Actual != nil && writes.MemoryLength > 0 {
+		fmt.Fprintf(writer, "\nMemory written, after:\n")
+		writeMemory(writer, divergence.After, writes.MemoryAddress)
+	}
+}
+
+//Function to list how two records differ
+func differences(expected Record, actual Record) []string {
+	found := make([]string, 0)
+	if expected.ProgramCounter != actual.ProgramCounter {
+		found = append(found, fmt.Sprintf("pc is 0x%04X, expected 0x%04X", actual.ProgramCounter, expected.ProgramCounter))
+	}
+	if expected.OpCode != actual.OpCode {
+		found = append(found, fmt.Sprintf("opCode is %04X, expected %04X", actual.OpCode, expected.OpCode))
+	}
+	if expected.Error != "" && actual.Error == "" {
+		found = append(found, fmt.Sprintf("expected the error %s", expected.Error))
+	}
+	if expected.Error == "" && actual.Error != "" {
+		found = append(found, fmt.Sprintf("hit the error %s", actual.Error))
+	}
+
+	//Every register either side changed
+	names := make(map[string]int)
+	for name := range expected.Changes {
+		names[name] = 0
+	}
+	for name := range actual.Changes {
+		names[name] = 0
+	}
+	for _, name := range sortedNames(names) {
+		expectedValue, expectedChanged := expected.Changes[name]
+		actualValue, actualChanged := actual.Changes[name]
+		switch {
+		case expectedChanged && !actualChanged:
+			found = append(found, fmt.Sprintf("%s didn't change, expected 0x%02X", name, expectedValue))
+			break
+		case !expectedChanged && actualChanged:
+			found = append(found, fmt.Sprintf("%s changed to 0x%02X, expected no change", name, actualValue))
+			break
+		case expectedValue != actualValue:
+			found = append(found, fmt.Sprintf("%s is 0x%02X, expected 0x%02X", name, actualValue, expectedValue))
+			break
+		}
+	}
+
+	return found
+}
+
+//Function to write every register, the stack, and the timers
+func writeRegisters(writer io.Writer, chipCpu cpu.Cpu) {
+	for row := 0; row < 16; row += 8 {
+		for register := row; register < row+8; register++ {
+			fmt.Fprintf(writer, "  v%X=0x%02X", register, cpu.GetRegister(chipCpu, register))
+		}
+		fmt.Fprintf(writer, "\n")
+	}
+
+	fmt.Fprintf(writer, "  i=0x%04X  pc=0x%04X  dt=0x%02X  st=0x%02X\n", cpu.GetIndexRegister(chipCpu), cpu.GetProgramCounter(chipCpu), cpu.GetDelayTimer(chipCpu), cpu.GetSoundTimer(chipCpu))
+
+	stack := cpu.GetStack(chipCpu)
+	calls := make([]string, len(stack))
+	for i, address := range stack {
+		calls[i] = fmt.Sprintf("0x%04X", address)
+	}
+	fmt.Fprintf(writer, "  stack=[%s]\n", strings.Join(calls, " "))
+}
+
+//Function to write the rows of memory around an address, 16 bytes a row
+func writeMemory(writer io.Writer, chipCpu cpu.Cpu, address int) {
+	memorySize := cpu.GetMemorySize(chipCpu)
+	start := address&^0xF - 16
+	if start < 0 {
+		start = 0
+	}
+
+	for row := start; row < start+48 && row < memorySize; row += 16 {
+		fmt.Fprintf(writer, "  0x%04X:", row)
+		for offset := 0; offset < 16 && row+offset < memorySize; offset++ {
+			marker := " "
+			if row+offset == address {
+				marker = ">"
+			}
+			fmt.Fprintf(writer, "%s%02X", marker, cpu.GetMemory(chipCpu, row+offset))
+		}
+		fmt.Fprintf(writer, "\n")
+	}
+}
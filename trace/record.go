@@ -0,0 +1,211 @@
+package trace
+
+/*
+   Instruction traces for Chip-8, SCHIP, and XO-CHIP games
+
+   A record of every instruction the cpu runs, as text or JSON lines, for finding bugs and comparing emulators
+*/
+
+//This is helper class for a single record, and reading and writing it in each format
+//Text records are one line, the cycle, address, opCode, instruction, then the registers it changed
+//       42  0x0204  7102  v1 += 0x02              ; v1=0x04
+//An instruction that hit an error ends with ! and the error
+//JSON records are one object a line
+//  {"cycle":42,"pc":516,"opcode":28930,"text":"v1 += 0x02","changes":{"v1":4}}
+
+//Imports
+import (
+	cpu "github.com/torch2424/chipGo/cpu"
+	disasm "github.com/torch2424/chipGo/disasm"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//Format is how records are written
+type Format int
+
+const (
+	//One line of text a record, easy to read
+	FormatText Format = iota
+	//One JSON object a line, easy for other tools to read
+	FormatJSON
+)
+
+//Function to find a format from it's command line name
+func ParseFormat(name string) (Format, error) {
+	switch name {
+	case "text":
+		return FormatText, nil
+	case "jsonl":
+		return FormatJSON, nil
+	}
+
+	return FormatText, fmt.Errorf("Unknown trace format: %s", name)
+}
+
+//Record is a single instruction the cpu ran
+type Record struct {
+
+	//How many instructions ran before this one
+	Cycle uint64 `json:"cycle"`
+
+	//Where the instruction is, and the instruction
+	ProgramCounter uint16 `json:"pc"`
+	OpCode         uint16 `json:"opcode"`
+	Text           string `json:"text"`
+
+	//The registers that changed, and their new values
+	//Registers are v0 to vF, i, sp for how deep the stack is, dt, and st
+	Changes map[string]int `json:"changes,omitempty"`
+
+	//The error the instruction hit, if any
+	Error string `json:"error,omitempty"`
+}
+
+//Function to find the registers of a cpu, by the names we trace them as
+func registers(chipCpu cpu.Cpu) map[string]int {
+	values := make(map[string]int, 20)
+	for i := 0; i < 16; i++ {
+		values[fmt.Sprintf("v%X", i)] = int(cpu.GetRegister(chipCpu, i))
+	}
+	values["i"] = int(cpu.GetIndexRegister(chipCpu))
+	values["sp"] = len(cpu.GetStack(chipCpu))
+	values["dt"] = int(cpu.GetDelayTimer(chipCpu))
+	values["st"] = int(cpu.GetSoundTimer(chipCpu))
+
+	return values
+}
+
+//Function to make a record of an instruction, from the cpu before and after it ran
+func NewRecord(cycle uint64, before cpu.Cpu, after cpu.Cpu, err error) Record {
+	programCounter := cpu.GetProgramCounter(before)
+	record := Record{Cycle: cycle, ProgramCounter: programCounter}
+
+	//The instruction, and the one after it for the four byte long index load
+	opCodeAt := func(address int) uint16 {
+		return uint16(cpu.GetMemory(before, address))<<8 | uint16(cpu.GetMemory(before, address+1))
+	}
+	record.OpCode = opCodeAt(int(programCounter))
+	text, _, isInstruction := disasm.Mnemonic(record.OpCode, opCodeAt(int(programCounter)+2), disasm.SyntaxOcto)
+	if !isInstruction {
+		text = "??"
+	}
+	record.Text = text
+
+	//Only keep the registers that changed
+	oldValues := registers(before)
+	for name, value := range registers(after) {
+		if oldValues[name] != value {
+			if record.Changes == nil {
+				record.Changes = make(map[string]int)
+			}
+			record.Changes[name] = value
+		}
+	}
+
+	if err != nil {
+		record.Error = err.Error()
+	}
+
+	return record
+}
+
+//Function to sort the names of changed registers, v0 to vF first, then the rest by name
+func sortedNames(changes map[string]int) []string {
+	names := make([]string, 0, len(changes))
+	for name := range changes {
+		names = append(names, name)
+	}
+
+	sort.Slice(names, func(i int, j int) bool {
+		iRegister := len(names[i]) == 2 && names[i][0] == 'v'
+		jRegister := len(names[j]) == 2 && names[j][0] == 'v'
+		if iRegister != jRegister {
+			return iRegister
+		}
+		return names[i] < names[j]
+	})
+
+	return names
+}
+
+//Function to write a record in a format, as a single line without the newline
+func (record Record) Format(format Format) string {
+	if format == FormatJSON {
+		data, _ := json.Marshal(record)
+		return string(data)
+	}
+
+	line := fmt.Sprintf("%9d  0x%04X  %04X  %-22s", record.Cycle, record.ProgramCounter, record.OpCode, record.Text)
+	if len(record.Changes) > 0 {
+		changes := make([]string, 0, len(record.Changes))
+		for _, name := range sortedNames(record.Changes) {
+			changes = append(changes, fmt.Sprintf("%s=0x%02X", name, record.Changes[name]))
+		}
+		line = line + "  ; " + strings.Join(changes, " ")
+	}
+	if record.Error != "" {
+		line = line + "  ! " + record.Error
+	}
+
+	return strings.TrimRight(line, " ")
+}
+
+//Function to read a record, in either format
+func ParseRecord(line string) (Record, error) {
+	var record Record
+	line = strings.TrimSpace(line)
+
+	if strings.HasPrefix(line, "{") {
+		err := json.Unmarshal([]byte(line), &record)
+		return record, err
+	}
+
+	//The error, then the changes, come off the end first
+	parts := strings.SplitN(line, " ! ", 2)
+	if len(parts) == 2 {
+		record.Error = strings.TrimSpace(parts[1])
+	}
+	parts = strings.SplitN(parts[0], " ; ", 2)
+	if len(parts) == 2 {
+		record.Changes = make(map[string]int)
+		for _, change := range strings.Fields(parts[1]) {
+			nameValue := strings.SplitN(change, "=", 2)
+			if len(nameValue) != 2 {
+				return record, fmt.Errorf("bad register change %s", change)
+			}
+			value, err := strconv.ParseUint(nameValue[1], 0, 16)
+			if err != nil {
+				return record, fmt.Errorf("bad register change %s", change)
+			}
+			record.Changes[nameValue[0]] = int(value)
+		}
+	}
+
+	fields := strings.Fields(parts[0])
+	if len(fields) < 3 {
+		return record, fmt.Errorf("expected cycle, address, and opCode, found %q", line)
+	}
+	cycle, err := strconv.ParseUint(fields[0], 10, 64)
+	if err != nil {
+		return record, fmt.Errorf("bad cycle %s", fields[0])
+	}
+	programCounter, err := strconv.ParseUint(fields[1], 0, 16)
+	if err != nil {
+		return record, fmt.Errorf("bad address %s", fields[1])
+	}
+	opCode, err := strconv.ParseUint(fields[2], 16, 16)
+	if err != nil {
+		return record, fmt.Errorf("bad opCode %s", fields[2])
+	}
+
+	record.Cycle = cycle
+	record.ProgramCounter = uint16(programCounter)
+	record.OpCode = uint16(opCode)
+	record.Text = strings.Join(fields[3:], " ")
+
+	return record, nil
+}
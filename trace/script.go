@@ -0,0 +1,110 @@
+package trace
+
+/*
+   Instruction traces for Chip-8, SCHIP, and XO-CHIP games
+
+   A record of every instruction the cpu runs, as text or JSON lines, for finding bugs and comparing emulators
+*/
+
+//This is helper class for input scripts, the keys to press when running a game without a window
+//Each line is a frame, and the keys held from that frame on
+//  0 5        from frame 0, hold key 5
+//  30 5 6     from frame 30, hold keys 5 and 6
+//  45         from frame 45, let go of every key
+//Keys are hex, 0 to F, frames must go up, and ; starts a comment
+
+//Imports
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+//Script is the keys to hold on each frame, see cpu.KeySource
+//Set Frame before running each frame
+type Script struct {
+	Frame int
+
+	//The frames the keys change on, and the keys from then on
+	frames []int
+	keys   [][16]bool
+}
+
+//Function to read an input script
+func ReadScript(reader io.Reader) (*Script, error) {
+	script := &Script{}
+
+	scanner := bufio.NewScanner(reader)
+	number := 0
+	for scanner.Scan() {
+		number++
+
+		text := scanner.Text()
+		comment := strings.Index(text, ";")
+		if comment >= 0 {
+			text = text[:comment]
+		}
+		fields := strings.Fields(text)
+		if len(fields) == 0 {
+			continue
+		}
+
+		frame, err := strconv.Atoi(fields[0])
+		if err != nil || frame < 0 {
+			return nil, fmt.Errorf("input script line %d: bad frame %s", number, fields[0])
+		}
+		if len(script.frames) > 0 && frame <= script.frames[len(script.frames)-1] {
+			return nil, fmt.Errorf("input script line %d: frame %d is not after frame %d", number, frame, script.frames[len(script.frames)-1])
+		}
+
+		var keys [16]bool
+		for _, field := range fields[1:] {
+			key, err := strconv.ParseUint(field, 16, 4)
+			if err != nil {
+				return nil, fmt.Errorf("input script line %d: bad key %s, keys are 0 to F", number, field)
+			}
+			keys[key] = true
+		}
+
+		script.frames = append(script.frames, frame)
+		script.keys = append(script.keys, keys)
+	}
+	if scanner.Err() != nil {
+		return nil, scanner.Err()
+	}
+
+	return script, nil
+}
+
+//Function to read an input script from a file
+func ReadScriptFile(path string) (*Script, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ReadScript(file)
+}
+
+//Function to find the keys held on the current frame
+//Returns the keys, and if any key is pressed
+func (script *Script) GetKeyArray() ([16]bool, bool) {
+	var keys [16]bool
+	for i, frame := range script.frames {
+		if frame > script.Frame {
+			break
+		}
+		keys = script.keys[i]
+	}
+
+	for _, pressed := range keys {
+		if pressed {
+			return keys, true
+		}
+	}
+	return keys, false
+}
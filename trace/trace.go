@@ -0,0 +1,195 @@
+package trace
+
+/*
+   Instruction traces for Chip-8, SCHIP, and XO-CHIP games
+
+   A record of every instruction the cpu runs, as text or JSON lines, for finding bugs and comparing emulators
+*/
+
+//The Tracer plugs into an Emulator, and is told about every instruction it runs
+//Records are written as they happen, or kept in a ring of the last few, written when the cpu hits an error
+//Text traces start with a comment of the random number generator the game started with, so tracediff can make the same numbers
+
+//Imports
+import (
+	cpu "github.com/torch2424/chipGo/cpu"
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+//Range is a range of addresses to trace, including both ends
+type Range struct {
+	Start uint16
+	End   uint16
+}
+
+//Function to read a range, e.g. 0x200-0x2FF, or a single address
+func ParseRange(text string) (Range, error) {
+	parts := strings.SplitN(text, "-", 2)
+
+	start, err := strconv.ParseUint(strings.TrimSpace(parts[0]), 0, 16)
+	if err != nil {
+		return Range{}, fmt.Errorf("Bad trace range %s, expected e.g. 0x200-0x2FF", text)
+	}
+	end := start
+	if len(parts) == 2 {
+		end, err = strconv.ParseUint(strings.TrimSpace(parts[1]), 0, 16)
+		if err != nil || end < start {
+			return Range{}, fmt.Errorf("Bad trace range %s, expected e.g. 0x200-0x2FF", text)
+		}
+	}
+
+	return Range{Start: uint16(start), End: uint16(end)}, nil
+}
+
+//Function to check if an address is in a range
+func (addresses Range) Contains(address uint16) bool {
+	return address >= addresses.Start && address <= addresses.End
+}
+
+//Options are what to trace, and how
+type Options struct {
+	Format Format
+
+	//Only instructions in these ranges are traced, every instruction if there are none
+	Ranges []Range
+
+	//Keep only the last RingSize records, and write them when the cpu hits an error
+	//0 writes every record as it happens
+	RingSize int
+
+	//The random number generator as the game starts, written at the top of text traces, nil to leave it out
+	Random *cpu.Random
+}
+
+//Tracer writes a record of each instruction, see Options
+type Tracer struct {
+	options Options
+	writer  *bufio.Writer
+
+	//How many instructions have run, including ones we didn't trace
+	cycle uint64
+
+	//The last records, oldest first starting at ringStart, when keeping a ring
+	ring      []Record
+	ringStart int
+}
+
+//Function to construct a new Tracer, writing to writer
+func New(writer io.Writer, options Options) *Tracer {
+	tracer := &Tracer{
+		options: options,
+		writer:  bufio.NewWriter(writer),
+		ring:    make([]Record, 0, options.RingSize),
+	}
+
+	//JSON traces are only records, so other tools can read every line
+	if options.Random != nil && options.Format == FormatText {
+		tracer.writer.WriteString(formatRandom(*options.Random))
+		tracer.writer.WriteString("\n")
+	}
+
+	return tracer
+}
+
+//Function to record an instruction, see cpu.Tracer
+func (tracer *Tracer) Trace(before cpu.Cpu, after cpu.Cpu, err error) {
+	cycle := tracer.cycle
+	tracer.cycle++
+
+	if !tracer.isTraced(cpu.GetProgramCounter(before)) && err == nil {
+		return
+	}
+	record := NewRecord(cycle, before, after, err)
+
+	//Without a ring, write it now
+	if tracer.options.RingSize <= 0 {
+		tracer.write(record)
+		return
+	}
+
+	//Otherwise keep it, writing over the oldest once the ring is full
+	if len(tracer.ring) < tracer.options.RingSize {
+		tracer.ring = append(tracer.ring, record)
+	} else {
+		tracer.ring[tracer.ringStart] = record
+		tracer.ringStart = (tracer.ringStart + 1) % len(tracer.ring)
+	}
+	if err != nil {
+		tracer.Dump()
+	}
+}
+
+//Function to check if we trace an address
+func (tracer *Tracer) isTraced(address uint16) bool {
+	if len(tracer.options.Ranges) == 0 {
+		return true
+	}
+
+	for _, addresses := range tracer.options.Ranges {
+		if addresses.Contains(address) {
+			return true
+		}
+	}
+	return false
+}
+
+//Function to write the records in the ring, oldest first, and empty it
+func (tracer *Tracer) Dump() error {
+	for i := range tracer.ring {
+		tracer.write(tracer.ring[(tracer.ringStart+i)%len(tracer.ring)])
+	}
+	tracer.ring = tracer.ring[:0]
+	tracer.ringStart = 0
+
+	return tracer.writer.Flush()
+}
+
+//Function to write any records that haven't been written yet
+//Records in the ring are only written by Dump
+func (tracer *Tracer) Flush() error {
+	return tracer.writer.Flush()
+}
+
+//Function to find how many instructions have run
+func (tracer *Tracer) Cycle() uint64 {
+	return tracer.cycle
+}
+
+//Function to write a random number generator as a comment, e.g. # random xorshift 0x1F2E3D4C5B6A7988 0x0
+func formatRandom(random cpu.Random) string {
+	return fmt.Sprintf("# random %s 0x%X 0x%X", random.Algorithm, random.State, random.StateHigh)
+}
+
+//Function to read a random number generator from a comment, see formatRandom
+//Returns false if the comment isn't one
+func parseRandom(line string) (cpu.Random, bool) {
+	fields := strings.Fields(line)
+	if len(fields) != 5 || fields[0] != "#" || fields[1] != "random" {
+		return cpu.Random{}, false
+	}
+
+	algorithm, err := cpu.ParseRandomAlgorithm(fields[2])
+	if err != nil {
+		return cpu.Random{}, false
+	}
+	state, err := strconv.ParseUint(fields[3], 0, 64)
+	if err != nil {
+		return cpu.Random{}, false
+	}
+	stateHigh, err := strconv.ParseUint(fields[4], 0, 64)
+	if err != nil {
+		return cpu.Random{}, false
+	}
+
+	return cpu.Random{Algorithm: algorithm, State: state, StateHigh: stateHigh}, true
+}
+
+//Function to write a single record
+func (tracer *Tracer) write(record Record) {
+	tracer.writer.WriteString(record.Format(tracer.options.Format))
+	tracer.writer.WriteString("\n")
+}
@@ -0,0 +1,72 @@
+package main
+
+//This is helper class for the tracediff command, see trace/diff.go
+//The game runs without a window, pressing keys from an input script, and is compared to a reference trace
+//Random numbers start from the generator at the top of the reference, or --seed and --random for traces without one
+
+import (
+	cpu "github.com/torch2424/chipGo/cpu"
+	trace "github.com/torch2424/chipGo/trace"
+	"fmt"
+	"os"
+)
+
+//Function to run a game, and report the first instruction that differs from the reference trace
+func runTraceDiff() {
+
+	//Read our input script, no keys are pressed without one
+	script := &trace.Script{}
+	if *diffInput != "" {
+		var err error
+		script, err = trace.ReadScriptFile(*diffInput)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+
+	//Open our reference trace
+	reference, err := os.Open(*diffReference)
+	if err != nil {
+		fmt.Println("File Not Found: ", *diffReference)
+		os.Exit(1)
+	}
+	defer reference.Close()
+
+	//Make the same random numbers as the reference
+	chipCpu := newHeadlessCpu(*diffPath)
+	differ := trace.NewDiffer(reference)
+	if *randomSeed == "" {
+		if differ.Random() == nil {
+			fmt.Println("The reference trace doesn't say which random numbers it was made with, use --seed and --random")
+			os.Exit(1)
+		}
+		chipCpu.Random = *differ.Random()
+	}
+
+	//Run the game until it stops matching, or the reference ends
+	emulator := cpu.NewEmulator(chipCpu, script, nil, nil)
+	emulator.Tracer = differ
+
+	instructions := cpu.InstructionsPerFrame(*gameSpeed)
+	for !differ.Done() && !emulator.Cpu.Exit {
+		err = emulator.RunFrame(instructions)
+		if err != nil {
+			break
+		}
+		script.Frame++
+	}
+	differ.Finish(emulator.Cpu)
+
+	//Report what we found
+	if differ.Err() != nil {
+		fmt.Println(differ.Err())
+		os.Exit(1)
+	}
+	divergence := differ.Divergence()
+	if divergence != nil {
+		divergence.Report(os.Stdout)
+		os.Exit(1)
+	}
+	fmt.Println("Traces match,", differ.Compared(), "instructions compared")
+}